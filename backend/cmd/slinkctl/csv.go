@@ -0,0 +1,253 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"slink-backend/internal/models"
+)
+
+// exportColumns are the columns links export writes. Import reads the
+// columns in importColumns by name and ignores the rest, so an export can be
+// imported into another deployment.
+var exportColumns = []string{
+	"id", "short_code", "short_url", "original_url", "custom_alias", "user_id",
+	"tags", "hide_referrer", "expires_at", "hit_count", "qr_scan_count", "created_at",
+}
+
+var importColumns = []string{"original_url", "custom_alias", "tags", "hide_referrer", "expires_at"}
+
+func exportLinks(ctx context.Context, a *app, args []string) error {
+	fs := newFlagSet("links export", "[flags] [file.csv]")
+	owner := fs.String("user", "", "only export links owned by `email or id`")
+	query := fs.String("query", "", "only export links whose destination contains `text`")
+	positional, err := parse(fs, args, 0, 1)
+	if err != nil {
+		return err
+	}
+
+	if err := a.connect(); err != nil {
+		return err
+	}
+	userID, err := ownerID(ctx, a, *owner)
+	if err != nil {
+		return err
+	}
+
+	out := a.out
+	var file *os.File
+	if len(positional) == 1 && positional[0] != "-" {
+		if file, err = os.Create(positional[0]); err != nil {
+			return err
+		}
+		defer file.Close()
+		out = file
+	}
+
+	w := csv.NewWriter(out)
+	if err := w.Write(exportColumns); err != nil {
+		return err
+	}
+	count := 0
+	err = eachLink(ctx, a, *query, deref(userID), func(l models.URL) error {
+		count++
+		return w.Write(linkRecord(a, l))
+	})
+	if err != nil {
+		return err
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+
+	if file != nil {
+		if err := file.Close(); err != nil {
+			return err
+		}
+		fmt.Fprintf(a.out, "exported %d links to %s\n", count, file.Name())
+	}
+	return nil
+}
+
+func linkRecord(a *app, l models.URL) []string {
+	var expiresAt string
+	if l.ExpiresAt != nil {
+		expiresAt = l.ExpiresAt.UTC().Format(time.RFC3339)
+	}
+	return []string{
+		l.ID,
+		l.ShortCode,
+		a.shortURL(l.ShortCode),
+		l.OriginalURL,
+		deref(l.CustomAlias),
+		deref(l.UserID),
+		strings.Join(l.Tags, ","),
+		strconv.FormatBool(l.HideReferrer),
+		expiresAt,
+		strconv.Itoa(l.HitCount),
+		strconv.Itoa(l.QRScanCount),
+		l.CreatedAt.UTC().Format(time.RFC3339),
+	}
+}
+
+func importLinks(ctx context.Context, a *app, args []string) error {
+	fs := newFlagSet("links import", "[flags] <file.csv or ->")
+	owner := fs.String("user", "", "`email or id` of the user who owns the imported links; they are anonymous if empty")
+	dryRun := fs.Bool("dry-run", false, "check the file without creating any links")
+	positional, err := parse(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+
+	var in io.Reader = os.Stdin
+	if positional[0] != "-" {
+		f, err := os.Open(positional[0])
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	requests, errs := readImport(in)
+
+	if *dryRun {
+		for _, err := range errs {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		fmt.Fprintf(a.out, "%d links to import, %d invalid rows\n", len(requests), len(errs))
+		if len(errs) > 0 {
+			return errors.New("the file has invalid rows")
+		}
+		return nil
+	}
+
+	if err := a.connect(); err != nil {
+		return err
+	}
+	userID, err := ownerID(ctx, a, *owner)
+	if err != nil {
+		return err
+	}
+
+	created := 0
+	for _, r := range requests {
+		if ctx.Err() != nil {
+			errs = append(errs, ctx.Err())
+			break
+		}
+		url, err := a.links.CreateShortURL(ctx, r.req, userID)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("line %d: %v", r.line, err))
+			continue
+		}
+		created++
+		a.record(ctx, models.AuditEvent{
+			Action:     models.AuditLinkCreated,
+			TargetType: "link",
+			TargetID:   url.ID,
+			Metadata:   map[string]string{"import": "csv"},
+		}, nil, url)
+		a.emit(ctx, url, models.WebhookLinkCreated)
+	}
+
+	for _, err := range errs {
+		fmt.Fprintln(os.Stderr, err)
+	}
+	fmt.Fprintf(a.out, "imported %d links, %d failed\n", created, len(errs))
+	if len(errs) > 0 {
+		return fmt.Errorf("%d rows were not imported", len(errs))
+	}
+	return nil
+}
+
+type importRow struct {
+	line int
+	req  models.ShortenRequest
+}
+
+// readImport parses a CSV file with a header row. Rows that can't be parsed
+// are returned as errors naming their line.
+func readImport(in io.Reader) ([]importRow, []error) {
+	r := csv.NewReader(in)
+	r.FieldsPerRecord = -1
+
+	header, err := r.Read()
+	if err != nil {
+		return nil, []error{fmt.Errorf("failed to read header: %v", err)}
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := columns["original_url"]; !ok {
+		return nil, []error{fmt.Errorf("header has no original_url column; the columns read are %s", strings.Join(importColumns, ", "))}
+	}
+
+	var rows []importRow
+	var errs []error
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			errs = append(errs, err)
+			if _, ok := err.(*csv.ParseError); ok {
+				continue
+			}
+			break
+		}
+		line, _ := r.FieldPos(0)
+
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		req, err := importRequest(field)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("line %d: %v", line, err))
+			continue
+		}
+		rows = append(rows, importRow{line: line, req: req})
+	}
+	return rows, errs
+}
+
+func importRequest(field func(string) string) (models.ShortenRequest, error) {
+	req := models.ShortenRequest{OriginalURL: field("original_url")}
+	if req.OriginalURL == "" {
+		return req, errors.New("original_url is empty")
+	}
+	if alias := field("custom_alias"); alias != "" {
+		req.CustomAlias = &alias
+	}
+	if tags := field("tags"); tags != "" {
+		req.Tags = strings.Split(tags, ",")
+	}
+	if value := field("hide_referrer"); value != "" {
+		hide, err := strconv.ParseBool(value)
+		if err != nil {
+			return req, fmt.Errorf("hide_referrer: %q is not true or false", value)
+		}
+		req.HideReferrer = hide
+	}
+	if value := field("expires_at"); value != "" {
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return req, fmt.Errorf("expires_at: %q is not an RFC 3339 time", value)
+		}
+		req.ExpiresAt = &t
+	}
+	return req, nil
+}
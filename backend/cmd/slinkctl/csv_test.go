@@ -0,0 +1,68 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadImport(t *testing.T) {
+	in := strings.Join([]string{
+		"Original_URL,custom_alias,tags,hide_referrer,expires_at,hit_count",
+		"https://example.com/a,,,,,12",
+		"https://example.com/b,promo,\"x,y\",true,2030-01-02T03:04:05Z,",
+		",missing,,,,",
+		"https://example.com/c,,,maybe,,",
+		"https://example.com/d,,,,next week,",
+		"https://example.com/e",
+	}, "\n")
+
+	rows, errs := readImport(strings.NewReader(in))
+
+	if len(rows) != 3 {
+		t.Fatalf("got %d rows, want 3: %+v", len(rows), rows)
+	}
+	if rows[0].line != 2 || rows[0].req.OriginalURL != "https://example.com/a" || rows[0].req.CustomAlias != nil {
+		t.Errorf("row 0 = %+v", rows[0])
+	}
+	b := rows[1].req
+	if rows[1].line != 3 || *b.CustomAlias != "promo" || strings.Join(b.Tags, "|") != "x|y" || !b.HideReferrer ||
+		!b.ExpiresAt.Equal(time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)) {
+		t.Errorf("row 1 = line %d, %+v", rows[1].line, b)
+	}
+	if rows[2].line != 7 || rows[2].req.OriginalURL != "https://example.com/e" {
+		t.Errorf("short row = %+v", rows[2])
+	}
+
+	want := []string{
+		"line 4: original_url is empty",
+		`line 5: hide_referrer: "maybe" is not true or false`,
+		`line 6: expires_at: "next week" is not an RFC 3339 time`,
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("errors = %v, want %d", errs, len(want))
+	}
+	for i, err := range errs {
+		if err.Error() != want[i] {
+			t.Errorf("error %d = %q, want %q", i, err, want[i])
+		}
+	}
+}
+
+func TestReadImportNeedsOriginalURLColumn(t *testing.T) {
+	rows, errs := readImport(strings.NewReader("url,alias\nhttps://example.com,x\n"))
+	if len(rows) != 0 || len(errs) != 1 || !strings.Contains(errs[0].Error(), "no original_url column") {
+		t.Errorf("readImport = %v, %v", rows, errs)
+	}
+
+	if _, errs := readImport(strings.NewReader("")); len(errs) != 1 {
+		t.Errorf("empty file: errors = %v", errs)
+	}
+}
+
+func TestReadImportReportsMalformedCSV(t *testing.T) {
+	rows, errs := readImport(strings.NewReader("original_url\n\"https://example.com/a\n"))
+	if len(rows) != 0 || len(errs) != 1 || !strings.Contains(errs[0].Error(), "line 2") {
+		t.Errorf("readImport = %v, %v", rows, errs)
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const keysUsage = `keys <command> [arguments]
+
+Commands:
+  rotate [-keep n]`
+
+// jwtSecretBytes is the amount of randomness in a generated signing key.
+const jwtSecretBytes = 48
+
+func runKeys(ctx context.Context, a *app, args []string) error {
+	return subcommand(ctx, a, "keys", args, map[string]func(context.Context, *app, []string) error{
+		"rotate": rotateKeys,
+	}, keysUsage)
+}
+
+// rotateKeys prints a new JWT_SECRET, and JWT_PREVIOUS_SECRETS holding the
+// keys that should keep verifying tokens. The operator deploys them; the
+// database isn't touched.
+func rotateKeys(ctx context.Context, a *app, args []string) error {
+	fs := newFlagSet("keys rotate", "[-keep n]")
+	keep := fs.Int("keep", 1, "number of previous keys that stay valid for verifying tokens; 0 signs everyone out")
+	if _, err := parse(fs, args, 0, 0); err != nil {
+		return err
+	}
+	if *keep < 0 {
+		return fmt.Errorf("-keep: must not be negative")
+	}
+
+	cfg, err := a.config()
+	if err != nil {
+		return err
+	}
+
+	previous := append([]string{cfg.JWTSecret}, cfg.JWTPreviousSecrets...)
+	if len(previous) > *keep {
+		previous = previous[:*keep]
+	}
+
+	fmt.Fprintf(a.out, "JWT_SECRET=%s\n", randomSecret(jwtSecretBytes))
+	fmt.Fprintf(a.out, "JWT_PREVIOUS_SECRETS=%s\n", strings.Join(previous, ","))
+
+	fmt.Fprintln(os.Stderr, "Set these in the server's environment or config file and restart it.")
+	if *keep > 0 {
+		fmt.Fprintln(os.Stderr, "Tokens signed with the previous keys are accepted until they expire; drop them from JWT_PREVIOUS_SECRETS after a day.")
+	} else {
+		fmt.Fprintln(os.Stderr, "Every existing token, including verification and reset links, stops working.")
+	}
+	return nil
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"slink-backend/internal/models"
+
+	"github.com/google/uuid"
+)
+
+const linksUsage = `links <command> [arguments]
+
+Commands:
+  create -url url [-alias alias] [-user email or id] [-tags a,b] [-expires time] [-hide-referrer]
+  list [-user email or id] [-query text] [-limit n]
+  delete <id or short code>...
+  import [-user email or id] [-dry-run] <file.csv or ->
+  export [-user email or id] [-query text] [file.csv]`
+
+// linkPageSize is how many links export fetches per query.
+const linkPageSize = 500
+
+func runLinks(ctx context.Context, a *app, args []string) error {
+	return subcommand(ctx, a, "links", args, map[string]func(context.Context, *app, []string) error{
+		"create": createLink,
+		"list":   listLinks,
+		"delete": deleteLinks,
+		"import": importLinks,
+		"export": exportLinks,
+	}, linksUsage)
+}
+
+func createLink(ctx context.Context, a *app, args []string) error {
+	fs := newFlagSet("links create", "-url url [flags]")
+	originalURL := fs.String("url", "", "destination `url`")
+	customAlias := fs.String("alias", "", "custom `alias` to use as the short code")
+	owner := fs.String("user", "", "`email or id` of the owner; the link is anonymous if empty")
+	tags := fs.String("tags", "", "comma-separated `tags`")
+	expires := fs.String("expires", "", "expiry `time` in RFC 3339 format")
+	hideReferrer := fs.Bool("hide-referrer", false, "keep the short link out of the destination's referrer")
+	if _, err := parse(fs, args, 0, 0); err != nil {
+		return err
+	}
+	if *originalURL == "" {
+		fs.Usage()
+		return errUsage
+	}
+
+	req := models.ShortenRequest{OriginalURL: *originalURL, HideReferrer: *hideReferrer}
+	if *customAlias != "" {
+		req.CustomAlias = customAlias
+	}
+	if *tags != "" {
+		req.Tags = strings.Split(*tags, ",")
+	}
+	if *expires != "" {
+		t, err := time.Parse(time.RFC3339, *expires)
+		if err != nil {
+			return fmt.Errorf("-expires: %q is not an RFC 3339 time", *expires)
+		}
+		req.ExpiresAt = &t
+	}
+
+	if err := a.connect(); err != nil {
+		return err
+	}
+	userID, err := ownerID(ctx, a, *owner)
+	if err != nil {
+		return err
+	}
+
+	url, err := a.links.CreateShortURL(ctx, req, userID)
+	if err != nil {
+		return err
+	}
+	a.record(ctx, models.AuditEvent{Action: models.AuditLinkCreated, TargetType: "link", TargetID: url.ID}, nil, url)
+	a.emit(ctx, url, models.WebhookLinkCreated)
+
+	fmt.Fprintln(a.out, a.shortURL(url.ShortCode))
+	return nil
+}
+
+func listLinks(ctx context.Context, a *app, args []string) error {
+	fs := newFlagSet("links list", "[flags]")
+	owner := fs.String("user", "", "only list links owned by `email or id`")
+	query := fs.String("query", "", "only list links whose destination contains `text`")
+	limit := fs.Int("limit", 50, "list at most `n` links, newest first")
+	if _, err := parse(fs, args, 0, 0); err != nil {
+		return err
+	}
+
+	if err := a.connect(); err != nil {
+		return err
+	}
+	userID, err := ownerID(ctx, a, *owner)
+	if err != nil {
+		return err
+	}
+
+	links, err := a.links.SearchLinks(ctx, *query, deref(userID), *limit, 0)
+	if err != nil {
+		return err
+	}
+
+	tw := newTable(a.out)
+	fmt.Fprintln(tw, "ID\tSHORT CODE\tCLICKS\tCREATED\tDESTINATION")
+	for _, l := range links {
+		code := l.ShortCode
+		if l.TakenDownAt != nil {
+			code += " (taken down)"
+		} else if l.Expired() {
+			code += " (expired)"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%s\t%s\n", l.ID, code, l.HitCount, l.CreatedAt.Local().Format(time.DateOnly), l.OriginalURL)
+	}
+	return tw.Flush()
+}
+
+func deleteLinks(ctx context.Context, a *app, args []string) error {
+	fs := newFlagSet("links delete", "<id or short code>...")
+	refs, err := parse(fs, args, 1, -1)
+	if err != nil {
+		return err
+	}
+
+	if err := a.connect(); err != nil {
+		return err
+	}
+
+	var errs []error
+	deleted := 0
+	for _, ref := range refs {
+		url, err := lookupLink(ctx, a, ref)
+		if err == nil {
+			url, err = a.links.RemoveLink(ctx, url.ID)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %v", ref, err))
+			continue
+		}
+		a.record(ctx, models.AuditEvent{Action: models.AuditLinkDeleted, TargetType: "link", TargetID: url.ID}, url, nil)
+		a.emit(ctx, url, models.WebhookLinkDeleted)
+		fmt.Fprintf(a.out, "deleted %s (%s)\n", url.ShortCode, url.OriginalURL)
+		deleted++
+	}
+	if deleted > 0 {
+		// The QR cache lives in each server's memory, out of reach from here.
+		fmt.Fprintf(a.out, "running servers may serve cached QR codes for deleted links for up to %s\n", a.cfg.QRCacheTTL)
+	}
+	return errors.Join(errs...)
+}
+
+// lookupLink finds a link by ID or short code.
+func lookupLink(ctx context.Context, a *app, ref string) (*models.URL, error) {
+	if _, err := uuid.Parse(ref); err == nil {
+		return a.links.GetURLByID(ctx, ref)
+	}
+	return a.links.GetURLByCode(ctx, ref)
+}
+
+// ownerID resolves an email address or user ID given on the command line;
+// an empty ref means no owner.
+func ownerID(ctx context.Context, a *app, ref string) (*string, error) {
+	if ref == "" {
+		return nil, nil
+	}
+	user, err := lookupUser(ctx, a, ref)
+	if err != nil {
+		return nil, err
+	}
+	return &user.ID, nil
+}
+
+// eachLink calls fn with every link matching query and userID, newest first.
+func eachLink(ctx context.Context, a *app, query, userID string, fn func(models.URL) error) error {
+	for offset := 0; ; offset += linkPageSize {
+		links, err := a.links.SearchLinks(ctx, query, userID, linkPageSize, offset)
+		if err != nil {
+			return err
+		}
+		for _, l := range links {
+			if err := fn(l); err != nil {
+				return err
+			}
+		}
+		if len(links) < linkPageSize {
+			return nil
+		}
+	}
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,276 @@
+// Command slinkctl administers a Slink deployment from the command line. It
+// applies database migrations, manages users and links, imports and exports
+// links as CSV, rotates the JWT signing key and prints statistics.
+//
+// It reads the same configuration as the server (.env, CONFIG_FILE and the
+// environment) and goes through the same services, so links it creates
+// follow the configured short code strategy and alias policy.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"os/user"
+	"strings"
+	"text/tabwriter"
+
+	"slink-backend/internal/alias"
+	"slink-backend/internal/config"
+	"slink-backend/internal/database"
+	"slink-backend/internal/mailer"
+	"slink-backend/internal/models"
+	"slink-backend/internal/services"
+	"slink-backend/internal/shortcode"
+	"slink-backend/internal/utils"
+
+	"github.com/joho/godotenv"
+)
+
+// errUsage is returned by commands called with the wrong arguments, after
+// they have printed their usage.
+var errUsage = errors.New("invalid usage")
+
+type command struct {
+	name    string
+	summary string
+	run     func(ctx context.Context, a *app, args []string) error
+}
+
+var commands = []command{
+	{"migrate", "apply the SQL migrations in database/", runMigrate},
+	{"users", "create, disable, enable and list users", runUsers},
+	{"links", "create, list, delete, import and export links", runLinks},
+	{"keys", "rotate the JWT signing key", runKeys},
+	{"stats", "print system statistics", runStats},
+}
+
+func main() {
+	_ = godotenv.Load()
+
+	fs := flag.NewFlagSet("slinkctl", flag.ContinueOnError)
+	configFile := fs.String("config", "", "YAML or TOML config `file`, as for the server")
+	verbose := fs.Bool("v", false, "log service activity to stderr")
+	fs.Usage = func() { usage(fs) }
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		os.Exit(2)
+	}
+
+	level := slog.LevelWarn
+	if *verbose {
+		level = slog.LevelInfo
+	}
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level})))
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	name := fs.Arg(0)
+	for _, cmd := range commands {
+		if cmd.name != name {
+			continue
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		err := cmd.run(ctx, &app{configFile: *configFile, out: os.Stdout}, fs.Args()[1:])
+		stop()
+		switch {
+		case err == nil, errors.Is(err, flag.ErrHelp):
+		case errors.Is(err, errUsage):
+			os.Exit(2)
+		default:
+			fmt.Fprintln(os.Stderr, "slinkctl:", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	fmt.Fprintf(os.Stderr, "slinkctl: unknown command %q\n", name)
+	fs.Usage()
+	os.Exit(2)
+}
+
+func usage(fs *flag.FlagSet) {
+	w := fs.Output()
+	fmt.Fprintln(w, "Usage: slinkctl [-config file] [-v] <command> [arguments]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, cmd := range commands {
+		fmt.Fprintf(tw, "  %s\t%s\n", cmd.name, cmd.summary)
+	}
+	tw.Flush()
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run 'slinkctl <command> -h' for the command's arguments.")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Flags:")
+	fs.PrintDefaults()
+}
+
+// subcommand dispatches args to the handler named by args[0].
+func subcommand(ctx context.Context, a *app, command string, args []string, handlers map[string]func(context.Context, *app, []string) error, usage string) error {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "-help" || args[0] == "--help" {
+		fmt.Fprintf(os.Stderr, "Usage: slinkctl %s\n", usage)
+		if len(args) == 0 {
+			return errUsage
+		}
+		return flag.ErrHelp
+	}
+	handler, ok := handlers[args[0]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "slinkctl %s: unknown subcommand %q\nUsage: slinkctl %s\n", command, args[0], usage)
+		return errUsage
+	}
+	return handler(ctx, a, args[1:])
+}
+
+// newFlagSet returns a flag set for "slinkctl name" whose usage shows
+// synopsis followed by the flags.
+func newFlagSet(name, synopsis string) *flag.FlagSet {
+	fs := flag.NewFlagSet("slinkctl "+name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: slinkctl %s %s\n", name, synopsis)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parse parses args, allowing flags after positional arguments, and checks
+// the number of positional arguments is between min and max; max < 0 means
+// any number.
+func parse(fs *flag.FlagSet, args []string, min, max int) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return nil, err
+			}
+			return nil, errUsage
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			break
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+	if len(positional) < min || (max >= 0 && len(positional) > max) {
+		fs.Usage()
+		return nil, errUsage
+	}
+	return positional, nil
+}
+
+// app holds the configuration and services, built on first use so that
+// commands like "keys rotate" work without a reachable database.
+type app struct {
+	configFile string
+	out        io.Writer
+
+	cfg      *config.Config
+	db       *database.SupabaseClient
+	users    services.UserServiceInterface
+	links    services.URLServiceInterface
+	admin    *services.AdminService
+	audit    *services.AuditService
+	webhooks *services.WebhookService
+}
+
+func (a *app) config() (*config.Config, error) {
+	if a.cfg != nil {
+		return a.cfg, nil
+	}
+
+	var args []string
+	if a.configFile != "" {
+		args = []string{"--config", a.configFile}
+	}
+	cfg, err := config.Load(args)
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration:\n%v", err)
+	}
+	utils.SetJWTSecret(cfg.JWTSecret, cfg.JWTPreviousSecrets...)
+
+	a.cfg = cfg
+	return cfg, nil
+}
+
+func (a *app) connect() error {
+	if a.db != nil {
+		return nil
+	}
+	cfg, err := a.config()
+	if err != nil {
+		return err
+	}
+
+	db, err := database.ConnectSupabase(cfg.SupabaseURL, cfg.SupabaseKey, cfg.SupabaseProjectRef, cfg.SupabaseDebug)
+	if err != nil {
+		return fmt.Errorf("failed to connect to Supabase: %v", err)
+	}
+	mail, err := mailer.New(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to configure mailer: %v", err)
+	}
+	codes, err := shortcode.New(cfg, db)
+	if err != nil {
+		return fmt.Errorf("failed to configure short codes: %v", err)
+	}
+	aliases, err := alias.New(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to configure alias policy: %v", err)
+	}
+
+	a.db = db
+	a.users = services.NewUserService(db, mail, cfg)
+	a.links = services.NewURLServiceSupa(db, codes, aliases)
+	a.admin = services.NewAdminService(db)
+	a.audit = services.NewAuditService(db)
+	a.webhooks = services.NewWebhookService(db)
+	return nil
+}
+
+// record adds event to the audit log, marked as coming from slinkctl and
+// the operator's OS account since there is no authenticated user.
+func (a *app) record(ctx context.Context, event models.AuditEvent, before, after interface{}) {
+	if event.Metadata == nil {
+		event.Metadata = map[string]string{}
+	}
+	event.Metadata["source"] = "slinkctl"
+	if u, err := user.Current(); err == nil {
+		event.Metadata["operator"] = u.Username
+	}
+	event.UserAgent = "slinkctl"
+
+	if err := a.audit.Record(ctx, event, before, after); err != nil {
+		slog.Error("Failed to record audit event", "action", event.Action, "error", err)
+	}
+}
+
+// emit queues eventType for the link owner's webhook endpoints, as the API
+// does for the same change.
+func (a *app) emit(ctx context.Context, url *models.URL, eventType string) {
+	if url.UserID == nil {
+		return
+	}
+	if err := a.webhooks.Emit(ctx, *url.UserID, eventType, url); err != nil {
+		slog.Error("Failed to emit webhook", "event", eventType, "link_id", url.ID, "error", err)
+	}
+}
+
+func (a *app) shortURL(code string) string {
+	return strings.TrimSuffix(a.cfg.BaseURL, "/") + "/" + code
+}
+
+func newTable(w io.Writer) *tabwriter.Writer {
+	return tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+}
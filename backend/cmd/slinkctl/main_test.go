@@ -0,0 +1,51 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		args       []string
+		min, max   int
+		positional string
+		user       string
+		dryRun     bool
+		err        error
+	}{
+		{args: []string{"links.csv"}, min: 1, max: 1, positional: "links.csv"},
+		{args: []string{"-user", "a@example.com", "links.csv"}, min: 1, max: 1, positional: "links.csv", user: "a@example.com"},
+		{args: []string{"links.csv", "-user", "a@example.com", "-dry-run"}, min: 1, max: 1, positional: "links.csv", user: "a@example.com", dryRun: true},
+		{args: []string{"abc", "-dry-run", "def"}, min: 1, max: -1, positional: "abc,def", dryRun: true},
+		{args: []string{"--", "-dash"}, min: 1, max: 1, positional: "-dash"},
+		{args: nil, min: 1, max: 1, err: errUsage},
+		{args: []string{"a", "b"}, min: 0, max: 1, err: errUsage},
+		{args: []string{"a", "-unknown"}, min: 0, max: 1, err: errUsage},
+		{args: []string{"-h"}, min: 0, max: 0, err: flag.ErrHelp},
+	}
+	for _, tt := range tests {
+		fs := newFlagSet("test", "")
+		fs.SetOutput(io.Discard)
+		user := fs.String("user", "", "")
+		dryRun := fs.Bool("dry-run", false, "")
+
+		positional, err := parse(fs, tt.args, tt.min, tt.max)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("parse(%q) error = %v, want %v", tt.args, err, tt.err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if got := strings.Join(positional, ","); got != tt.positional {
+			t.Errorf("parse(%q) positional = %q, want %q", tt.args, got, tt.positional)
+		}
+		if *user != tt.user || *dryRun != tt.dryRun {
+			t.Errorf("parse(%q) flags = %q, %v; want %q, %v", tt.args, *user, *dryRun, tt.user, tt.dryRun)
+		}
+	}
+}
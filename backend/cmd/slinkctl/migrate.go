@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	_ "github.com/lib/pq"
+)
+
+// manifestName lists the migrations in the order they run. The other .sql
+// files in the directory are one-off fixes and diagnostics, not migrations.
+const manifestName = "migrations.txt"
+
+const createMigrationsTable = `CREATE TABLE IF NOT EXISTS schema_migrations (
+    name TEXT PRIMARY KEY,
+    checksum TEXT NOT NULL,
+    applied_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+)`
+
+type migration struct {
+	name     string
+	sql      string
+	checksum string
+}
+
+type appliedMigration struct {
+	checksum  string
+	appliedAt time.Time
+}
+
+func runMigrate(ctx context.Context, a *app, args []string) error {
+	fs := newFlagSet("migrate", "[-dir dir] [-status | -dry-run | -baseline]")
+	dir := fs.String("dir", "../database", "`directory` holding "+manifestName+" and the SQL files")
+	status := fs.Bool("status", false, "list the migrations and when each was applied")
+	dryRun := fs.Bool("dry-run", false, "list the pending migrations without applying them")
+	baseline := fs.Bool("baseline", false, "mark every pending migration as applied without running it, for databases set up by hand")
+	if _, err := parse(fs, args, 0, 0); err != nil {
+		return err
+	}
+
+	migrations, err := readMigrations(*dir)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := a.config()
+	if err != nil {
+		return err
+	}
+	if cfg.SupabaseDBURL == "" {
+		return errors.New("SUPABASE_DB_URL is not set; use the Postgres connection string from the Supabase dashboard")
+	}
+	db, err := sql.Open("postgres", cfg.SupabaseDBURL)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	if _, err := db.ExecContext(ctx, createMigrationsTable); err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %v", err)
+	}
+	applied, err := appliedMigrations(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range migrations {
+		if prev, ok := applied[m.name]; ok && prev.checksum != m.checksum {
+			fmt.Fprintf(os.Stderr, "warning: %s has changed since it was applied; write a new migration instead\n", m.name)
+		}
+	}
+
+	if *status {
+		tw := newTable(a.out)
+		fmt.Fprintln(tw, "MIGRATION\tAPPLIED")
+		for _, m := range migrations {
+			when := "pending"
+			if prev, ok := applied[m.name]; ok {
+				when = prev.appliedAt.Local().Format(time.RFC3339)
+			}
+			fmt.Fprintf(tw, "%s\t%s\n", m.name, when)
+		}
+		return tw.Flush()
+	}
+
+	pending := 0
+	for _, m := range migrations {
+		if _, ok := applied[m.name]; ok {
+			continue
+		}
+		pending++
+
+		switch {
+		case *dryRun:
+			fmt.Fprintln(a.out, "pending", m.name)
+		case *baseline:
+			if err := insertMigration(ctx, db, m); err != nil {
+				return err
+			}
+			fmt.Fprintln(a.out, "marked", m.name)
+		default:
+			if err := applyMigration(ctx, db, m); err != nil {
+				return err
+			}
+			fmt.Fprintln(a.out, "applied", m.name)
+		}
+	}
+	if pending == 0 {
+		fmt.Fprintln(a.out, "database is up to date")
+	}
+	return nil
+}
+
+// readMigrations reads the manifest in dir and the files it names. Blank
+// lines and lines starting with # are ignored.
+func readMigrations(dir string) ([]migration, error) {
+	manifest, err := os.ReadFile(filepath.Join(dir, manifestName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migration manifest: %v", err)
+	}
+
+	var migrations []migration
+	seen := map[string]bool{}
+	scanner := bufio.NewScanner(bytes.NewReader(manifest))
+	for scanner.Scan() {
+		name := strings.TrimSpace(scanner.Text())
+		if name == "" || strings.HasPrefix(name, "#") {
+			continue
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("%s: %s is listed twice", manifestName, name)
+		}
+		seen[name] = true
+
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration: %v", err)
+		}
+		sum := sha256.Sum256(data)
+		migrations = append(migrations, migration{name: name, sql: string(data), checksum: hex.EncodeToString(sum[:])})
+	}
+	return migrations, scanner.Err()
+}
+
+func appliedMigrations(ctx context.Context, db *sql.DB) (map[string]appliedMigration, error) {
+	rows, err := db.QueryContext(ctx, "SELECT name, checksum, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %v", err)
+	}
+	defer rows.Close()
+
+	applied := map[string]appliedMigration{}
+	for rows.Next() {
+		var name string
+		var m appliedMigration
+		if err := rows.Scan(&name, &m.checksum, &m.appliedAt); err != nil {
+			return nil, fmt.Errorf("failed to read schema_migrations: %v", err)
+		}
+		applied[name] = m
+	}
+	return applied, rows.Err()
+}
+
+// applyMigration runs m and records it in one transaction, so a failing
+// migration leaves nothing behind and can be fixed and rerun.
+func applyMigration(ctx context.Context, db *sql.DB, m migration) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	// Without arguments the whole file is sent as one simple query, so it
+	// may hold several statements.
+	if _, err := tx.ExecContext(ctx, m.sql); err != nil {
+		return fmt.Errorf("migration %s failed: %v", m.name, err)
+	}
+	if err := insertMigration(ctx, tx, m); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit %s: %v", m.name, err)
+	}
+	return nil
+}
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+func insertMigration(ctx context.Context, db execer, m migration) error {
+	_, err := db.ExecContext(ctx, "INSERT INTO schema_migrations (name, checksum) VALUES ($1, $2)", m.name, m.checksum)
+	if err != nil {
+		return fmt.Errorf("failed to record %s: %v", m.name, err)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestReadMigrations(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		manifestName: "# comment\nschema.sql\n\n  users.sql  \n# users.sql is listed above\n",
+		"schema.sql": "CREATE TABLE urls ();",
+		"users.sql":  "CREATE TABLE users ();",
+		"fix.sql":    "-- not listed",
+	})
+
+	migrations, err := readMigrations(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, m := range migrations {
+		names = append(names, m.name)
+	}
+	if strings.Join(names, ",") != "schema.sql,users.sql" {
+		t.Errorf("migrations = %v, want schema.sql, users.sql", names)
+	}
+	if migrations[0].sql != "CREATE TABLE urls ();" || migrations[0].checksum == migrations[1].checksum {
+		t.Errorf("migrations = %+v", migrations)
+	}
+}
+
+func TestReadMigrationsRejectsBadManifests(t *testing.T) {
+	tests := map[string]map[string]string{
+		"listed twice": {manifestName: "schema.sql\nschema.sql\n", "schema.sql": ""},
+		"missing file": {manifestName: "schema.sql\nmissing.sql\n", "schema.sql": ""},
+		"no manifest":  {"schema.sql": ""},
+	}
+	for name, files := range tests {
+		if _, err := readMigrations(writeFiles(t, files)); err == nil {
+			t.Errorf("%s: no error", name)
+		}
+	}
+}
+
+func TestRepositoryManifest(t *testing.T) {
+	migrations, err := readMigrations("../../../database")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) == 0 || migrations[0].name != "schema.sql" {
+		t.Errorf("the manifest should start with schema.sql")
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+func runStats(ctx context.Context, a *app, args []string) error {
+	fs := newFlagSet("stats", "[-json]")
+	asJSON := fs.Bool("json", false, "print the statistics as JSON")
+	if _, err := parse(fs, args, 0, 0); err != nil {
+		return err
+	}
+
+	if err := a.connect(); err != nil {
+		return err
+	}
+	stats, err := a.admin.GetSystemStats(ctx)
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(a.out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(stats)
+	}
+
+	tw := newTable(a.out)
+	for _, row := range []struct {
+		label string
+		value int
+	}{
+		{"Users", stats.TotalUsers},
+		{"Admins", stats.AdminUsers},
+		{"Disabled users", stats.DisabledUsers},
+		{"Signups in the last 7 days", stats.SignupsLast7Days},
+		{"Links", stats.TotalLinks},
+		{"Taken down links", stats.TakenDownLinks},
+		{"Links in the last 7 days", stats.LinksLast7Days},
+		{"Clicks", stats.TotalClicks},
+	} {
+		fmt.Fprintf(tw, "%s\t%d\n", row.label, row.value)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/mail"
+	"time"
+
+	"slink-backend/internal/database"
+	"slink-backend/internal/models"
+	"slink-backend/internal/services"
+
+	"github.com/google/uuid"
+)
+
+const usersUsage = `users <command> [arguments]
+
+Commands:
+  create -email address [-password password] [-name name] [-admin] [-verified]
+  disable <email or id>
+  enable <email or id>
+  list [-query text] [-limit n]`
+
+const minPasswordLength = 6
+
+func runUsers(ctx context.Context, a *app, args []string) error {
+	return subcommand(ctx, a, "users", args, map[string]func(context.Context, *app, []string) error{
+		"create":  createUser,
+		"disable": func(ctx context.Context, a *app, args []string) error { return setUserDisabled(ctx, a, args, true) },
+		"enable":  func(ctx context.Context, a *app, args []string) error { return setUserDisabled(ctx, a, args, false) },
+		"list":    listUsers,
+	}, usersUsage)
+}
+
+func createUser(ctx context.Context, a *app, args []string) error {
+	fs := newFlagSet("users create", "-email address [flags]")
+	email := fs.String("email", "", "email `address` to sign in with")
+	password := fs.String("password", "", "initial `password`; a random one is generated and printed if empty")
+	name := fs.String("name", "", "display `name`")
+	admin := fs.Bool("admin", false, "give the user the admin role")
+	verified := fs.Bool("verified", false, "mark the email address as verified")
+	if _, err := parse(fs, args, 0, 0); err != nil {
+		return err
+	}
+
+	if *email == "" {
+		fs.Usage()
+		return errUsage
+	}
+	if _, err := mail.ParseAddress(*email); err != nil {
+		return fmt.Errorf("-email: %q is not a valid email address", *email)
+	}
+	generated := *password == ""
+	if generated {
+		*password = randomSecret(12)
+	} else if len(*password) < minPasswordLength {
+		return fmt.Errorf("-password: must be at least %d characters", minPasswordLength)
+	}
+
+	if err := a.connect(); err != nil {
+		return err
+	}
+
+	req := models.RegisterRequest{Email: *email, Password: *password}
+	if *name != "" {
+		req.Name = name
+	}
+	register := a.users.Register
+	if *verified {
+		register = a.users.RegisterVerified
+	}
+	user, err := register(ctx, req)
+	if err != nil {
+		return err
+	}
+	if *admin {
+		if user, err = a.users.SetRole(ctx, user.ID, models.RoleAdmin); err != nil {
+			return fmt.Errorf("created %s but failed to make it an admin: %v", *email, err)
+		}
+	}
+
+	a.record(ctx, models.AuditEvent{Action: models.AuditUserRegistered, TargetType: "user", TargetID: user.ID}, nil, user)
+
+	fmt.Fprintf(a.out, "created user %s (%s)\n", user.Email, user.ID)
+	if generated {
+		fmt.Fprintf(a.out, "password: %s\n", *password)
+	}
+	return nil
+}
+
+func setUserDisabled(ctx context.Context, a *app, args []string, disabled bool) error {
+	verb := "enable"
+	if disabled {
+		verb = "disable"
+	}
+	fs := newFlagSet("users "+verb, "<email or id>")
+	positional, err := parse(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+
+	if err := a.connect(); err != nil {
+		return err
+	}
+	before, err := lookupUser(ctx, a, positional[0])
+	if err != nil {
+		return err
+	}
+	user, err := a.users.SetDisabled(ctx, before.ID, disabled)
+	if err != nil {
+		return err
+	}
+
+	action := models.AuditAdminUserEnable
+	if disabled {
+		action = models.AuditAdminUserDisable
+	}
+	a.record(ctx, models.AuditEvent{Action: action, TargetType: "user", TargetID: user.ID}, before, user)
+
+	fmt.Fprintf(a.out, "%sd user %s\n", verb, user.Email)
+	return nil
+}
+
+func listUsers(ctx context.Context, a *app, args []string) error {
+	fs := newFlagSet("users list", "[flags]")
+	query := fs.String("query", "", "only list users whose email contains `text`")
+	limit := fs.Int("limit", 50, "list at most `n` users, newest first")
+	if _, err := parse(fs, args, 0, 0); err != nil {
+		return err
+	}
+
+	if err := a.connect(); err != nil {
+		return err
+	}
+	users, err := a.users.ListUsers(ctx, *query, *limit, 0)
+	if err != nil {
+		return err
+	}
+
+	tw := newTable(a.out)
+	fmt.Fprintln(tw, "ID\tEMAIL\tROLE\tPLAN\tVERIFIED\tDISABLED\tCREATED")
+	for _, u := range users {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%t\t%t\t%s\n",
+			u.ID, u.Email, u.Role, u.Plan, u.EmailVerified, u.Disabled, u.CreatedAt.Local().Format(time.DateOnly))
+	}
+	return tw.Flush()
+}
+
+// lookupUser finds a user by ID or email address.
+func lookupUser(ctx context.Context, a *app, ref string) (*models.User, error) {
+	var user *models.User
+	var err error
+	if _, uuidErr := uuid.Parse(ref); uuidErr == nil {
+		user, err = a.users.GetUserByID(ctx, ref)
+	} else {
+		user, err = a.users.GetUserByEmail(ctx, ref)
+	}
+	if errors.Is(err, database.ErrNotFound) || errors.Is(err, services.ErrNotFound) {
+		return nil, fmt.Errorf("no user %q", ref)
+	}
+	return user, err
+}
+
+func randomSecret(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,278 @@
+// Package alias decides which custom aliases users may claim.
+package alias
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"slink-backend/internal/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultPlan applies to users without a plan, or with a plan that has no
+// limits configured.
+const DefaultPlan = "free"
+
+// MaxLength is the longest alias the urls table can store.
+const MaxLength = 64
+
+var aliasRegex = regexp.MustCompile(`^[a-zA-Z0-9-]+$`)
+
+var (
+	ErrInvalid  = errors.New("invalid custom alias")
+	ErrReserved = errors.New("custom alias is reserved")
+	ErrBlocked  = errors.New("custom alias is not allowed")
+)
+
+// LengthError is returned for aliases outside the plan's length limits.
+type LengthError struct {
+	Limits Limits
+}
+
+func (e *LengthError) Error() string {
+	return fmt.Sprintf("custom alias must be %d to %d characters", e.Limits.Min, e.Limits.Max)
+}
+
+// Paths the frontend serves or is likely to serve. Registered backend routes
+// are added by ReserveRoutes.
+var defaultReserved = []string{
+	"about", "account", "admin", "api", "app", "assets", "auth", "blog",
+	"contact", "dashboard", "docs", "health", "help", "links", "login",
+	"logout", "metrics", "pricing", "privacy", "profile", "qr-codes",
+	"register", "settings", "signin", "signup", "static", "status",
+	"support", "terms", "www",
+}
+
+//go:embed blocklist.txt
+var defaultBlocklist string
+
+// Limits bounds the length of the aliases a plan may use.
+type Limits struct {
+	Min int `json:"min"`
+	Max int `json:"max"`
+}
+
+// Policy holds the reserved words, the blocklist and the per-plan length
+// limits. Reserve and ReserveRoutes must be called before the policy is
+// shared between goroutines.
+type Policy struct {
+	reserved map[string]bool
+	blocked  []string
+	limits   map[string]Limits
+}
+
+// New builds the policy from the ALIAS_* settings.
+func New(cfg *config.Config) (*Policy, error) {
+	limits, err := parseLimits(cfg.AliasPlanLimits)
+	if err != nil {
+		return nil, err
+	}
+
+	blocklist := defaultBlocklist
+	if cfg.AliasBlocklistFile != "" {
+		data, err := os.ReadFile(cfg.AliasBlocklistFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read alias blocklist: %v", err)
+		}
+		blocklist = string(data)
+	}
+
+	p := &Policy{reserved: map[string]bool{}, limits: limits}
+	p.Reserve(defaultReserved...)
+	p.Reserve(cfg.AliasReservedWords...)
+	for _, word := range append(parseBlocklist(blocklist), cfg.AliasBlocklist...) {
+		if word = normalize(word); word != "" {
+			p.blocked = append(p.blocked, word)
+		}
+	}
+	return p, nil
+}
+
+// Reserve stops words from being used as aliases, ignoring case.
+func (p *Policy) Reserve(words ...string) {
+	for _, word := range words {
+		if word = strings.ToLower(strings.TrimSpace(word)); word != "" {
+			p.reserved[word] = true
+		}
+	}
+}
+
+// ReserveRoutes reserves the first path segment of every registered route,
+// so an alias can never shadow one.
+func (p *Policy) ReserveRoutes(routes gin.RoutesInfo) {
+	for _, route := range routes {
+		segment, _, _ := strings.Cut(strings.TrimPrefix(route.Path, "/"), "/")
+		if segment == "" || strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			continue
+		}
+		p.Reserve(segment)
+	}
+}
+
+// Limits returns the length limits for plan.
+func (p *Policy) Limits(plan string) Limits {
+	if limits, ok := p.limits[plan]; ok {
+		return limits
+	}
+	return p.limits[DefaultPlan]
+}
+
+// HasPlan reports whether plan has limits configured.
+func (p *Policy) HasPlan(plan string) bool {
+	_, ok := p.limits[plan]
+	return ok
+}
+
+// Check reports why alias can't be used on plan, or nil if it can. It
+// doesn't check whether the alias is taken.
+func (p *Policy) Check(alias, plan string) error {
+	if !aliasRegex.MatchString(alias) {
+		return ErrInvalid
+	}
+	limits := p.Limits(plan)
+	if len(alias) < limits.Min || len(alias) > limits.Max {
+		return &LengthError{Limits: limits}
+	}
+	if p.reserved[strings.ToLower(alias)] {
+		return ErrReserved
+	}
+	if p.isBlocked(alias) {
+		return ErrBlocked
+	}
+	return nil
+}
+
+// CheckGenerated reports whether a generated short code is reserved or
+// blocked. Generated codes skip the format and length rules, which are the
+// generator's business.
+func (p *Policy) CheckGenerated(code string) error {
+	if p.reserved[strings.ToLower(code)] {
+		return ErrReserved
+	}
+	if p.isBlocked(code) {
+		return ErrBlocked
+	}
+	return nil
+}
+
+// isBlocked matches the alias against the blocklist after undoing common
+// obfuscation. Entries of five or more characters match anywhere in the
+// alias; shorter ones only match a whole hyphen-separated word, so that
+// innocent words containing them stay usable.
+func (p *Policy) isBlocked(alias string) bool {
+	segments := strings.Split(alias, "-")
+	for i, segment := range segments {
+		segments[i] = normalize(segment)
+	}
+	joined := strings.Join(segments, "")
+
+	for _, word := range p.blocked {
+		if len(word) >= 5 {
+			if strings.Contains(joined, word) {
+				return true
+			}
+			continue
+		}
+		if joined == word {
+			return true
+		}
+		for _, segment := range segments {
+			if segment == word {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Candidates returns variations of alias that pass the policy for plan,
+// most similar first. Whether they are taken is up to the caller.
+func (p *Policy) Candidates(alias, plan string) []string {
+	base := strings.Trim(strings.ToLower(alias), "-")
+	maxLen := p.Limits(plan).Max
+
+	var variants []string
+	for i := 1; i <= 9; i++ {
+		variants = append(variants, fitSuffix(base, strconv.Itoa(i), maxLen))
+	}
+	for _, suffix := range []string{"-link", "-go", "-app", "-hq", "-official"} {
+		variants = append(variants, fitSuffix(base, suffix, maxLen))
+	}
+	for i := 10; i <= 99; i += 11 {
+		variants = append(variants, fitSuffix(base, "-"+strconv.Itoa(i), maxLen))
+	}
+
+	seen := map[string]bool{alias: true}
+	candidates := []string{}
+	for _, variant := range variants {
+		if seen[variant] || p.Check(variant, plan) != nil {
+			continue
+		}
+		seen[variant] = true
+		candidates = append(candidates, variant)
+	}
+	return candidates
+}
+
+// fitSuffix appends suffix to base, shortening base to stay within maxLen.
+// It returns "" when too little of base would be left to be recognisable.
+func fitSuffix(base, suffix string, maxLen int) string {
+	if len(base)+len(suffix) > maxLen {
+		base = strings.TrimRight(base[:max(0, maxLen-len(suffix))], "-")
+		if len(base) < 3 {
+			return ""
+		}
+	}
+	return base + suffix
+}
+
+// leetReplacer undoes digit substitutions and folds l into i, since 1 is
+// used for both.
+var leetReplacer = strings.NewReplacer(
+	"0", "o", "1", "i", "l", "i", "3", "e", "4", "a", "5", "s", "7", "t", "8", "b", "-", "",
+)
+
+func normalize(word string) string {
+	return leetReplacer.Replace(strings.ToLower(strings.TrimSpace(word)))
+}
+
+func parseBlocklist(data string) []string {
+	var words []string
+	for _, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		words = append(words, line)
+	}
+	return words
+}
+
+// parseLimits parses "plan:min-max" entries. The default plan must be
+// present.
+func parseLimits(entries []string) (map[string]Limits, error) {
+	limits := map[string]Limits{}
+	for _, entry := range entries {
+		plan, bounds, ok := strings.Cut(entry, ":")
+		minText, maxText, ok2 := strings.Cut(bounds, "-")
+		if !ok || !ok2 {
+			return nil, fmt.Errorf("invalid alias plan limits %q, want plan:min-max", entry)
+		}
+		minLen, err1 := strconv.Atoi(strings.TrimSpace(minText))
+		maxLen, err2 := strconv.Atoi(strings.TrimSpace(maxText))
+		if err1 != nil || err2 != nil || minLen < 1 || maxLen < minLen || maxLen > MaxLength {
+			return nil, fmt.Errorf("invalid alias plan limits %q, lengths must be between 1 and %d", entry, MaxLength)
+		}
+		limits[strings.TrimSpace(plan)] = Limits{Min: minLen, Max: maxLen}
+	}
+	if _, ok := limits[DefaultPlan]; !ok {
+		return nil, fmt.Errorf("alias plan limits must include the %s plan", DefaultPlan)
+	}
+	return limits, nil
+}
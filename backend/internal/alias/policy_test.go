@@ -0,0 +1,163 @@
+package alias
+
+import (
+	"errors"
+	"testing"
+
+	"slink-backend/internal/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newTestPolicy(t *testing.T) *Policy {
+	t.Helper()
+	p, err := New(&config.Config{
+		AliasPlanLimits:    []string{"free:3-10", "pro:1-32"},
+		AliasReservedWords: []string{"Careers"},
+		AliasBlocklist:     []string{"scam"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.ReserveRoutes(gin.RoutesInfo{
+		{Method: "GET", Path: "/qr/:shortCode"},
+		{Method: "GET", Path: "/:shortCode"},
+		{Method: "POST", Path: "/shorten"},
+	})
+	return p
+}
+
+func TestCheckGenerated(t *testing.T) {
+	p := newTestPolicy(t)
+
+	tests := []struct {
+		code string
+		want error
+	}{
+		{"aZ3kQ9", nil},
+		{"qr", ErrReserved},
+		{"Shorten", ErrReserved},
+		{"admin", ErrReserved},
+		{"careers", ErrReserved},
+		{"sc4m", ErrBlocked},
+		{"xgoogle1", ErrBlocked},
+		// Generated codes aren't held to alias format or length rules.
+		{"a_b", nil},
+		{"x", nil},
+	}
+	for _, tt := range tests {
+		if err := p.CheckGenerated(tt.code); !errors.Is(err, tt.want) {
+			t.Errorf("CheckGenerated(%q) = %v, want %v", tt.code, err, tt.want)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	p := newTestPolicy(t)
+
+	tests := []struct {
+		alias, plan string
+		want        error
+	}{
+		{"my-link", "free", nil},
+		{"My-Link", "free", nil},
+		{"my_link", "free", ErrInvalid},
+		{"my link", "free", ErrInvalid},
+		{"ab", "free", &LengthError{}},
+		{"ab", "pro", nil},
+		{"abcdefghijk", "free", &LengthError{}},
+		{"abcdefghijk", "pro", nil},
+		{"abcdefghijk", "unknown", &LengthError{}},
+		// Reserved words and route segments, ignoring case.
+		{"admin", "free", ErrReserved},
+		{"Login", "free", ErrReserved},
+		{"careers", "free", ErrReserved},
+		{"shorten", "free", ErrReserved},
+		{"qr", "pro", ErrReserved},
+		// Blocked words, through obfuscation.
+		{"scam", "free", ErrBlocked},
+		{"my-sc4m", "free", ErrBlocked},
+		{"g00gle-login", "pro", ErrBlocked},
+		{"paypalsupport", "pro", ErrBlocked},
+		// Short entries only match whole words.
+		{"scampi", "free", nil},
+	}
+	for _, tt := range tests {
+		err := p.Check(tt.alias, tt.plan)
+		var lengthErr *LengthError
+		switch want := tt.want.(type) {
+		case *LengthError:
+			if !errors.As(err, &lengthErr) {
+				t.Errorf("Check(%q, %q) = %v, want a length error", tt.alias, tt.plan, err)
+			}
+		default:
+			if !errors.Is(err, want) {
+				t.Errorf("Check(%q, %q) = %v, want %v", tt.alias, tt.plan, err, want)
+			}
+		}
+	}
+}
+
+func TestReserveRoutesSkipsParameters(t *testing.T) {
+	p := newTestPolicy(t)
+	// "/:shortCode" must not reserve anything, or every alias would be.
+	for _, alias := range []string{"shortCode", ":shortCode", "launch"} {
+		if err := p.Check(alias, "pro"); errors.Is(err, ErrReserved) {
+			t.Errorf("Check(%q) = %v", alias, err)
+		}
+	}
+}
+
+func TestLimits(t *testing.T) {
+	p := newTestPolicy(t)
+	if got := p.Limits("pro"); got != (Limits{Min: 1, Max: 32}) {
+		t.Errorf("Limits(pro) = %+v", got)
+	}
+	if got := p.Limits("enterprise"); got != (Limits{Min: 3, Max: 10}) {
+		t.Errorf("Limits(enterprise) = %+v, want the free plan's", got)
+	}
+	if p.HasPlan("enterprise") || !p.HasPlan("pro") {
+		t.Error("HasPlan reports the wrong plans")
+	}
+}
+
+func TestNewRejectsBadLimits(t *testing.T) {
+	for _, limits := range [][]string{
+		{"pro:1-32"},
+		{"free:3"},
+		{"free:0-10"},
+		{"free:5-4"},
+		{"free:1-65"},
+		{"free:a-b"},
+	} {
+		if _, err := New(&config.Config{AliasPlanLimits: limits}); err == nil {
+			t.Errorf("New accepted plan limits %q", limits)
+		}
+	}
+}
+
+func TestCandidates(t *testing.T) {
+	p := newTestPolicy(t)
+
+	candidates := p.Candidates("launch", "free")
+	if len(candidates) == 0 || candidates[0] != "launch1" {
+		t.Fatalf("Candidates(launch) = %q, want launch1 first", candidates)
+	}
+	seen := map[string]bool{}
+	for _, c := range candidates {
+		if err := p.Check(c, "free"); err != nil {
+			t.Errorf("candidate %q fails the policy: %v", c, err)
+		}
+		if seen[c] || c == "launch" {
+			t.Errorf("candidate %q repeated", c)
+		}
+		seen[c] = true
+	}
+
+	// Suffixes shorten the base to stay within the plan's maximum.
+	for _, c := range p.Candidates("abcdefghij", "free") {
+		if len(c) > 10 {
+			t.Errorf("candidate %q is longer than the free plan allows", c)
+		}
+	}
+}
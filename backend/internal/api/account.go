@@ -0,0 +1,135 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"slink-backend/internal/models"
+	"slink-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// VerifyEmail accepts the token either as a query parameter (links in the
+// verification mail) or as a JSON body.
+func (h *Handler) VerifyEmail(c *gin.Context) {
+	var req models.VerifyEmailRequest
+
+	var err error
+	if c.Request.Method == http.MethodGet {
+		err = c.ShouldBindQuery(&req)
+	} else {
+		err = c.ShouldBindJSON(&req)
+	}
+	if err != nil {
+		c.Error(bindError(err))
+		return
+	}
+
+	user, err := h.userService.VerifyEmail(c.Request.Context(), req.Token)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Email verified",
+		"user":    newUserResponse(user),
+	})
+}
+
+func (h *Handler) ResendVerification(c *gin.Context) {
+	user, err := h.userService.GetUserByID(c.Request.Context(), c.GetString("userID"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	if user.EmailVerified {
+		c.Error(services.Conflict("email_already_verified", "Email already verified"))
+		return
+	}
+
+	if err := h.userService.SendVerificationEmail(c.Request.Context(), user); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "Verification email sent",
+	})
+}
+
+func (h *Handler) ForgotPassword(c *gin.Context) {
+	var req models.ForgotPasswordRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(bindError(err))
+		return
+	}
+
+	// Look the account up and send the mail after responding, so neither
+	// the status nor the timing tells whether the address is registered.
+	go func(ctx context.Context) {
+		ctx, cancel := context.WithTimeout(ctx, h.config.BackgroundTimeout)
+		defer cancel()
+		if err := h.userService.RequestPasswordReset(ctx, req.Email); err != nil {
+			slog.ErrorContext(ctx, "Failed to send password reset email", "error", err)
+		}
+	}(detached(c))
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "If an account exists for this email, a password reset link has been sent",
+	})
+}
+
+func (h *Handler) ResetPassword(c *gin.Context) {
+	var req models.ResetPasswordRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(bindError(err))
+		return
+	}
+
+	userID, err := h.userService.ResetPassword(c.Request.Context(), req.Token, req.Password)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	h.audit(c, models.AuditEvent{ActorID: &userID, Action: models.AuditPasswordReset, TargetType: "user", TargetID: userID}, nil, nil)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Password has been reset",
+	})
+}
+
+// RequireVerifiedEmail rejects unverified users when
+// REQUIRE_EMAIL_VERIFICATION is enabled. Must run after AuthMiddleware.
+func (h *Handler) RequireVerifiedEmail() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !h.config.RequireEmailVerification {
+			c.Next()
+			return
+		}
+
+		user, err := h.userService.GetUserByID(c.Request.Context(), c.GetString("userID"))
+		if errors.Is(err, services.ErrNotFound) {
+			fail(c, services.Unauthorized("user_not_found", "User not found"))
+			return
+		}
+		if err != nil {
+			fail(c, err)
+			return
+		}
+
+		if !user.EmailVerified {
+			fail(c, services.Forbidden("email_not_verified", "Email address must be verified before shortening links"))
+			return
+		}
+
+		c.Next()
+	}
+}
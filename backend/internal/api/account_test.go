@@ -0,0 +1,57 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"slink-backend/internal/config"
+	"slink-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fakeResets records password reset requests and fails them with err.
+type fakeResets struct {
+	services.UserServiceInterface
+	err       error
+	requested chan string
+}
+
+func (f *fakeResets) RequestPasswordReset(ctx context.Context, email string) error {
+	f.requested <- email
+	return f.err
+}
+
+func TestForgotPasswordRespondsTheSameForEveryAddress(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	for _, err := range []error{nil, errors.New("mail server unavailable")} {
+		users := &fakeResets{err: err, requested: make(chan string, 1)}
+		h := &Handler{userService: users, config: &config.Config{BackgroundTimeout: time.Second}}
+		router := gin.New()
+		router.Use(ErrorHandler())
+		router.POST("/api/password/forgot", h.ForgotPassword)
+
+		req := httptest.NewRequest(http.MethodPost, "/api/password/forgot", strings.NewReader(`{"email":"user@example.com"}`))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusAccepted {
+			t.Errorf("reset error %v: status = %d, want 202", err, rec.Code)
+		}
+		select {
+		case email := <-users.requested:
+			if email != "user@example.com" {
+				t.Errorf("reset requested for %q", email)
+			}
+		case <-time.After(time.Second):
+			t.Error("reset was never requested")
+		}
+	}
+}
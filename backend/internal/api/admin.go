@@ -0,0 +1,273 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"slink-backend/internal/models"
+	"slink-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 200
+)
+
+// RequireRole only lets users with the given role through. Must run after
+// AuthMiddleware.
+func (h *Handler) RequireRole(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString("role") != role {
+			fail(c, services.Forbidden("insufficient_permissions", "Insufficient permissions"))
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func (h *Handler) AdminListUsers(c *gin.Context) {
+	limit, offset := pagination(c)
+
+	users, err := h.userService.ListUsers(c.Request.Context(), c.Query("q"), limit, offset)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response := make([]models.AdminUserResponse, len(users))
+	for i := range users {
+		response[i] = newAdminUserResponse(&users[i])
+	}
+
+	h.audit(c, models.AuditEvent{
+		Action:     models.AuditAdminListUsers,
+		TargetType: "user",
+		Metadata:   searchMetadata(c, limit, offset, "q"),
+	}, nil, nil)
+
+	c.JSON(http.StatusOK, gin.H{
+		"users":  response,
+		"limit":  limit,
+		"offset": offset,
+	})
+}
+
+func (h *Handler) AdminDisableUser(c *gin.Context) {
+	h.setUserDisabled(c, true)
+}
+
+func (h *Handler) AdminEnableUser(c *gin.Context) {
+	h.setUserDisabled(c, false)
+}
+
+func (h *Handler) setUserDisabled(c *gin.Context, disabled bool) {
+	userID := c.Param("id")
+	if userID == c.GetString("userID") {
+		c.Error(services.InvalidInput("own_account", "You cannot change your own account status"))
+		return
+	}
+
+	before, err := h.userService.GetUserByID(c.Request.Context(), userID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	user, err := h.userService.SetDisabled(c.Request.Context(), userID, disabled)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	action := models.AuditAdminUserEnable
+	if disabled {
+		action = models.AuditAdminUserDisable
+	}
+	h.audit(c, models.AuditEvent{Action: action, TargetType: "user", TargetID: user.ID}, newAdminUserResponse(before), newAdminUserResponse(user))
+
+	c.JSON(http.StatusOK, newAdminUserResponse(user))
+}
+
+func (h *Handler) AdminSetMFARequired(c *gin.Context) {
+	var req models.SetMFARequiredRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(bindError(err))
+		return
+	}
+
+	userID := c.Param("id")
+	if userID == c.GetString("userID") {
+		c.Error(services.InvalidInput("own_account", "You cannot change your own 2FA requirement"))
+		return
+	}
+
+	before, err := h.userService.GetUserByID(c.Request.Context(), userID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	user, err := h.userService.SetMFARequired(c.Request.Context(), before.ID, req.Required)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	h.audit(c, models.AuditEvent{Action: models.AuditAdminRequireMFA, TargetType: "user", TargetID: user.ID}, newAdminUserResponse(before), newAdminUserResponse(user))
+
+	c.JSON(http.StatusOK, newAdminUserResponse(user))
+}
+
+func (h *Handler) AdminSetPlan(c *gin.Context) {
+	var req models.SetPlanRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(bindError(err))
+		return
+	}
+
+	if !h.aliases.HasPlan(req.Plan) {
+		c.Error(services.InvalidField("plan", "unknown_plan", "Unknown plan"))
+		return
+	}
+
+	before, err := h.userService.GetUserByID(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	user, err := h.userService.SetPlan(c.Request.Context(), before.ID, req.Plan)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	h.audit(c, models.AuditEvent{Action: models.AuditAdminSetPlan, TargetType: "user", TargetID: user.ID}, newAdminUserResponse(before), newAdminUserResponse(user))
+
+	c.JSON(http.StatusOK, newAdminUserResponse(user))
+}
+
+func (h *Handler) AdminListLinks(c *gin.Context) {
+	limit, offset := pagination(c)
+
+	links, err := h.urlService.SearchLinks(c.Request.Context(), c.Query("q"), c.Query("user_id"), limit, offset)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	h.audit(c, models.AuditEvent{
+		Action:     models.AuditAdminListLinks,
+		TargetType: "link",
+		Metadata:   searchMetadata(c, limit, offset, "q", "user_id"),
+	}, nil, nil)
+
+	c.JSON(http.StatusOK, gin.H{
+		"links":  links,
+		"limit":  limit,
+		"offset": offset,
+	})
+}
+
+func (h *Handler) AdminTakeDownLink(c *gin.Context) {
+	var req models.TakedownRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(bindError(err))
+		return
+	}
+
+	before, err := h.urlService.GetURLByID(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	url, err := h.urlService.TakeDownLink(c.Request.Context(), before.ID, req.Reason)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	h.qrCache.InvalidateLink(url.ID)
+	h.audit(c, models.AuditEvent{Action: models.AuditAdminTakedown, TargetType: "link", TargetID: url.ID}, before, url)
+
+	c.JSON(http.StatusOK, url)
+}
+
+func (h *Handler) AdminRestoreLink(c *gin.Context) {
+	before, err := h.urlService.GetURLByID(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	url, err := h.urlService.RestoreLink(c.Request.Context(), before.ID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	h.qrCache.InvalidateLink(url.ID)
+	h.audit(c, models.AuditEvent{Action: models.AuditAdminRestore, TargetType: "link", TargetID: url.ID}, before, url)
+
+	c.JSON(http.StatusOK, url)
+}
+
+func (h *Handler) AdminStats(c *gin.Context) {
+	stats, err := h.adminService.GetSystemStats(c.Request.Context())
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	h.audit(c, models.AuditEvent{Action: models.AuditAdminViewStats}, nil, nil)
+
+	c.JSON(http.StatusOK, stats)
+}
+
+func newAdminUserResponse(user *models.User) models.AdminUserResponse {
+	return models.AdminUserResponse{
+		UserResponse: newUserResponse(user),
+		Disabled:     user.Disabled,
+		MFARequired:  user.MFARequired,
+		DeletedAt:    user.DeletedAt,
+		UpdatedAt:    user.UpdatedAt,
+	}
+}
+
+// searchMetadata records the search parameters of an admin listing.
+func searchMetadata(c *gin.Context, limit, offset int, params ...string) map[string]string {
+	metadata := map[string]string{
+		"limit":  strconv.Itoa(limit),
+		"offset": strconv.Itoa(offset),
+	}
+	for _, param := range params {
+		if value := c.Query(param); value != "" {
+			metadata[param] = value
+		}
+	}
+	return metadata
+}
+
+func pagination(c *gin.Context) (int, int) {
+	limit, err := strconv.Atoi(c.Query("limit"))
+	if err != nil || limit <= 0 {
+		limit = defaultPageSize
+	}
+	if limit > maxPageSize {
+		limit = maxPageSize
+	}
+
+	offset, err := strconv.Atoi(c.Query("offset"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	return limit, offset
+}
@@ -0,0 +1,71 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"slink-backend/internal/models"
+	"slink-backend/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequireRole(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	utils.SetJWTSecret("test-secret")
+	token, err := utils.GenerateToken(testUserID, "user@example.com", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		role string
+		want int
+	}{
+		{models.RoleUser, http.StatusForbidden},
+		{"", http.StatusForbidden},
+		{models.RoleAdmin, http.StatusOK},
+	} {
+		user := testUser()
+		user.Role = tt.role
+		h := &Handler{userService: &fakeUsers{user: user}}
+
+		router := gin.New()
+		router.Use(ErrorHandler())
+		router.GET("/api/admin/stats", h.AuthMiddleware(), h.RequireRole(models.RoleAdmin), func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+
+		req := httptest.NewRequest(http.MethodGet, "/api/admin/stats", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		if rec.Code != tt.want {
+			t.Errorf("role %q: status = %d, want %d", tt.role, rec.Code, tt.want)
+		}
+		if tt.want == http.StatusForbidden && !strings.Contains(rec.Body.String(), "insufficient_permissions") {
+			t.Errorf("role %q: body = %s, want insufficient_permissions", tt.role, rec.Body)
+		}
+	}
+}
+
+func TestTakenDownLinkShowsReason(t *testing.T) {
+	takenDown := time.Now()
+	reason := "Phishing <script>"
+	rec, _ := redirect(t, &models.URL{ID: "link-1", ShortCode: "abc123", OriginalURL: "https://example.com/page", TakenDownAt: &takenDown, TakedownReason: &reason})
+
+	if rec.Code != http.StatusGone || rec.Header().Get("Location") != "" {
+		t.Errorf("status = %d, Location = %q; want 410 and no redirect", rec.Code, rec.Header().Get("Location"))
+	}
+	body := rec.Body.String()
+	if !strings.Contains(body, "Reason: Phishing &lt;script&gt;") {
+		t.Errorf("page doesn't show the escaped reason:\n%s", body)
+	}
+	if strings.Contains(body, "example.com") {
+		t.Errorf("page reveals the destination:\n%s", body)
+	}
+}
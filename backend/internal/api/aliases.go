@@ -0,0 +1,49 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"slink-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+const maxAliasSuggestions = 5
+
+// SuggestAlias reports whether ?alias= can be claimed by the current user
+// and suggests available alternatives when it is taken or reserved.
+func (h *Handler) SuggestAlias(c *gin.Context) {
+	customAlias := c.Query("alias")
+	if customAlias == "" {
+		c.Error(services.InvalidField("alias", "required", "alias is required"))
+		return
+	}
+
+	suggestion, err := h.urlService.SuggestAliases(c.Request.Context(), customAlias, c.GetString("userID"), maxAliasSuggestions)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, suggestion)
+}
+
+// withAliasSuggestions adds available alternatives to an alias_taken error,
+// so clients can offer them without another request. Other errors are
+// returned unchanged.
+func (h *Handler) withAliasSuggestions(ctx context.Context, err error, customAlias *string, userID string) error {
+	var apiErr *services.Error
+	if customAlias == nil || !errors.As(err, &apiErr) || apiErr.Code != "alias_taken" {
+		return err
+	}
+
+	suggestion, suggestErr := h.urlService.SuggestAliases(ctx, *customAlias, userID, maxAliasSuggestions)
+	if suggestErr != nil {
+		slog.Error("Failed to suggest aliases", "error", suggestErr)
+		return apiErr.With("suggestions", []string{})
+	}
+	return apiErr.With("suggestions", suggestion.Suggestions)
+}
@@ -0,0 +1,112 @@
+package api
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"slink-backend/internal/models"
+	"slink-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// audit records event for the current request, filling in the actor, IP and
+// user agent. Failures are logged rather than returned so that auditing
+// never breaks the action being audited.
+func (h *Handler) audit(c *gin.Context, event models.AuditEvent, before, after interface{}) {
+	if event.ActorID == nil {
+		if userID := c.GetString("userID"); userID != "" {
+			event.ActorID = &userID
+		}
+	}
+	event.IP = c.ClientIP()
+	event.UserAgent = c.Request.UserAgent()
+
+	// The action has happened; a client hanging up must not lose its record.
+	if err := h.auditService.Record(detached(c), event, before, after); err != nil {
+		slog.ErrorContext(c.Request.Context(), "Failed to record audit event", "action", event.Action, "error", err)
+	}
+}
+
+// AuditDeadline bounds ListAuditEvents: a page of events gets the read
+// timeout, while an NDJSON export streams the whole log and gets export.
+func AuditDeadline(read, export time.Duration) gin.HandlerFunc {
+	page, stream := Deadline(read, read), Deadline(export, export)
+	return func(c *gin.Context) {
+		if wantsNDJSON(c) {
+			stream(c)
+			return
+		}
+		page(c)
+	}
+}
+
+func wantsNDJSON(c *gin.Context) bool {
+	return c.Query("format") == "ndjson" || strings.Contains(c.GetHeader("Accept"), "application/x-ndjson")
+}
+
+// ListAuditEvents returns audit events, newest first. Admins can query every
+// event; other users only see events they caused. With ?format=ndjson the
+// full result set is streamed as newline-delimited JSON.
+func (h *Handler) ListAuditEvents(c *gin.Context) {
+	filter, err := auditFilter(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	if wantsNDJSON(c) {
+		c.Header("Content-Type", "application/x-ndjson")
+		c.Header("Content-Disposition", `attachment; filename="audit.ndjson"`)
+		c.Status(http.StatusOK)
+		if err := h.auditService.Export(c.Request.Context(), filter, c.Writer); err != nil {
+			slog.ErrorContext(c.Request.Context(), "Failed to export audit events", "error", err)
+		}
+		return
+	}
+
+	events, err := h.auditService.List(c.Request.Context(), filter)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"events": events,
+		"limit":  filter.Limit,
+		"offset": filter.Offset,
+	})
+}
+
+// auditFilter reads the audit query parameters, restricting non-admins to
+// events they caused whatever actor_id they ask for.
+func auditFilter(c *gin.Context) (models.AuditFilter, error) {
+	limit, offset := pagination(c)
+	filter := models.AuditFilter{
+		ActorID:    c.Query("actor_id"),
+		Action:     c.Query("action"),
+		TargetType: c.Query("target_type"),
+		TargetID:   c.Query("target_id"),
+		Limit:      limit,
+		Offset:     offset,
+	}
+
+	if c.GetString("role") != models.RoleAdmin {
+		filter.ActorID = c.GetString("userID")
+	}
+
+	for param, dest := range map[string]**time.Time{"since": &filter.Since, "until": &filter.Until} {
+		value := c.Query(param)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return filter, services.InvalidField(param, "invalid_timestamp", "Invalid "+param+" timestamp, expected RFC 3339")
+		}
+		*dest = &t
+	}
+	return filter, nil
+}
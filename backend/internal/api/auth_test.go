@@ -0,0 +1,103 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"slink-backend/internal/models"
+	"slink-backend/internal/services"
+	"slink-backend/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+const testUserID = "5b0c7f4e-2a8e-4c1e-9a51-3f1d2b6c7d80"
+
+// fakeUsers serves a single user; other methods panic if called.
+type fakeUsers struct {
+	services.UserServiceInterface
+	user *models.User
+}
+
+func (f *fakeUsers) GetUserByID(ctx context.Context, userID string) (*models.User, error) {
+	if userID != f.user.ID {
+		return nil, services.NotFound("user_not_found", "user not found")
+	}
+	return f.user, nil
+}
+
+func testUser() *models.User {
+	return &models.User{ID: testUserID, Email: "user@example.com", Role: models.RoleUser}
+}
+
+func profileRouter(user *models.User) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	h := &Handler{userService: &fakeUsers{user: user}}
+
+	router := gin.New()
+	router.Use(ErrorHandler())
+	router.GET("/api/profile", h.AuthMiddleware(), h.EnforceMFAEnrollment(), h.GetProfile)
+	return router
+}
+
+func getProfile(t *testing.T, token string) int {
+	t.Helper()
+	return getProfileAs(t, testUser(), token)
+}
+
+func getProfileAs(t *testing.T, user *models.User, token string) int {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/api/profile", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+	profileRouter(user).ServeHTTP(rec, req)
+	return rec.Code
+}
+
+func TestAuthMiddlewareAcceptsSessionToken(t *testing.T) {
+	utils.SetJWTSecret("test-secret")
+	token, err := utils.GenerateToken(testUserID, "user@example.com", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code := getProfile(t, token); code != http.StatusOK {
+		t.Errorf("status = %d, want 200", code)
+	}
+}
+
+func TestAuthMiddlewareRejectsActionTokens(t *testing.T) {
+	utils.SetJWTSecret("test-secret")
+
+	for _, purpose := range []string{utils.PurposeMFAChallenge, utils.PurposeEmailVerification, utils.PurposePasswordReset} {
+		token, err := utils.GenerateActionToken(testUserID, purpose, "fp", 5*time.Minute)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if code := getProfile(t, token); code != http.StatusUnauthorized {
+			t.Errorf("%s token: status = %d, want 401", purpose, code)
+		}
+	}
+}
+
+func TestAuthMiddlewareBlocksSessionsPendingMFAEnrollment(t *testing.T) {
+	utils.SetJWTSecret("test-secret")
+	// A session issued before an admin required 2FA.
+	token, err := utils.GenerateToken(testUserID, "user@example.com", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user := testUser()
+	user.MFARequired = true
+	if code := getProfileAs(t, user, token); code != http.StatusForbidden {
+		t.Errorf("status = %d, want 403", code)
+	}
+
+	user.TOTPEnabled = true
+	if code := getProfileAs(t, user, token); code != http.StatusOK {
+		t.Errorf("after enrolling: status = %d, want 200", code)
+	}
+}
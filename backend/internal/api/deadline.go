@@ -0,0 +1,31 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Deadline bounds how long a request may take: read applies to GET and HEAD
+// requests, write to everything else. Handlers pass the request context on
+// to the database, so work still running when it expires is abandoned and
+// the client gets a 504. A zero timeout leaves the request unbounded.
+func Deadline(read, write time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		timeout := write
+		if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+			timeout = read
+		}
+		if timeout <= 0 {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
@@ -0,0 +1,46 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAuditDeadline(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	const read, export = time.Second, time.Hour
+
+	var remaining time.Duration
+	router := gin.New()
+	router.GET("/api/audit", AuditDeadline(read, export), func(c *gin.Context) {
+		deadline, ok := c.Request.Context().Deadline()
+		if !ok {
+			t.Fatal("no deadline set")
+		}
+		remaining = time.Until(deadline)
+	})
+
+	tests := []struct {
+		name   string
+		target string
+		accept string
+		want   time.Duration
+	}{
+		{"page", "/api/audit", "", read},
+		{"format param", "/api/audit?format=ndjson", "", export},
+		{"accept header", "/api/audit", "application/x-ndjson", export},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			req.Header.Set("Accept", tt.accept)
+			router.ServeHTTP(httptest.NewRecorder(), req)
+			if remaining > tt.want || remaining < tt.want/2 {
+				t.Errorf("deadline in %v, want %v", remaining, tt.want)
+			}
+		})
+	}
+}
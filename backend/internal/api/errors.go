@@ -0,0 +1,288 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"slink-backend/internal/logging"
+	"slink-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+const requestIDHeader = "X-Request-ID"
+
+// statusClientClosedRequest is nginx's non-standard status for requests the
+// client gave up on before a response was ready.
+const statusClientClosedRequest = 499
+
+var requestIDRegex = regexp.MustCompile(`^[A-Za-z0-9._-]{1,128}$`)
+
+// problem is an RFC 7807 problem details body. Code identifies the error
+// for programs; Detail is meant for people and may change.
+type problem struct {
+	Type      string                `json:"type"`
+	Title     string                `json:"title"`
+	Status    int                   `json:"status"`
+	Detail    string                `json:"detail,omitempty"`
+	Instance  string                `json:"instance,omitempty"`
+	Code      string                `json:"code"`
+	RequestID string                `json:"request_id"`
+	Errors    []services.FieldError `json:"errors,omitempty"`
+
+	extensions map[string]interface{}
+}
+
+// MarshalJSON adds the extension members alongside the standard ones.
+func (p *problem) MarshalJSON() ([]byte, error) {
+	type plain problem
+	body, err := json.Marshal((*plain)(p))
+	if err != nil || len(p.extensions) == 0 {
+		return body, err
+	}
+
+	members := map[string]interface{}{}
+	for k, v := range p.extensions {
+		members[k] = v
+	}
+	if err := json.Unmarshal(body, &members); err != nil {
+		return nil, err
+	}
+	return json.Marshal(members)
+}
+
+// Kinds only the API layer produces.
+var (
+	errUnprocessable = errors.New("unprocessable")
+	errTooLarge      = errors.New("too large")
+	errTimedOut      = errors.New("timed out")
+	errClientClosed  = errors.New("client closed request")
+)
+
+var (
+	errInvalidRequest   = services.InvalidInput("invalid_request", "Invalid request format")
+	errNotAuthenticated = services.Unauthorized("not_authenticated", "User not authenticated")
+	errInvalidToken     = services.Unauthorized("invalid_token", "Invalid token")
+	errLinkDisabled     = services.Gone("link_disabled", "This link has been disabled")
+	errLinkExpired      = services.Expired("link_expired", "This link has expired")
+	errLogoUnreadable   = services.InvalidField("logo", "invalid_logo", "Failed to read logo file")
+	errTimeout          = &services.Error{Kind: errTimedOut, Code: "timeout", Message: "The request took too long to complete"}
+	errCanceled         = &services.Error{Kind: errClientClosed, Code: "client_closed_request", Message: "The client closed the request"}
+)
+
+func init() {
+	// Report validation errors under the names clients send.
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(func(field reflect.StructField) string {
+			for _, tag := range []string{"json", "form"} {
+				name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+				if name != "" && name != "-" {
+					return name
+				}
+			}
+			return field.Name
+		})
+	}
+}
+
+// RequestID tags every request with an ID, taken from the X-Request-ID
+// header when the client sent a sensible one. The ID is echoed in the
+// response, included in every error and added to logs written with the
+// request's context.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(requestIDHeader)
+		if !requestIDRegex.MatchString(id) {
+			id = uuid.New().String()
+		}
+		c.Set("requestID", id)
+		c.Header(requestIDHeader, id)
+		c.Request = c.Request.WithContext(logging.WithRequestID(c.Request.Context(), id))
+		c.Next()
+	}
+}
+
+// ErrorHandler renders the last error attached with c.Error as
+// application/problem+json, unless the handler already wrote a response.
+// Errors that aren't *services.Error are logged and reported as a generic
+// internal error, so database and other internal details never reach
+// clients.
+func ErrorHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		renderErrors(c)
+	}
+}
+
+// renderErrors writes the last recorded error, if any, unless a response
+// was already written.
+func renderErrors(c *gin.Context) {
+	if len(c.Errors) == 0 || c.Writer.Written() {
+		return
+	}
+	writeProblem(c, c.Errors.Last().Err)
+}
+
+// NoRoute reports unknown routes in the same format as other errors.
+func NoRoute(c *gin.Context) {
+	c.Error(services.NotFound("route_not_found", "Route not found"))
+}
+
+// fail records err for ErrorHandler and stops the handler chain.
+func fail(c *gin.Context, err error) {
+	c.Error(err)
+	c.Abort()
+}
+
+func writeProblem(c *gin.Context, err error) {
+	requestID := c.GetString("requestID")
+
+	var apiErr *services.Error
+	if !errors.As(err, &apiErr) {
+		switch contextError(c, err) {
+		case context.DeadlineExceeded:
+			slog.WarnContext(c.Request.Context(), "Request timed out", "method", c.Request.Method, "path", c.Request.URL.Path, "error", err)
+			apiErr = errTimeout
+		case context.Canceled:
+			apiErr = errCanceled
+		default:
+			slog.ErrorContext(c.Request.Context(), "Request failed", "method", c.Request.Method, "path", c.Request.URL.Path, "error", err)
+			apiErr = &services.Error{Code: "internal_error", Message: "An internal error occurred"}
+		}
+	}
+
+	status := statusFor(apiErr.Kind)
+	title := http.StatusText(status)
+	if status == statusClientClosedRequest {
+		title = "Client Closed Request"
+	}
+	body, _ := json.Marshal(&problem{
+		Type:       "about:blank",
+		Title:      title,
+		Status:     status,
+		Detail:     apiErr.Message,
+		Instance:   c.Request.URL.Path,
+		Code:       apiErr.Code,
+		RequestID:  requestID,
+		Errors:     apiErr.Fields,
+		extensions: apiErr.Extensions,
+	})
+	c.Data(status, "application/problem+json", body)
+}
+
+// contextError reports whether err was caused by the request's deadline or
+// by the client going away. Services don't always wrap the errors they get,
+// so the request's own context is checked too.
+func contextError(c *gin.Context, err error) error {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return context.DeadlineExceeded
+	case errors.Is(err, context.Canceled):
+		return context.Canceled
+	}
+	return c.Request.Context().Err()
+}
+
+func statusFor(kind error) int {
+	switch kind {
+	case services.ErrNotFound:
+		return http.StatusNotFound
+	case services.ErrConflict:
+		return http.StatusConflict
+	case services.ErrInvalidInput:
+		return http.StatusBadRequest
+	case services.ErrUnauthorized:
+		return http.StatusUnauthorized
+	case services.ErrForbidden:
+		return http.StatusForbidden
+	case services.ErrGone, services.ErrExpired:
+		return http.StatusGone
+	case errUnprocessable:
+		return http.StatusUnprocessableEntity
+	case errTooLarge:
+		return http.StatusRequestEntityTooLarge
+	case errTimedOut:
+		return http.StatusGatewayTimeout
+	case errClientClosed:
+		return statusClientClosedRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// bindError describes why binding the request failed, field by field where
+// the validator can tell.
+func bindError(err error) error {
+	var validationErrs validator.ValidationErrors
+	var typeErr *json.UnmarshalTypeError
+
+	switch {
+	case errors.As(err, &validationErrs):
+		fields := make([]services.FieldError, len(validationErrs))
+		for i, fe := range validationErrs {
+			fields[i] = services.FieldError{
+				Field:   fe.Field(),
+				Code:    fe.Tag(),
+				Message: fieldMessage(fe),
+			}
+		}
+		return &services.Error{Kind: services.ErrInvalidInput, Code: "validation_failed", Message: "Request validation failed", Fields: fields}
+	case errors.As(err, &typeErr):
+		return &services.Error{
+			Kind:    services.ErrInvalidInput,
+			Code:    "invalid_request",
+			Message: "Invalid request format",
+			Fields: []services.FieldError{{
+				Field:   typeErr.Field,
+				Code:    "type",
+				Message: fmt.Sprintf("%s must be a %s", typeErr.Field, typeErr.Type.String()),
+			}},
+		}
+	case errors.Is(err, io.EOF):
+		return services.InvalidInput("invalid_request", "Request body is empty")
+	default:
+		return errInvalidRequest
+	}
+}
+
+func fieldMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return fmt.Sprintf("%s is required", fe.Field())
+	case "email":
+		return fmt.Sprintf("%s must be a valid email address", fe.Field())
+	case "url":
+		return fmt.Sprintf("%s must be a valid URL", fe.Field())
+	case "min":
+		return fmt.Sprintf("%s must be at least %s%s", fe.Field(), fe.Param(), sizeUnit(fe.Kind()))
+	case "max":
+		return fmt.Sprintf("%s must be at most %s%s", fe.Field(), fe.Param(), sizeUnit(fe.Kind()))
+	case "oneof":
+		return fmt.Sprintf("%s must be one of %s", fe.Field(), fe.Param())
+	default:
+		return fmt.Sprintf("%s is invalid", fe.Field())
+	}
+}
+
+// sizeUnit names what min and max count for a field of the given kind.
+func sizeUnit(kind reflect.Kind) string {
+	switch kind {
+	case reflect.String:
+		return " characters"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return " items"
+	default:
+		return ""
+	}
+}
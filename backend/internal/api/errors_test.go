@@ -0,0 +1,52 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"slink-backend/internal/config"
+	"slink-backend/internal/models"
+	"slink-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestExpiredLinksAreDistinctFromTakenDown(t *testing.T) {
+	if !errors.Is(errLinkExpired, services.ErrExpired) || errors.Is(errLinkExpired, services.ErrGone) {
+		t.Error("errLinkExpired is not an ErrExpired")
+	}
+	if !errors.Is(errLinkDisabled, services.ErrGone) || errors.Is(errLinkDisabled, services.ErrExpired) {
+		t.Error("errLinkDisabled is not an ErrGone")
+	}
+
+	past := time.Now().Add(-time.Hour)
+	tests := []struct {
+		link *models.URL
+		code string
+	}{
+		{&models.URL{ID: "link-1", ShortCode: "old", ExpiresAt: &past}, "link_expired"},
+		{&models.URL{ID: "link-2", ShortCode: "bad", TakenDownAt: &past}, "link_disabled"},
+	}
+	for _, tt := range tests {
+		gin.SetMode(gin.TestMode)
+		h := &Handler{
+			urlService: &fakeLinks{link: tt.link},
+			qrService:  services.NewQRService(128, 64, 512),
+			qrCache:    services.NewQRCache(16, time.Minute),
+			config:     &config.Config{BaseURL: "https://sl.ink"},
+		}
+		router := gin.New()
+		router.Use(ErrorHandler())
+		router.GET("/qr/:shortCode", h.GenerateQR)
+
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/qr/"+tt.link.ShortCode, nil))
+		if rec.Code != http.StatusGone || !strings.Contains(rec.Body.String(), `"code":"`+tt.code+`"`) {
+			t.Errorf("%s: status = %d, body %s; want 410 %s", tt.link.ShortCode, rec.Code, rec.Body, tt.code)
+		}
+	}
+}
@@ -1,32 +1,61 @@
 package api
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"strings"
+	"time"
 
+	"slink-backend/internal/alias"
 	"slink-backend/internal/config"
 	"slink-backend/internal/database"
+	"slink-backend/internal/mailer"
+	"slink-backend/internal/metrics"
 	"slink-backend/internal/models"
 	"slink-backend/internal/services"
+	"slink-backend/internal/shortcode"
 	"slink-backend/internal/utils"
 
 	"github.com/gin-gonic/gin"
 )
 
 type Handler struct {
-	urlService  services.URLServiceInterface
-	userService services.UserServiceInterface
-	qrService   *services.QRService
-	config      *config.Config
+	urlService     services.URLServiceInterface
+	userService    services.UserServiceInterface
+	adminService   *services.AdminService
+	auditService   *services.AuditService
+	qrService      *services.QRService
+	logoService    *services.QRLogoService
+	qrCache        *services.QRCache
+	metadata       *services.MetadataFetcher
+	linkHealth     *services.LinkHealthService
+	webhookService *services.WebhookService
+	idempotency    *services.IdempotencyService
+	aliases        *alias.Policy
+	config         *config.Config
 }
 
-func NewHandler(supabaseClient *database.SupabaseClient, cfg *config.Config) *Handler {
+func NewHandler(supabaseClient *database.SupabaseClient, m mailer.Mailer, codes shortcode.Generator, aliases *alias.Policy, cfg *config.Config) *Handler {
+	qrCache := services.NewQRCache(cfg.QRCacheSize, cfg.QRCacheTTL)
+	metrics.RegisterCache("qr", qrCache.Stats)
+
 	return &Handler{
-		urlService:  services.NewURLServiceSupa(supabaseClient),
-		userService: services.NewUserService(supabaseClient),
-		qrService:   services.NewQRService(cfg.QRSize),
-		config:      cfg,
+		urlService:     services.NewURLServiceSupa(supabaseClient, codes, aliases),
+		userService:    services.NewUserService(supabaseClient, m, cfg),
+		adminService:   services.NewAdminService(supabaseClient),
+		auditService:   services.NewAuditService(supabaseClient),
+		qrService:      services.NewQRService(cfg.QRSize, cfg.QRMinSize, cfg.QRMaxSize),
+		logoService:    services.NewQRLogoService(supabaseClient),
+		qrCache:        qrCache,
+		metadata:       services.NewMetadataFetcher(services.NewPublicHTTPClient(cfg.PreviewFetchTimeout)),
+		linkHealth:     services.NewLinkHealthService(supabaseClient),
+		webhookService: services.NewWebhookService(supabaseClient),
+		idempotency:    services.NewIdempotencyService(supabaseClient, cfg.IdempotencyKeyTTL),
+		aliases:        aliases,
+		config:         cfg,
 	}
 }
 
@@ -34,16 +63,17 @@ func (h *Handler) ShortenURL(c *gin.Context) {
 	var req models.ShortenRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid request format",
-		})
+		c.Error(bindError(err))
 		return
 	}
 
-	if !isValidURL(req.OriginalURL) {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid URL. Must start with http:// or https://",
-		})
+	if !utils.IsValidURL(req.OriginalURL) {
+		c.Error(services.InvalidField("original_url", "invalid_url", "Invalid URL. Must start with http:// or https://"))
+		return
+	}
+
+	if _, err := utils.NormalizeTags(req.Tags); err != nil {
+		c.Error(services.InvalidField("tags", "invalid_tags", err.Error()))
 		return
 	}
 
@@ -53,123 +83,206 @@ func (h *Handler) ShortenURL(c *gin.Context) {
 		userIDPtr = &userID
 	}
 
-	url, err := h.urlService.CreateShortURL(req.OriginalURL, req.CustomAlias, userIDPtr)
-	if err != nil {
-		fmt.Printf("Error in CreateShortURL: %v\n", err)
-		if err.Error() == "custom alias already exists" {
-			c.JSON(http.StatusConflict, gin.H{
-				"error": "Custom alias already exists",
-			})
+	// A custom alias asks for a specific new link, so it's never reused. Nor
+	// is a link that handles referrers differently from what was asked for.
+	if userID != "" && req.CustomAlias == nil && h.reuseExisting(c.Request.Context(), userID, req) {
+		existing, err := h.urlService.FindLinkByDestination(c.Request.Context(), userID, req.OriginalURL)
+		if err == nil && existing.HideReferrer == req.HideReferrer {
+			response := h.newShortenResponse(existing)
+			response.Reused = true
+			c.JSON(http.StatusOK, response)
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to create short URL",
-		})
+	}
+
+	url, err := h.urlService.CreateShortURL(c.Request.Context(), req, userIDPtr)
+	if err != nil {
+		c.Error(h.withAliasSuggestions(c.Request.Context(), err, req.CustomAlias, userID))
 		return
 	}
 
-	fmt.Printf("URL created successfully: %+v\n", url)
+	h.audit(c, models.AuditEvent{Action: models.AuditLinkCreated, TargetType: "link", TargetID: url.ID}, nil, url)
+	h.emitWebhook(detached(c), url, models.WebhookLinkCreated, url)
+	go h.fetchLinkMetadata(detached(c), url.ID, url.OriginalURL)
 
-	shortURL := fmt.Sprintf("%s/%s", h.config.BaseURL, url.ShortCode)
-	qrCodeURL := fmt.Sprintf("%s/api/qr/%s", h.config.BaseURL, url.ShortCode)
+	response := h.newShortenResponse(url)
 
-	response := models.ShortenResponse{
-		ID:          url.ID,
-		OriginalURL: url.OriginalURL,
-		ShortCode:   url.ShortCode,
-		ShortURL:    shortURL,
-		QRCodeURL:   qrCodeURL,
-		CustomAlias: url.CustomAlias,
+	c.JSON(http.StatusCreated, response)
+}
+
+func (h *Handler) newShortenResponse(url *models.URL) models.ShortenResponse {
+	return models.ShortenResponse{
+		ID:           url.ID,
+		OriginalURL:  url.OriginalURL,
+		ShortCode:    url.ShortCode,
+		ShortURL:     fmt.Sprintf("%s/%s", h.config.BaseURL, url.ShortCode),
+		QRCodeURL:    fmt.Sprintf("%s/api/qr/%s", h.config.BaseURL, url.ShortCode),
+		CustomAlias:  url.CustomAlias,
+		Tags:         url.Tags,
+		ExpiresAt:    url.ExpiresAt,
+		HideReferrer: url.HideReferrer,
 	}
+}
 
-	fmt.Printf("Response built: %+v\n", response)
-	fmt.Printf("Sending JSON response: %+v\n", response)
-	c.JSON(http.StatusCreated, response)
+// reuseExisting decides whether req may return an existing link: the
+// request's reuse_existing flag wins, otherwise the user's default applies.
+func (h *Handler) reuseExisting(ctx context.Context, userID string, req models.ShortenRequest) bool {
+	if req.ReuseExisting != nil {
+		return *req.ReuseExisting
+	}
+	user, err := h.userService.GetUserByID(ctx, userID)
+	return err == nil && user.ReuseExistingLinks
 }
 
+// RedirectURL sends visitors to a link's destination. A trailing "+" on the
+// code shows a preview page instead, and social crawlers get the link's
+// Open Graph tags rather than a redirect.
 func (h *Handler) RedirectURL(c *gin.Context) {
 	shortCode := c.Param("shortCode")
+	preview := strings.HasSuffix(shortCode, "+")
+	shortCode = strings.TrimSuffix(shortCode, "+")
+
+	// QR codes encode ?src=qr&v=<variant>; these markers are only for
+	// attribution and are not passed on to the destination.
+	isQRScan := c.Query("src") == qrSource
 
-	url, err := h.urlService.GetURLByCode(shortCode)
+	url, err := h.urlService.GetURLByCode(c.Request.Context(), shortCode)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": "URL not found",
-		})
+		if errors.Is(err, services.ErrNotFound) {
+			countRedirect(isQRScan, "not_found")
+		}
+		c.Error(err)
 		return
 	}
 
-	go func() {
-		if err := h.urlService.IncrementHitCount(url.ShortCode); err != nil {
-			fmt.Printf("Failed to increment hit count: %v\n", err)
-		}
-	}()
+	if url.TakenDownAt != nil {
+		countRedirect(isQRScan, "taken_down")
+		renderTakedownPage(c, url)
+		return
+	}
 
-	c.Redirect(http.StatusMovedPermanently, url.OriginalURL)
-}
+	if url.Expired() {
+		countRedirect(isQRScan, "expired")
+		renderExpiredPage(c, url)
+		return
+	}
 
-func (h *Handler) GenerateQR(c *gin.Context) {
-	shortCode := c.Param("shortCode")
+	if preview {
+		countRedirect(isQRScan, "preview")
+		renderPreviewPage(c, url, h.config.BaseURL)
+		return
+	}
 
-	url, err := h.urlService.GetURLByCode(shortCode)
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": "URL not found",
-		})
+	if utils.IsSocialCrawler(c.GetHeader("User-Agent")) {
+		countRedirect(isQRScan, "crawler")
+		renderOpenGraphPage(c, url, h.config.BaseURL)
 		return
 	}
 
-	fullURL := fmt.Sprintf("%s/%s", h.config.BaseURL, url.ShortCode)
+	variant := c.Query("v")
+	if !utils.IsValidTag(variant) {
+		variant = ""
+	}
 
-	qrData, err := h.qrService.GenerateQRCode(fullURL)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to generate QR code",
-		})
+	click := models.LinkClick{
+		LinkID:    url.ID,
+		ShortCode: url.ShortCode,
+		Source:    "link",
+		Variant:   variant,
+		Referrer:  c.Request.Referer(),
+		UserAgent: c.Request.UserAgent(),
+		ClickedAt: time.Now(),
+	}
+	if isQRScan {
+		click.Source = qrSource
+	}
+
+	metrics.PendingHitUpdates.Inc()
+	go func(ctx context.Context) {
+		defer metrics.PendingHitUpdates.Dec()
+		ctx, cancel := context.WithTimeout(ctx, h.config.BackgroundTimeout)
+		defer cancel()
+
+		var err error
+		if isQRScan {
+			err = h.urlService.RecordQRScan(ctx, url.ShortCode, variant)
+		} else {
+			err = h.urlService.IncrementHitCount(ctx, url.ShortCode)
+		}
+		if err != nil {
+			slog.Error("Failed to record click", "short_code", url.ShortCode, "error", err)
+		}
+		h.emitWebhook(ctx, url, models.WebhookLinkClicked, click)
+	}(detached(c))
+
+	countRedirect(isQRScan, "redirected")
+	if url.HideReferrer {
+		renderHiddenReferrerRedirect(c, url)
 		return
 	}
+	// Not 301: browsers keep permanent redirects indefinitely, so later
+	// visits would bypass click counting, expiry and takedowns.
+	c.Header("Cache-Control", "private, max-age=0")
+	c.Redirect(http.StatusFound, url.OriginalURL)
+}
+
+func countRedirect(isQRScan bool, outcome string) {
+	source := "link"
+	if isQRScan {
+		source = qrSource
+	}
+	metrics.Redirects.WithLabelValues(source, outcome).Inc()
+}
 
-	c.Data(http.StatusOK, "image/png", qrData)
+// detached returns the request's context without its cancellation, for
+// work that outlives the request but should keep its request ID and trace.
+func detached(c *gin.Context) context.Context {
+	return context.WithoutCancel(c.Request.Context())
 }
 
-func isValidURL(url string) bool {
-	if len(url) < 8 {
-		return false
+// fetchLinkMetadata stores the destination's title, description and image
+// for previews. Failures only mean the preview shows less, so they're logged.
+func (h *Handler) fetchLinkMetadata(ctx context.Context, linkID, destination string) {
+	if !h.config.PreviewFetchEnabled {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, h.config.BackgroundTimeout)
+	defer cancel()
+
+	meta, err := h.metadata.Fetch(ctx, destination)
+	if err != nil {
+		slog.Warn("Failed to fetch link metadata", "link_id", linkID, "error", err)
+		return
+	}
+	if err := h.urlService.SetLinkMetadata(ctx, linkID, meta); err != nil {
+		slog.Error("Failed to store link metadata", "link_id", linkID, "error", err)
 	}
-	return url[:7] == "http://" || url[:8] == "https://"
 }
 
 func (h *Handler) Register(c *gin.Context) {
 	var req models.RegisterRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid request format",
-		})
+		c.Error(bindError(err))
 		return
 	}
 
-	user, err := h.userService.Register(req)
+	user, err := h.userService.Register(c.Request.Context(), req)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		c.Error(err)
 		return
 	}
 
-	token, err := utils.GenerateToken(user.ID, user.Email)
+	token, err := utils.GenerateToken(user.ID, user.Email, user.TokenVersion)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to generate token",
-		})
+		c.Error(err)
 		return
 	}
 
-	userResponse := models.UserResponse{
-		ID:        user.ID,
-		Email:     user.Email,
-		Name:      user.Name,
-		CreatedAt: user.CreatedAt,
-	}
+	h.audit(c, models.AuditEvent{ActorID: &user.ID, Action: models.AuditUserRegistered, TargetType: "user", TargetID: user.ID}, nil, newUserResponse(user))
+	h.audit(c, models.AuditEvent{ActorID: &user.ID, Action: models.AuditTokenIssued, TargetType: "user", TargetID: user.ID}, nil, nil)
+
+	userResponse := newUserResponse(user)
 
 	response := models.AuthResponse{
 		Token: token,
@@ -183,38 +296,68 @@ func (h *Handler) Login(c *gin.Context) {
 	var req models.LoginRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid request format",
-		})
+		c.Error(bindError(err))
 		return
 	}
 
-	user, err := h.userService.Login(req)
+	user, err := h.userService.Login(c.Request.Context(), req)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": err.Error(),
-		})
+		h.audit(c, models.AuditEvent{
+			Action:   models.AuditLoginFailure,
+			Metadata: map[string]string{"email": req.Email, "reason": err.Error()},
+		}, nil, nil)
+		c.Error(err)
 		return
 	}
 
-	token, err := utils.GenerateToken(user.ID, user.Email)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to generate token",
+	if user.TOTPEnabled {
+		mfaToken, err := h.userService.CreateMFAChallenge(c.Request.Context(), user)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, models.MFAChallengeResponse{
+			MFARequired: true,
+			MFAToken:    mfaToken,
 		})
 		return
 	}
 
-	userResponse := models.UserResponse{
-		ID:        user.ID,
-		Email:     user.Email,
-		Name:      user.Name,
-		CreatedAt: user.CreatedAt,
+	h.respondWithSession(c, user, "password")
+}
+
+// respondWithSession issues a session token for a fully authenticated user.
+// Users who are required to use 2FA but haven't enrolled yet only get a
+// short-lived token restricted to the enrollment endpoints.
+func (h *Handler) respondWithSession(c *gin.Context, user *models.User, method string) {
+	var token string
+	var err error
+	enrollmentRequired := user.MFARequired && !user.TOTPEnabled
+	if enrollmentRequired {
+		token, err = utils.GenerateEnrollmentToken(user.ID, user.Email, user.TokenVersion)
+	} else {
+		token, err = utils.GenerateToken(user.ID, user.Email, user.TokenVersion)
+	}
+	if err != nil {
+		c.Error(err)
+		return
 	}
 
+	h.audit(c, models.AuditEvent{
+		ActorID:    &user.ID,
+		Action:     models.AuditLoginSuccess,
+		TargetType: "user",
+		TargetID:   user.ID,
+		Metadata:   map[string]string{"method": method, "mfa_enrollment_required": fmt.Sprint(enrollmentRequired)},
+	}, nil, nil)
+
+	userResponse := newUserResponse(user)
+
 	response := models.AuthResponse{
-		Token: token,
-		User:  userResponse,
+		Token:                 token,
+		User:                  userResponse,
+		MFAEnrollmentRequired: enrollmentRequired,
 	}
 
 	c.JSON(http.StatusOK, response)
@@ -223,26 +366,17 @@ func (h *Handler) Login(c *gin.Context) {
 func (h *Handler) GetProfile(c *gin.Context) {
 	userID := c.GetString("userID")
 	if userID == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "User not authenticated",
-		})
+		c.Error(errNotAuthenticated)
 		return
 	}
 
-	user, err := h.userService.GetUserByID(userID)
+	user, err := h.userService.GetUserByID(c.Request.Context(), userID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": "User not found",
-		})
+		c.Error(err)
 		return
 	}
 
-	userResponse := models.UserResponse{
-		ID:        user.ID,
-		Email:     user.Email,
-		Name:      user.Name,
-		CreatedAt: user.CreatedAt,
-	}
+	userResponse := newUserResponse(user)
 
 	c.JSON(http.StatusOK, userResponse)
 }
@@ -250,56 +384,84 @@ func (h *Handler) GetProfile(c *gin.Context) {
 func (h *Handler) GetLinksByUser(c *gin.Context) {
 	userID := c.GetString("userID")
 	if userID == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "User not authenticated",
-		})
+		c.Error(errNotAuthenticated)
 		return
 	}
 
-	links, err := h.urlService.GetLinksByUser(userID)
+	links, err := h.urlService.GetLinksByUser(c.Request.Context(), userID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to get user links",
-		})
+		c.Error(err)
 		return
 	}
 
+	if err := h.linkHealth.AttachHealth(c.Request.Context(), links); err != nil {
+		slog.ErrorContext(c.Request.Context(), "Failed to get link health", "error", err)
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"links": links,
 	})
 }
 
+func newUserResponse(user *models.User) models.UserResponse {
+	return models.UserResponse{
+		ID:                 user.ID,
+		Email:              user.Email,
+		Name:               user.Name,
+		EmailVerified:      user.EmailVerified,
+		TOTPEnabled:        user.TOTPEnabled,
+		Role:               user.Role,
+		Plan:               user.Plan,
+		ReuseExistingLinks: user.ReuseExistingLinks,
+		CreatedAt:          user.CreatedAt,
+	}
+}
+
 func (h *Handler) AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": "Authorization header required",
-			})
-			c.Abort()
+			fail(c, services.Unauthorized("missing_token", "Authorization header required"))
 			return
 		}
 
 		tokenParts := strings.Split(authHeader, " ")
 		if len(tokenParts) != 2 || tokenParts[0] != "Bearer" {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": "Invalid authorization format",
-			})
-			c.Abort()
+			fail(c, services.Unauthorized("invalid_token", "Invalid authorization format"))
 			return
 		}
 
 		claims, err := utils.ValidateToken(tokenParts[1])
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": "Invalid token",
-			})
-			c.Abort()
+			fail(c, errInvalidToken)
+			return
+		}
+
+		// Tokens are revoked by bumping the user's token version, e.g. on
+		// password change or account deletion.
+		user, err := h.userService.GetUserByID(c.Request.Context(), claims.UserID)
+		if err != nil && !errors.Is(err, services.ErrNotFound) {
+			// Don't sign users out because the lookup failed or timed out.
+			fail(c, err)
+			return
+		}
+		if err != nil || user.DeletedAt != nil || user.TokenVersion != claims.TokenVersion {
+			fail(c, errInvalidToken)
+			return
+		}
+
+		if user.Disabled {
+			fail(c, services.Forbidden("account_disabled", "Account disabled"))
 			return
 		}
 
-		c.Set("userID", claims.UserID)
-		c.Set("email", claims.Email)
+		c.Set("userID", user.ID)
+		c.Set("email", user.Email)
+		c.Set("role", user.Role)
+		c.Set("tokenVersion", user.TokenVersion)
+		// Check the account as well as the token, so sessions issued before an
+		// admin required 2FA can't skip the enrollment.
+		c.Set("mfaEnrollmentPending", claims.MFAEnrollmentPending || (user.MFARequired && !user.TOTPEnabled))
 		c.Next()
 	}
 }
@@ -0,0 +1,41 @@
+package api
+
+import (
+	"context"
+	"net/http"
+
+	"slink-backend/internal/health"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Live reports that the process is up and serving. It checks nothing else,
+// so an unreachable dependency doesn't get the server restarted.
+func Live(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "ok",
+		"service": "slink-backend",
+	})
+}
+
+// Ready reports whether the server's dependencies work, with each
+// component's status and check latency. It answers 503 when any fails.
+func Ready(checker *health.Checker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		report := checker.Report()
+		status := http.StatusOK
+		if !report.OK() {
+			status = http.StatusServiceUnavailable
+		}
+		c.Header("Cache-Control", "no-store")
+		c.JSON(status, report)
+	}
+}
+
+// CheckQRCache is the readiness check for the in-memory QR cache. Taking
+// its lock is enough to show it isn't stuck.
+func (h *Handler) CheckQRCache(context.Context) error {
+	h.qrCache.Len()
+	return nil
+}
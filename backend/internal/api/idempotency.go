@@ -0,0 +1,103 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log/slog"
+
+	"slink-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+const maxIdempotencyKeyLength = 255
+
+// responseRecorder keeps a copy of everything written to the response.
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Idempotent honours the Idempotency-Key header: the first successful
+// response for a key is stored and replayed for retries with the same
+// request. Reusing a key for a different request is rejected, as is a retry
+// that arrives while the first request is still running. Failed responses
+// are not stored, so the request can be retried with the same key. Must run
+// after AuthMiddleware.
+func (h *Handler) Idempotent() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader("Idempotency-Key")
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			fail(c, services.InvalidInput("idempotency_key_too_long", "Idempotency-Key is too long"))
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			fail(c, errInvalidRequest)
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		hash := sha256.New()
+		hash.Write([]byte(c.Request.Method + " " + c.Request.URL.Path + "\n"))
+		hash.Write(body)
+		requestHash := hex.EncodeToString(hash.Sum(nil))
+
+		userID := c.GetString("userID")
+		record, err := h.idempotency.Begin(c.Request.Context(), userID, key, requestHash)
+		if err != nil {
+			fail(c, err)
+			return
+		}
+
+		if !record.Claimed {
+			switch {
+			case record.RequestHash != requestHash:
+				fail(c, &services.Error{Kind: errUnprocessable, Code: "idempotency_key_reused", Message: "Idempotency-Key was already used for a different request"})
+			case record.StatusCode == nil:
+				fail(c, services.Conflict("idempotency_key_in_progress", "A request with this Idempotency-Key is still in progress"))
+			default:
+				c.Header("Idempotent-Replayed", "true")
+				c.Data(*record.StatusCode, "application/json; charset=utf-8", record.ResponseBody)
+				c.Abort()
+			}
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+		// Render errors now so the stored response and status are final.
+		renderErrors(c)
+
+		// Settle the key even if the client has gone, or retries would see
+		// it in progress until it expires.
+		ctx := detached(c)
+		status := recorder.Status()
+		if status >= 200 && status < 300 {
+			err = h.idempotency.Complete(ctx, userID, key, status, recorder.body.Bytes())
+		} else {
+			err = h.idempotency.Release(ctx, userID, key)
+		}
+		if err != nil {
+			slog.ErrorContext(c.Request.Context(), "Failed to finish idempotency key", "error", err)
+		}
+	}
+}
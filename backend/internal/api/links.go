@@ -0,0 +1,70 @@
+package api
+
+import (
+	"net/http"
+
+	"slink-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) UpdateLink(c *gin.Context) {
+	var req models.UpdateLinkRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(bindError(err))
+		return
+	}
+
+	userID := c.GetString("userID")
+	before, err := h.urlService.GetOwnedURL(c.Request.Context(), c.Param("id"), userID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	url, err := h.urlService.UpdateLink(c.Request.Context(), before.ID, userID, req)
+	if err != nil {
+		c.Error(h.withAliasSuggestions(c.Request.Context(), err, req.CustomAlias, userID))
+		return
+	}
+
+	h.qrCache.InvalidateLink(url.ID)
+	h.audit(c, models.AuditEvent{Action: models.AuditLinkUpdated, TargetType: "link", TargetID: url.ID}, before, url)
+	h.emitWebhook(detached(c), url, models.WebhookLinkUpdated, url)
+	if url.OriginalURL != before.OriginalURL {
+		go h.fetchLinkMetadata(detached(c), url.ID, url.OriginalURL)
+	}
+
+	c.JSON(http.StatusOK, url)
+}
+
+func (h *Handler) DeleteLink(c *gin.Context) {
+	url, err := h.urlService.DeleteLink(c.Request.Context(), c.Param("id"), c.GetString("userID"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	h.qrCache.InvalidateLink(url.ID)
+	h.audit(c, models.AuditEvent{Action: models.AuditLinkDeleted, TargetType: "link", TargetID: url.ID}, url, nil)
+	h.emitWebhook(detached(c), url, models.WebhookLinkDeleted, url)
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *Handler) GetLinkStats(c *gin.Context) {
+	url, err := h.urlService.GetOwnedURL(c.Request.Context(), c.Param("id"), c.GetString("userID"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	stats, err := h.urlService.GetLinkStats(c.Request.Context(), url)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
@@ -0,0 +1,54 @@
+package api
+
+import (
+	"io"
+	"log/slog"
+	"runtime/debug"
+	"time"
+
+	"slink-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+var errPanic = &services.Error{Code: "internal_error", Message: "An internal error occurred"}
+
+// RequestLogger logs one line per request once the response is written.
+// The query string is left out since it may carry tokens.
+func RequestLogger() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		switch {
+		case status >= 500:
+			level = slog.LevelError
+		case status >= 400:
+			level = slog.LevelWarn
+		}
+
+		slog.Log(c.Request.Context(), level, "Request handled",
+			"method", c.Request.Method,
+			"path", c.Request.URL.Path,
+			"route", c.FullPath(),
+			"status", status,
+			"duration_ms", time.Since(start).Milliseconds(),
+			"bytes", c.Writer.Size(),
+			"client_ip", c.ClientIP(),
+		)
+	}
+}
+
+// Recovery turns panics into a logged internal error. Must run after
+// ErrorHandler, which renders the response.
+func Recovery() gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(io.Discard, func(c *gin.Context, recovered interface{}) {
+		slog.ErrorContext(c.Request.Context(), "Panic while handling request",
+			"panic", recovered,
+			"stack", string(debug.Stack()),
+		)
+		fail(c, errPanic)
+	})
+}
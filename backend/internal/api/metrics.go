@@ -0,0 +1,45 @@
+package api
+
+import (
+	"crypto/subtle"
+	"strconv"
+	"time"
+
+	"slink-backend/internal/metrics"
+	"slink-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// RequestMetrics counts requests and records their latency by route
+// template, so short codes and IDs don't each become a series. Requests
+// that match no route share the "unmatched" label.
+func RequestMetrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		metrics.HTTPRequests.WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).Inc()
+		metrics.HTTPDuration.WithLabelValues(c.Request.Method, route).Observe(time.Since(start).Seconds())
+	}
+}
+
+// Metrics serves the Prometheus metrics. When token is set, scrapers must
+// send it as a bearer token.
+func Metrics(token string) gin.HandlerFunc {
+	handler := promhttp.Handler()
+	expected := []byte("Bearer " + token)
+
+	return func(c *gin.Context) {
+		if token != "" && subtle.ConstantTimeCompare([]byte(c.GetHeader("Authorization")), expected) != 1 {
+			fail(c, services.Unauthorized("invalid_token", "Invalid metrics token"))
+			return
+		}
+		handler.ServeHTTP(c.Writer, c.Request)
+	}
+}
@@ -0,0 +1,85 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"slink-backend/internal/metrics"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMetricsToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(ErrorHandler())
+	router.GET("/metrics", Metrics("s3cret"))
+	router.GET("/open", Metrics(""))
+
+	tests := []struct {
+		path string
+		auth string
+		want int
+	}{
+		{"/metrics", "", http.StatusUnauthorized},
+		{"/metrics", "Bearer wrong", http.StatusUnauthorized},
+		{"/metrics", "s3cret", http.StatusUnauthorized},
+		{"/metrics", "Bearer s3cret", http.StatusOK},
+		{"/open", "", http.StatusOK},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		if tt.auth != "" {
+			req.Header.Set("Authorization", tt.auth)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		if rec.Code != tt.want {
+			t.Errorf("%s with %q: status = %d, want %d", tt.path, tt.auth, rec.Code, tt.want)
+		}
+		if rec.Code == http.StatusOK && !strings.Contains(rec.Body.String(), "slink_short_code_collisions_total") {
+			t.Errorf("%s: body does not list the slink metrics", tt.path)
+		}
+	}
+}
+
+func TestRequestMetricsLabelsRouteTemplates(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(RequestMetrics())
+	router.GET("/links/:id", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	matched := metrics.HTTPRequests.WithLabelValues(http.MethodGet, "/links/:id", "204")
+	unmatched := metrics.HTTPRequests.WithLabelValues(http.MethodGet, "unmatched", "404")
+	beforeMatched, beforeUnmatched := testutil.ToFloat64(matched), testutil.ToFloat64(unmatched)
+
+	for _, path := range []string{"/links/1", "/links/2", "/wp-login.php", "/random-probe"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	if got := testutil.ToFloat64(matched) - beforeMatched; got != 2 {
+		t.Errorf("/links/:id counted %v requests, want 2", got)
+	}
+	if got := testutil.ToFloat64(unmatched) - beforeUnmatched; got != 2 {
+		t.Errorf("unmatched counted %v requests, want 2", got)
+	}
+
+	families, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, family := range families {
+		for _, m := range family.GetMetric() {
+			for _, label := range m.GetLabel() {
+				if label.GetName() == "route" && (label.GetValue() == "/wp-login.php" || label.GetValue() == "/links/1") {
+					t.Errorf("%s has a series for the raw path %s", family.GetName(), label.GetValue())
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package api
+
+import (
+	"encoding/base64"
+	"net/http"
+
+	"slink-backend/internal/models"
+	"slink-backend/internal/services"
+	"slink-backend/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) LoginMFA(c *gin.Context) {
+	var req models.MFALoginRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(bindError(err))
+		return
+	}
+
+	user, err := h.userService.VerifyMFAChallenge(c.Request.Context(), req.MFAToken, req.Code, req.RecoveryCode)
+	if err != nil {
+		h.audit(c, models.AuditEvent{
+			Action:   models.AuditLoginFailure,
+			Metadata: map[string]string{"method": "mfa", "reason": err.Error()},
+		}, nil, nil)
+		c.Error(err)
+		return
+	}
+
+	h.respondWithSession(c, user, "mfa")
+}
+
+func (h *Handler) EnrollTOTP(c *gin.Context) {
+	secret, uri, err := h.userService.BeginTOTPEnrollment(c.Request.Context(), c.GetString("userID"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	qrData, err := h.qrService.GenerateQRCode(uri)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.MFAEnrollmentResponse{
+		Secret:     secret,
+		OTPAuthURI: uri,
+		QRCode:     "data:image/png;base64," + base64.StdEncoding.EncodeToString(qrData),
+	})
+}
+
+func (h *Handler) ConfirmTOTP(c *gin.Context) {
+	var req models.MFACodeRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(bindError(err))
+		return
+	}
+
+	codes, err := h.userService.ConfirmTOTPEnrollment(c.Request.Context(), c.GetString("userID"), req.Code)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	h.audit(c, models.AuditEvent{Action: models.AuditMFAEnabled, TargetType: "user", TargetID: c.GetString("userID")}, nil, nil)
+
+	response := models.RecoveryCodesResponse{RecoveryCodes: codes}
+
+	// Swap a restricted enrollment token for a regular session token.
+	if c.GetBool("mfaEnrollmentPending") {
+		token, err := utils.GenerateToken(c.GetString("userID"), c.GetString("email"), c.GetInt("tokenVersion"))
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		response.Token = token
+
+		h.audit(c, models.AuditEvent{Action: models.AuditTokenIssued, TargetType: "user", TargetID: c.GetString("userID")}, nil, nil)
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *Handler) DisableTOTP(c *gin.Context) {
+	var req models.DisableMFARequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(bindError(err))
+		return
+	}
+
+	err := h.userService.DisableTOTP(c.Request.Context(), c.GetString("userID"), req.Password, req.Code, req.RecoveryCode)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	h.audit(c, models.AuditEvent{Action: models.AuditMFADisabled, TargetType: "user", TargetID: c.GetString("userID")}, nil, nil)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Two-factor authentication disabled",
+	})
+}
+
+func (h *Handler) RegenerateRecoveryCodes(c *gin.Context) {
+	var req models.MFACodeRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(bindError(err))
+		return
+	}
+
+	codes, err := h.userService.RegenerateRecoveryCodes(c.Request.Context(), c.GetString("userID"), req.Code)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// EnforceMFAEnrollment blocks tokens that were issued only for completing a
+// forced 2FA enrollment. Must run after AuthMiddleware.
+func (h *Handler) EnforceMFAEnrollment() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetBool("mfaEnrollmentPending") {
+			fail(c, services.Forbidden("mfa_enrollment_required", "Two-factor authentication enrollment required"))
+			return
+		}
+
+		c.Next()
+	}
+}
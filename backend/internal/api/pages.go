@@ -0,0 +1,178 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"net/http"
+	"time"
+
+	"slink-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+var takedownTemplate = template.Must(template.New("takedown").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="robots" content="noindex">
+<title>Link unavailable</title>
+</head>
+<body>
+<h1>This link has been disabled</h1>
+<p>The short link <strong>{{.ShortCode}}</strong> was taken down by the Slink team.</p>
+{{if .Reason}}<p>Reason: {{.Reason}}</p>{{end}}
+</body>
+</html>
+`))
+
+var expiredTemplate = template.Must(template.New("expired").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="robots" content="noindex">
+<title>Link expired</title>
+</head>
+<body>
+<h1>This link has expired</h1>
+<p>The short link <strong>{{.ShortCode}}</strong> stopped working on {{.ExpiresAt.Format "January 2, 2006"}}.</p>
+</body>
+</html>
+`))
+
+var previewTemplate = template.Must(template.New("preview").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="robots" content="noindex">
+<title>Preview of {{.ShortURL}}</title>
+</head>
+<body>
+<h1>Where does {{.ShortURL}} go?</h1>
+{{if .Image}}<img src="{{.Image}}" alt="" style="max-width: 480px">{{end}}
+{{if .Title}}<h2>{{.Title}}</h2>{{end}}
+{{if .Description}}<p>{{.Description}}</p>{{end}}
+<p>Destination: <a href="{{.Destination}}" rel="noopener noreferrer">{{.Destination}}</a></p>
+<p>Created {{.CreatedAt.Format "January 2, 2006"}} &middot; {{.HitCount}} clicks</p>
+<p><a href="{{.Destination}}" rel="noopener noreferrer">Continue to the destination</a></p>
+</body>
+</html>
+`))
+
+var openGraphTemplate = template.Must(template.New("opengraph").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<meta property="og:type" content="website">
+<meta property="og:url" content="{{.ShortURL}}">
+<meta property="og:title" content="{{.Title}}">
+{{if .Description}}<meta property="og:description" content="{{.Description}}">
+<meta name="description" content="{{.Description}}">{{end}}
+{{if .Image}}<meta property="og:image" content="{{.Image}}">
+<meta name="twitter:card" content="summary_large_image">{{else}}<meta name="twitter:card" content="summary">{{end}}
+</head>
+<body>
+<p><a href="{{.Destination}}">{{.Title}}</a></p>
+</body>
+</html>
+`))
+
+// hiddenReferrerTemplate forwards visitors with a meta refresh, which,
+// unlike a redirect, can carry its own referrer policy in every browser.
+var hiddenReferrerTemplate = template.Must(template.New("hidden-referrer").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="robots" content="noindex">
+<meta name="referrer" content="no-referrer">
+<meta http-equiv="refresh" content="0; url={{.}}">
+<title>Redirecting</title>
+</head>
+<body>
+<p><a href="{{.}}" rel="noreferrer">Continue to the destination</a></p>
+</body>
+</html>
+`))
+
+// linkPage is what the preview and Open Graph pages show about a link.
+// Custom OG fields take precedence over the fetched metadata.
+type linkPage struct {
+	ShortURL    string
+	Destination string
+	Title       string
+	Description string
+	Image       string
+	CreatedAt   time.Time
+	HitCount    int
+}
+
+func newLinkPage(url *models.URL, baseURL string) linkPage {
+	page := linkPage{
+		ShortURL:    fmt.Sprintf("%s/%s", baseURL, url.ShortCode),
+		Destination: url.OriginalURL,
+		CreatedAt:   url.CreatedAt,
+		HitCount:    url.HitCount,
+	}
+	if url.Metadata != nil {
+		page.Title = url.Metadata.Title
+		page.Description = url.Metadata.Description
+		page.Image = url.Metadata.Image
+	}
+	if url.OGTitle != nil {
+		page.Title = *url.OGTitle
+	}
+	if url.OGDescription != nil {
+		page.Description = *url.OGDescription
+	}
+	if url.OGImage != nil {
+		page.Image = *url.OGImage
+	}
+	return page
+}
+
+func renderPreviewPage(c *gin.Context, url *models.URL, baseURL string) {
+	renderHTML(c, http.StatusOK, previewTemplate, newLinkPage(url, baseURL))
+}
+
+// renderOpenGraphPage answers social crawlers, which unfurl links from the
+// tags on the page they land on rather than following redirects.
+func renderOpenGraphPage(c *gin.Context, url *models.URL, baseURL string) {
+	page := newLinkPage(url, baseURL)
+	if page.Title == "" {
+		page.Title = page.ShortURL
+	}
+	renderHTML(c, http.StatusOK, openGraphTemplate, page)
+}
+
+func renderHiddenReferrerRedirect(c *gin.Context, url *models.URL) {
+	c.Header("Referrer-Policy", "no-referrer")
+	c.Header("Cache-Control", "no-store")
+	renderHTML(c, http.StatusOK, hiddenReferrerTemplate, url.OriginalURL)
+}
+
+func renderTakedownPage(c *gin.Context, url *models.URL) {
+	data := struct {
+		ShortCode string
+		Reason    string
+	}{ShortCode: url.ShortCode}
+	if url.TakedownReason != nil {
+		data.Reason = *url.TakedownReason
+	}
+
+	renderHTML(c, http.StatusGone, takedownTemplate, data)
+}
+
+func renderExpiredPage(c *gin.Context, url *models.URL) {
+	renderHTML(c, http.StatusGone, expiredTemplate, url)
+}
+
+func renderHTML(c *gin.Context, status int, tmpl *template.Template, data interface{}) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		c.String(http.StatusInternalServerError, "Failed to render page")
+		return
+	}
+	c.Data(status, "text/html; charset=utf-8", buf.Bytes())
+}
@@ -0,0 +1,91 @@
+package api
+
+import (
+	"net/http"
+
+	"slink-backend/internal/models"
+	"slink-backend/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) UpdateProfile(c *gin.Context) {
+	var req models.UpdateProfileRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(bindError(err))
+		return
+	}
+
+	before, err := h.userService.GetUserByID(c.Request.Context(), c.GetString("userID"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	user, err := h.userService.UpdateProfile(c.Request.Context(), before.ID, req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	h.audit(c, models.AuditEvent{Action: models.AuditUserUpdated, TargetType: "user", TargetID: user.ID}, newUserResponse(before), newUserResponse(user))
+
+	c.JSON(http.StatusOK, newUserResponse(user))
+}
+
+// ChangePassword revokes every existing token, so the caller receives a
+// fresh one in the response.
+func (h *Handler) ChangePassword(c *gin.Context) {
+	var req models.ChangePasswordRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(bindError(err))
+		return
+	}
+
+	user, err := h.userService.ChangePassword(c.Request.Context(), c.GetString("userID"), req.CurrentPassword, req.NewPassword)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	token, err := utils.GenerateToken(user.ID, user.Email, user.TokenVersion)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	h.audit(c, models.AuditEvent{Action: models.AuditPasswordChanged, TargetType: "user", TargetID: user.ID}, nil, nil)
+	h.audit(c, models.AuditEvent{Action: models.AuditTokenIssued, TargetType: "user", TargetID: user.ID}, nil, nil)
+
+	c.JSON(http.StatusOK, models.AuthResponse{
+		Token: token,
+		User:  newUserResponse(user),
+	})
+}
+
+func (h *Handler) DeleteProfile(c *gin.Context) {
+	var req models.DeleteAccountRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(bindError(err))
+		return
+	}
+
+	userID := c.GetString("userID")
+	if err := h.userService.DeleteAccount(c.Request.Context(), userID, req.Password); err != nil {
+		c.Error(err)
+		return
+	}
+
+	h.qrCache.InvalidateOwner(userID)
+	h.audit(c, models.AuditEvent{
+		Action:     models.AuditUserDeleted,
+		TargetType: "user",
+		TargetID:   userID,
+		Metadata:   map[string]string{"policy": h.config.AccountDeletionPolicy},
+	}, nil, nil)
+
+	c.Status(http.StatusNoContent)
+}
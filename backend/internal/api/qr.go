@@ -0,0 +1,258 @@
+package api
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"slink-backend/internal/models"
+	"slink-backend/internal/services"
+	"slink-backend/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/skip2/go-qrcode"
+)
+
+// qrSource is the src query value marking a visit as a QR code scan.
+const qrSource = "qr"
+
+// GenerateQR serves the QR code for a short link, styled by the query
+// parameters size, fg, bg, ec (L/M/Q/H), border and logo. The code encodes
+// the short URL marked as a QR scan, tagged with variant if given, so
+// RedirectURL can attribute the visit. Renderings are
+// cached by code and options, so repeat requests don't touch the database,
+// and carry a strong ETag for conditional requests.
+func (h *Handler) GenerateQR(c *gin.Context) {
+	shortCode := c.Param("shortCode")
+
+	var style models.QRStyle
+	if err := c.ShouldBindQuery(&style); err != nil {
+		c.Error(bindError(err))
+		return
+	}
+
+	opts, useLogo, err := h.qrOptions(style)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	format, err := qrFormat(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	key := services.QRCacheKey(shortCode, style.Variant, opts, useLogo, format)
+	entry, ok := h.qrCache.Get(key)
+	if ok && entry.Expired() {
+		// Look the link up again: it may have been extended since.
+		h.qrCache.InvalidateLink(entry.LinkID)
+		ok = false
+	}
+	if !ok {
+		url, err := h.urlService.GetURLByCode(c.Request.Context(), shortCode)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		if url.TakenDownAt != nil {
+			c.Error(errLinkDisabled)
+			return
+		}
+		if url.Expired() {
+			c.Error(errLinkExpired)
+			return
+		}
+
+		if useLogo {
+			if url.UserID == nil {
+				c.Error(services.InvalidField("logo", "logo_not_found", "link has no owner logo"))
+				return
+			}
+			logo, err := h.logoService.GetLogo(c.Request.Context(), *url.UserID)
+			if err != nil {
+				c.Error(services.InvalidField("logo", "logo_not_found", "link owner has not uploaded a logo"))
+				return
+			}
+			opts.Logo = logo
+		}
+
+		qrData, err := h.qrService.Generate(h.qrContentURL(url.ShortCode, style.Variant), opts, format)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		entry = services.NewCachedQR(qrData, format, url, useLogo)
+		h.qrCache.Set(key, entry)
+	}
+
+	disposition := "inline"
+	if download, _ := strconv.ParseBool(c.Query("download")); download {
+		disposition = "attachment"
+	}
+	c.Header("ETag", entry.ETag)
+	maxAge := h.config.QRCacheMaxAge
+	if entry.ExpiresAt != nil {
+		maxAge = min(maxAge, time.Until(*entry.ExpiresAt))
+	}
+	c.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", int(maxAge.Seconds())))
+	c.Header("Vary", "Accept")
+
+	if etagMatches(c.GetHeader("If-None-Match"), entry.ETag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`%s; filename="%s.%s"`, disposition, shortCode, format))
+	c.Data(http.StatusOK, entry.ContentType, entry.Data)
+}
+
+// qrOptions turns style into rendering options. Whether the owner's logo is
+// wanted is returned separately since loading it needs the link.
+func (h *Handler) qrOptions(style models.QRStyle) (services.QROptions, bool, error) {
+	opts := h.qrService.DefaultOptions()
+
+	if style.Size != 0 {
+		opts.Size = style.Size
+	}
+
+	if style.Foreground != "" {
+		fg, err := services.ParseHexColor(style.Foreground)
+		if err != nil {
+			return opts, false, services.InvalidField("fg", "invalid_color", "fg: "+err.Error())
+		}
+		opts.Foreground = fg
+	}
+
+	if style.Background != "" {
+		bg, err := services.ParseHexColor(style.Background)
+		if err != nil {
+			return opts, false, services.InvalidField("bg", "invalid_color", "bg: "+err.Error())
+		}
+		opts.Background = bg
+	}
+
+	if style.ErrorCorrection != "" {
+		level, err := services.ParseRecoveryLevel(style.ErrorCorrection)
+		if err != nil {
+			return opts, false, err
+		}
+		opts.Level = level
+	}
+
+	opts.Border = style.Border
+
+	if style.Variant != "" && !utils.IsValidTag(style.Variant) {
+		return opts, false, services.InvalidField("variant", "invalid_variant", fmt.Sprintf("invalid variant %q", style.Variant))
+	}
+
+	if style.Logo {
+		opts.Level = qrcode.Highest
+	}
+
+	return opts, style.Logo, h.qrService.ValidateOptions(opts)
+}
+
+// qrContentURL is the URL encoded into QR codes for shortCode. RedirectURL
+// strips the markers before redirecting.
+func (h *Handler) qrContentURL(shortCode, variant string) string {
+	query := url.Values{"src": {qrSource}}
+	if variant != "" {
+		query.Set("v", variant)
+	}
+	return fmt.Sprintf("%s/%s?%s", h.config.BaseURL, shortCode, query.Encode())
+}
+
+// etagMatches implements the weak comparison If-None-Match calls for.
+func etagMatches(header, etag string) bool {
+	if header == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// qrFormat picks the output format from ?format=, falling back to the
+// Accept header and finally PNG.
+func qrFormat(c *gin.Context) (services.QRFormat, error) {
+	if v := c.Query("format"); v != "" {
+		return services.ParseQRFormat(v)
+	}
+
+	switch c.NegotiateFormat(services.QRFormatPNG.ContentType(), services.QRFormatSVG.ContentType(), services.QRFormatPDF.ContentType()) {
+	case services.QRFormatSVG.ContentType():
+		return services.QRFormatSVG, nil
+	case services.QRFormatPDF.ContentType():
+		return services.QRFormatPDF, nil
+	default:
+		return services.QRFormatPNG, nil
+	}
+}
+
+func (h *Handler) UploadQRLogo(c *gin.Context) {
+	file, err := c.FormFile("logo")
+	if err != nil {
+		c.Error(services.InvalidField("logo", "required", "Missing logo file"))
+		return
+	}
+	if file.Size > services.MaxLogoBytes {
+		c.Error(&services.Error{Kind: errTooLarge, Code: "logo_too_large", Message: fmt.Sprintf("Logo must be at most %d bytes", services.MaxLogoBytes)})
+		return
+	}
+
+	f, err := file.Open()
+	if err != nil {
+		c.Error(errLogoUnreadable)
+		return
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(io.LimitReader(f, services.MaxLogoBytes+1))
+	if err != nil {
+		c.Error(errLogoUnreadable)
+		return
+	}
+
+	userID := c.GetString("userID")
+	if err := h.logoService.SaveLogo(c.Request.Context(), userID, data); err != nil {
+		c.Error(err)
+		return
+	}
+	h.qrCache.InvalidateLogo(userID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Logo uploaded",
+	})
+}
+
+func (h *Handler) GetQRLogo(c *gin.Context) {
+	data, err := h.logoService.GetLogoPNG(c.Request.Context(), c.GetString("userID"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Data(http.StatusOK, "image/png", data)
+}
+
+func (h *Handler) DeleteQRLogo(c *gin.Context) {
+	userID := c.GetString("userID")
+	if err := h.logoService.DeleteLogo(c.Request.Context(), userID); err != nil {
+		c.Error(err)
+		return
+	}
+	h.qrCache.InvalidateLogo(userID)
+
+	c.Status(http.StatusNoContent)
+}
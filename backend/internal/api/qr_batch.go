@@ -0,0 +1,183 @@
+package api
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"slink-backend/internal/models"
+	"slink-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+var manifestHeader = []string{"filename", "link_id", "short_code", "short_url", "original_url", "error"}
+
+// BatchQR streams a ZIP archive with a QR code for each of the caller's
+// links selected by ID or tag, plus a manifest.csv describing every entry.
+// Links that can't be rendered, and requested IDs that don't match one of
+// the caller's links, are listed in the manifest with the reason instead of
+// failing the whole archive.
+func (h *Handler) BatchQR(c *gin.Context) {
+	var req models.QRBatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(bindError(err))
+		return
+	}
+
+	if len(req.LinkIDs) == 0 && req.Tag == "" {
+		c.Error(services.InvalidInput("selection_required", "link_ids or tag is required"))
+		return
+	}
+	if len(req.LinkIDs) > h.config.QRBatchMax {
+		c.Error(h.errBatchTooLarge())
+		return
+	}
+
+	format := services.QRFormatPNG
+	if req.Format != "" {
+		f, err := services.ParseQRFormat(req.Format)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		format = f
+	}
+
+	opts, useLogo, err := h.qrOptions(req.QRStyle)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	userID := c.GetString("userID")
+	if useLogo {
+		logo, err := h.logoService.GetLogo(c.Request.Context(), userID)
+		if err != nil {
+			c.Error(services.InvalidField("logo", "logo_not_found", "You have not uploaded a logo"))
+			return
+		}
+		opts.Logo = logo
+	}
+
+	// Fetch one more than allowed so an oversized tag selection is rejected
+	// rather than silently truncated.
+	links, err := h.urlService.FilterLinksByUser(c.Request.Context(), userID, req.LinkIDs, req.Tag, h.config.QRBatchMax+1)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if len(links) == 0 {
+		c.Error(services.NotFound("link_not_found", "No matching links"))
+		return
+	}
+	if len(links) > h.config.QRBatchMax {
+		c.Error(h.errBatchTooLarge())
+		return
+	}
+
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="qr-codes-%s.zip"`, time.Now().UTC().Format("20060102-150405")))
+	c.Status(http.StatusOK)
+
+	zw := zip.NewWriter(c.Writer)
+	manifest := [][]string{manifestHeader}
+	names := make(map[string]int)
+	found := make(map[string]bool, len(links))
+
+	for i := range links {
+		link := &links[i]
+		found[link.ID] = true
+		shortURL := fmt.Sprintf("%s/%s", h.config.BaseURL, link.ShortCode)
+		row := []string{"", link.ID, link.ShortCode, shortURL, link.OriginalURL, ""}
+
+		if link.TakenDownAt != nil {
+			row[5] = "link has been taken down"
+			manifest = append(manifest, row)
+			continue
+		}
+		if link.Expired() {
+			row[5] = "link has expired"
+			manifest = append(manifest, row)
+			continue
+		}
+
+		data, err := h.batchQRData(link, req.Variant, opts, useLogo, format)
+		if err != nil {
+			row[5] = err.Error()
+			manifest = append(manifest, row)
+			continue
+		}
+
+		row[0] = uniqueFilename(names, link.ShortCode, string(format))
+		w, err := zw.Create(row[0])
+		if err != nil {
+			slog.ErrorContext(c.Request.Context(), "Failed to write QR batch entry", "error", err)
+			return
+		}
+		if _, err := w.Write(data); err != nil {
+			slog.ErrorContext(c.Request.Context(), "Failed to write QR batch entry", "error", err)
+			return
+		}
+		manifest = append(manifest, row)
+	}
+
+	missing := "link not found"
+	if req.Tag != "" {
+		missing = fmt.Sprintf("link not found or not tagged %q", req.Tag)
+	}
+	for _, id := range req.LinkIDs {
+		if !found[id] {
+			found[id] = true
+			manifest = append(manifest, []string{"", id, "", "", "", missing})
+		}
+	}
+
+	w, err := zw.Create("manifest.csv")
+	if err == nil {
+		err = csv.NewWriter(w).WriteAll(manifest)
+	}
+	if err == nil {
+		err = zw.Close()
+	}
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Failed to finish QR batch archive", "error", err)
+	}
+}
+
+func (h *Handler) errBatchTooLarge() error {
+	return services.InvalidInput("too_many_links", fmt.Sprintf("At most %d links can be exported at once", h.config.QRBatchMax))
+}
+
+// batchQRData renders link through the same cache GenerateQR uses.
+func (h *Handler) batchQRData(link *models.URL, variant string, opts services.QROptions, useLogo bool, format services.QRFormat) ([]byte, error) {
+	key := services.QRCacheKey(link.ShortCode, variant, opts, useLogo, format)
+	if entry, ok := h.qrCache.Get(key); ok {
+		return entry.Data, nil
+	}
+
+	data, err := h.qrService.Generate(h.qrContentURL(link.ShortCode, variant), opts, format)
+	if err != nil {
+		return nil, err
+	}
+	h.qrCache.Set(key, services.NewCachedQR(data, format, link, useLogo))
+	return data, nil
+}
+
+// uniqueFilename returns code.ext, suffixed if an earlier entry already used
+// the name. Comparison ignores case since aliases may differ only in case and
+// most unzip targets are case-insensitive filesystems.
+func uniqueFilename(seen map[string]int, code, ext string) string {
+	name := fmt.Sprintf("%s.%s", code, ext)
+	key := strings.ToLower(name)
+	n := seen[key]
+	seen[key] = n + 1
+	if n == 0 {
+		return name
+	}
+	return uniqueFilename(seen, fmt.Sprintf("%s-%d", code, n+1), ext)
+}
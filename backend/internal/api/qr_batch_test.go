@@ -0,0 +1,131 @@
+package api
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"slink-backend/internal/config"
+	"slink-backend/internal/models"
+	"slink-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// batchLinks returns whichever of its links match the requested IDs or tag.
+type batchLinks struct {
+	services.URLServiceInterface
+	links []models.URL
+}
+
+func (f *batchLinks) FilterLinksByUser(ctx context.Context, userID string, ids []string, tag string, limit int) ([]models.URL, error) {
+	var out []models.URL
+	for _, link := range f.links {
+		if len(ids) > 0 && !slices.Contains(ids, link.ID) {
+			continue
+		}
+		if tag != "" && !slices.Contains(link.Tags, tag) {
+			continue
+		}
+		out = append(out, link)
+	}
+	return out[:min(len(out), limit)], nil
+}
+
+func batchQR(t *testing.T, links []models.URL, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	h := &Handler{
+		urlService: &batchLinks{links: links},
+		qrService:  services.NewQRService(128, 64, 512),
+		qrCache:    services.NewQRCache(16, time.Minute),
+		config:     &config.Config{BaseURL: "https://sl.ink", QRBatchMax: 3},
+	}
+
+	router := gin.New()
+	router.Use(ErrorHandler(), func(c *gin.Context) { c.Set("userID", "user-1") })
+	router.POST("/qr/batch", h.BatchQR)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/qr/batch", strings.NewReader(body)))
+	return rec
+}
+
+func TestBatchQRArchive(t *testing.T) {
+	expired := time.Now().Add(-time.Hour)
+	links := []models.URL{
+		{ID: "link-1", ShortCode: "abc", OriginalURL: "https://example.com/a"},
+		{ID: "link-2", ShortCode: "old", OriginalURL: "https://example.com/b", ExpiresAt: &expired},
+	}
+	rec := batchQR(t, links, `{"link_ids":["link-1","link-2","missing"]}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(rec.Body.Bytes()), int64(rec.Body.Len()))
+	if err != nil {
+		t.Fatalf("archive: %v", err)
+	}
+	files := map[string][]byte{}
+	for _, f := range zr.File {
+		r, err := f.Open()
+		if err != nil {
+			t.Fatalf("open %s: %v", f.Name, err)
+		}
+		files[f.Name], _ = io.ReadAll(r)
+		r.Close()
+	}
+	if len(files) != 2 {
+		t.Errorf("archive has %d entries, want abc.png and manifest.csv", len(files))
+	}
+	if !bytes.HasPrefix(files["abc.png"], []byte("\x89PNG")) {
+		t.Error("abc.png is not a PNG")
+	}
+
+	rows, err := csv.NewReader(bytes.NewReader(files["manifest.csv"])).ReadAll()
+	if err != nil {
+		t.Fatalf("manifest: %v", err)
+	}
+	want := [][]string{
+		manifestHeader,
+		{"abc.png", "link-1", "abc", "https://sl.ink/abc", "https://example.com/a", ""},
+		{"", "link-2", "old", "https://sl.ink/old", "https://example.com/b", "link has expired"},
+		{"", "missing", "", "", "", "link not found"},
+	}
+	if !slices.EqualFunc(rows, want, slices.Equal) {
+		t.Errorf("manifest = %q, want %q", rows, want)
+	}
+}
+
+func TestBatchQRRejectsTooManyLinks(t *testing.T) {
+	links := []models.URL{
+		{ID: "link-1", ShortCode: "a", Tags: []string{"promo"}},
+		{ID: "link-2", ShortCode: "b", Tags: []string{"promo"}},
+		{ID: "link-3", ShortCode: "c", Tags: []string{"promo"}},
+		{ID: "link-4", ShortCode: "d", Tags: []string{"promo"}},
+	}
+
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"ids", `{"link_ids":["link-1","link-2","link-3","link-4"]}`},
+		{"tag", `{"tag":"promo"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := batchQR(t, links, tt.body)
+			if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "too_many_links") {
+				t.Errorf("status = %d, body %s; want 400 too_many_links", rec.Code, rec.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"slink-backend/internal/config"
+	"slink-backend/internal/models"
+	"slink-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fakeLinks serves a single link and reports the hits recorded for it;
+// other methods panic if called.
+type fakeLinks struct {
+	services.URLServiceInterface
+	link *models.URL
+	hits chan string
+}
+
+func (f *fakeLinks) GetURLByCode(ctx context.Context, code string) (*models.URL, error) {
+	if code != f.link.ShortCode {
+		return nil, services.NotFound("link_not_found", "link not found")
+	}
+	return f.link, nil
+}
+
+func (f *fakeLinks) IncrementHitCount(ctx context.Context, code string) error {
+	f.hits <- code
+	return nil
+}
+
+func redirect(t *testing.T, link *models.URL) (*httptest.ResponseRecorder, *fakeLinks) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	links := &fakeLinks{link: link, hits: make(chan string, 1)}
+	h := &Handler{urlService: links, config: &config.Config{BackgroundTimeout: time.Second}}
+
+	router := gin.New()
+	router.Use(ErrorHandler())
+	router.GET("/:shortCode", h.RedirectURL)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/"+link.ShortCode, nil))
+	return rec, links
+}
+
+func TestRedirectIsNotCached(t *testing.T) {
+	rec, links := redirect(t, &models.URL{ID: "link-1", ShortCode: "abc123", OriginalURL: "https://example.com/page"})
+
+	if rec.Code != http.StatusFound {
+		t.Errorf("status = %d, want 302", rec.Code)
+	}
+	if got := rec.Header().Get("Location"); got != "https://example.com/page" {
+		t.Errorf("Location = %q", got)
+	}
+	if got := rec.Header().Get("Cache-Control"); got != "private, max-age=0" {
+		t.Errorf("Cache-Control = %q, want private, max-age=0", got)
+	}
+
+	select {
+	case code := <-links.hits:
+		if code != "abc123" {
+			t.Errorf("hit recorded for %q", code)
+		}
+	case <-time.After(time.Second):
+		t.Error("hit was not recorded")
+	}
+}
+
+func TestRedirectRefusesExpiredLink(t *testing.T) {
+	expired := time.Now().Add(-time.Hour)
+	rec, _ := redirect(t, &models.URL{ID: "link-1", ShortCode: "abc123", OriginalURL: "https://example.com/page", ExpiresAt: &expired})
+
+	if rec.Code == http.StatusFound || rec.Header().Get("Location") != "" {
+		t.Errorf("expired link redirected with status %d", rec.Code)
+	}
+}
+
+func TestRedirectHidingReferrer(t *testing.T) {
+	rec, _ := redirect(t, &models.URL{ID: "link-1", ShortCode: "abc123", OriginalURL: "https://example.com/page?a=1&b=2", HideReferrer: true})
+
+	if rec.Code != http.StatusOK || rec.Header().Get("Location") != "" {
+		t.Errorf("status = %d, Location = %q; want a 200 page instead of a redirect", rec.Code, rec.Header().Get("Location"))
+	}
+	if got := rec.Header().Get("Referrer-Policy"); got != "no-referrer" {
+		t.Errorf("Referrer-Policy = %q, want no-referrer", got)
+	}
+	body := rec.Body.String()
+	for _, want := range []string{
+		`<meta name="referrer" content="no-referrer">`,
+		`<meta http-equiv="refresh" content="0; url=https://example.com/page?a=1&amp;b=2">`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("page is missing %s:\n%s", want, body)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"slink-backend/internal/config"
+	"slink-backend/internal/models"
+	"slink-backend/internal/services"
+	"slink-backend/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FindLinkByDestination matches like the real query: same owner and same
+// normalized destination.
+func (f *fakeLinks) FindLinkByDestination(ctx context.Context, userID, originalURL string) (*models.URL, error) {
+	want, err := utils.NormalizeURL(originalURL)
+	if err != nil {
+		return nil, err
+	}
+	have, _ := utils.NormalizeURL(f.link.OriginalURL)
+	if f.link.UserID == nil || *f.link.UserID != userID || have != want {
+		return nil, services.NotFound("link_not_found", "link not found")
+	}
+	return f.link, nil
+}
+
+func shorten(t *testing.T, links *fakeLinks, reuseByDefault bool, body string) (int, models.ShortenResponse) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	h := &Handler{
+		urlService:  links,
+		userService: &fakeUsers{user: &models.User{ID: testUserID, ReuseExistingLinks: reuseByDefault}},
+		config:      &config.Config{BaseURL: "https://sl.ink"},
+	}
+
+	router := gin.New()
+	router.Use(ErrorHandler())
+	router.POST("/api/shorten", func(c *gin.Context) { c.Set("userID", testUserID) }, h.ShortenURL)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/shorten", strings.NewReader(body)))
+	var response models.ShortenResponse
+	json.Unmarshal(rec.Body.Bytes(), &response)
+	return rec.Code, response
+}
+
+func TestShortenReusesLinkToEquivalentDestination(t *testing.T) {
+	owner := testUserID
+	links := &fakeLinks{link: &models.URL{ID: "link-1", ShortCode: "abc123", OriginalURL: "https://Example.com/a/?b=2&a=1", UserID: &owner}}
+
+	tests := []struct {
+		name           string
+		reuseByDefault bool
+		body           string
+	}{
+		{"user default", true, `{"original_url":"https://example.com:443/a?a=1&b=2"}`},
+		{"request flag", false, `{"original_url":"https://example.com/a?a=1&b=2","reuse_existing":true}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, response := shorten(t, links, tt.reuseByDefault, tt.body)
+			if code != http.StatusOK || !response.Reused || response.ShortCode != "abc123" {
+				t.Errorf("got %d %+v, want 200 reusing abc123", code, response)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package api
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"slink-backend/internal/models"
+	"slink-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// emitWebhook queues eventType for the link owner's webhook endpoints.
+// Failures are logged; they never fail the request that caused the event.
+func (h *Handler) emitWebhook(ctx context.Context, url *models.URL, eventType string, data interface{}) {
+	if url.UserID == nil {
+		return
+	}
+	if err := h.webhookService.Emit(ctx, *url.UserID, eventType, data); err != nil {
+		slog.ErrorContext(ctx, "Failed to emit webhook", "event", eventType, "link_id", url.ID, "error", err)
+	}
+}
+
+func (h *Handler) ListWebhooks(c *gin.Context) {
+	endpoints, err := h.webhookService.ListEndpoints(c.Request.Context(), c.GetString("userID"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"webhooks":    endpoints,
+		"event_types": models.WebhookEventTypes,
+	})
+}
+
+func (h *Handler) CreateWebhook(c *gin.Context) {
+	var req models.CreateWebhookRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(bindError(err))
+		return
+	}
+
+	endpoint, err := h.webhookService.CreateEndpoint(c.Request.Context(), c.GetString("userID"), req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	h.audit(c, models.AuditEvent{Action: models.AuditWebhookCreated, TargetType: "webhook", TargetID: endpoint.ID}, nil, endpoint)
+
+	c.JSON(http.StatusCreated, endpoint)
+}
+
+func (h *Handler) UpdateWebhook(c *gin.Context) {
+	var req models.UpdateWebhookRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(bindError(err))
+		return
+	}
+
+	userID := c.GetString("userID")
+	before, err := h.webhookService.GetEndpoint(c.Request.Context(), c.Param("id"), userID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	endpoint, err := h.webhookService.UpdateEndpoint(c.Request.Context(), before.ID, userID, req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	h.audit(c, models.AuditEvent{Action: models.AuditWebhookUpdated, TargetType: "webhook", TargetID: endpoint.ID}, before, endpoint)
+
+	c.JSON(http.StatusOK, endpoint)
+}
+
+func (h *Handler) RotateWebhookSecret(c *gin.Context) {
+	endpoint, err := h.webhookService.RotateSecret(c.Request.Context(), c.Param("id"), c.GetString("userID"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	h.audit(c, models.AuditEvent{
+		Action:     models.AuditWebhookUpdated,
+		TargetType: "webhook",
+		TargetID:   endpoint.ID,
+		Metadata:   map[string]string{"change": "secret_rotated"},
+	}, nil, nil)
+
+	c.JSON(http.StatusOK, endpoint)
+}
+
+func (h *Handler) DeleteWebhook(c *gin.Context) {
+	endpoint, err := h.webhookService.DeleteEndpoint(c.Request.Context(), c.Param("id"), c.GetString("userID"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	h.audit(c, models.AuditEvent{Action: models.AuditWebhookDeleted, TargetType: "webhook", TargetID: endpoint.ID}, endpoint, nil)
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListWebhookDeliveries returns an endpoint's delivery log, newest first.
+// ?status=pending|succeeded|dead narrows it down.
+func (h *Handler) ListWebhookDeliveries(c *gin.Context) {
+	status := c.Query("status")
+	switch status {
+	case "", models.DeliveryPending, models.DeliverySucceeded, models.DeliveryDead:
+	default:
+		c.Error(services.InvalidField("status", "oneof", "Invalid status"))
+		return
+	}
+
+	limit, offset := pagination(c)
+	deliveries, err := h.webhookService.ListDeliveries(c.Request.Context(), c.Param("id"), c.GetString("userID"), status, limit, offset)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"deliveries": deliveries,
+		"limit":      limit,
+		"offset":     offset,
+	})
+}
+
+func (h *Handler) RedeliverWebhook(c *gin.Context) {
+	delivery, err := h.webhookService.Redeliver(c.Request.Context(), c.Param("id"), c.Param("deliveryID"), c.GetString("userID"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, delivery)
+}
@@ -0,0 +1,126 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// LRU is a size-bounded, thread-safe cache whose entries also expire after
+// a fixed TTL. A zero TTL disables expiry.
+type LRU[K comparable, V any] struct {
+	mu       sync.Mutex
+	capacity int
+	ttl      time.Duration
+	items    map[K]*list.Element
+	order    *list.List
+	hits     uint64
+	misses   uint64
+}
+
+type entry[K comparable, V any] struct {
+	key       K
+	value     V
+	expiresAt time.Time
+}
+
+func NewLRU[K comparable, V any](capacity int, ttl time.Duration) *LRU[K, V] {
+	return &LRU[K, V]{
+		capacity: capacity,
+		ttl:      ttl,
+		items:    make(map[K]*list.Element),
+		order:    list.New(),
+	}
+}
+
+func (c *LRU[K, V]) Get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var zero V
+	elem, ok := c.items[key]
+	if !ok {
+		c.misses++
+		return zero, false
+	}
+
+	e := elem.Value.(*entry[K, V])
+	if c.ttl > 0 && time.Now().After(e.expiresAt) {
+		c.removeElement(elem)
+		c.misses++
+		return zero, false
+	}
+
+	c.order.MoveToFront(elem)
+	c.hits++
+	return e.value, true
+}
+
+func (c *LRU[K, V]) Set(key K, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.capacity <= 0 {
+		return
+	}
+
+	expiresAt := time.Now().Add(c.ttl)
+	if elem, ok := c.items[key]; ok {
+		e := elem.Value.(*entry[K, V])
+		e.value = value
+		e.expiresAt = expiresAt
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.items[key] = c.order.PushFront(&entry[K, V]{key: key, value: value, expiresAt: expiresAt})
+	for c.order.Len() > c.capacity {
+		c.removeElement(c.order.Back())
+	}
+}
+
+func (c *LRU[K, V]) Delete(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.items[key]; ok {
+		c.removeElement(elem)
+	}
+}
+
+// DeleteFunc removes every entry for which fn returns true and reports how
+// many were removed.
+func (c *LRU[K, V]) DeleteFunc(fn func(key K, value V) bool) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	removed := 0
+	for elem := c.order.Front(); elem != nil; {
+		next := elem.Next()
+		e := elem.Value.(*entry[K, V])
+		if fn(e.key, e.value) {
+			c.removeElement(elem)
+			removed++
+		}
+		elem = next
+	}
+	return removed
+}
+
+func (c *LRU[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// Stats returns the number of lookups that hit and missed so far.
+func (c *LRU[K, V]) Stats() (hits, misses uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hits, c.misses
+}
+
+func (c *LRU[K, V]) removeElement(elem *list.Element) {
+	c.order.Remove(elem)
+	delete(c.items, elem.Value.(*entry[K, V]).key)
+}
@@ -0,0 +1,83 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLRUEvictsLeastRecentlyUsed(t *testing.T) {
+	c := NewLRU[string, int](2, 0)
+	c.Set("a", 1)
+	c.Set("b", 2)
+	if _, ok := c.Get("a"); !ok {
+		t.Fatal("a missing before eviction")
+	}
+	c.Set("c", 3)
+
+	if _, ok := c.Get("b"); ok {
+		t.Error("b should have been evicted as the least recently used entry")
+	}
+	for key, want := range map[string]int{"a": 1, "c": 3} {
+		if got, ok := c.Get(key); !ok || got != want {
+			t.Errorf("Get(%q) = %d, %v; want %d, true", key, got, ok, want)
+		}
+	}
+	if c.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", c.Len())
+	}
+}
+
+func TestLRUSetRefreshesEntry(t *testing.T) {
+	c := NewLRU[string, int](2, 0)
+	c.Set("a", 1)
+	c.Set("b", 2)
+	c.Set("a", 10)
+	c.Set("c", 3)
+
+	if got, ok := c.Get("a"); !ok || got != 10 {
+		t.Errorf("Get(a) = %d, %v; want 10, true", got, ok)
+	}
+	if _, ok := c.Get("b"); ok {
+		t.Error("b should have been evicted")
+	}
+}
+
+func TestLRUExpiry(t *testing.T) {
+	c := NewLRU[string, int](2, time.Millisecond)
+	c.Set("a", 1)
+	time.Sleep(5 * time.Millisecond)
+
+	if _, ok := c.Get("a"); ok {
+		t.Error("expired entry was returned")
+	}
+	if c.Len() != 0 {
+		t.Errorf("Len() = %d after expiry, want 0", c.Len())
+	}
+	if hits, misses := c.Stats(); hits != 0 || misses != 1 {
+		t.Errorf("Stats() = %d, %d; want 0, 1", hits, misses)
+	}
+}
+
+func TestLRUZeroCapacityStoresNothing(t *testing.T) {
+	c := NewLRU[string, int](0, 0)
+	c.Set("a", 1)
+	if _, ok := c.Get("a"); ok {
+		t.Error("zero-capacity cache stored an entry")
+	}
+}
+
+func TestLRUDeleteFunc(t *testing.T) {
+	c := NewLRU[string, int](4, 0)
+	for i, key := range []string{"a", "b", "c", "d"} {
+		c.Set(key, i)
+	}
+	if n := c.DeleteFunc(func(_ string, v int) bool { return v%2 == 0 }); n != 2 {
+		t.Errorf("DeleteFunc removed %d entries, want 2", n)
+	}
+	if _, ok := c.Get("a"); ok {
+		t.Error("a should have been deleted")
+	}
+	if _, ok := c.Get("b"); !ok {
+		t.Error("b should have been kept")
+	}
+}
@@ -1,51 +1,144 @@
+// Package config loads the server configuration. Every setting has a
+// default and can be overridden, in increasing order of precedence, by a
+// YAML or TOML config file, by environment variables and by command-line
+// flags.
+//
+// Settings are described by struct tags on Config:
+//
+//	env         environment variable; the file key is its lower-case form
+//	            and the flag its lower-case form with dashes
+//	default     value used when no source sets the setting
+//	unit        for durations, the unit of plain numbers (ms, s, m or h)
+//	oneof       comma-separated list of accepted values
+//	secret      redacted by --print-config
+//	production  "required" rejects the default and placeholder values when
+//	            APP_ENV is production
 package config
 
 import (
-	"os"
+	"time"
+)
+
+const (
+	EnvDevelopment = "development"
+	EnvProduction  = "production"
 )
 
 type Config struct {
-	SupabaseURL        string
-	SupabaseKey        string
-	SupabaseProjectRef string
-	BaseURL            string
-	QRSize             int
-}
+	Environment string `env:"APP_ENV" default:"development" oneof:"development,production"`
+	Port        int    `env:"PORT" default:"8080"`
+	JWTSecret   string `env:"JWT_SECRET" default:"your-secret-key" secret:"true" production:"required"`
+	// JWTPreviousSecrets still verify tokens after a key rotation, until
+	// the tokens signed with them expire.
+	JWTPreviousSecrets []string `env:"JWT_PREVIOUS_SECRETS" secret:"true"`
 
-func Load() *Config {
-	return &Config{
-		SupabaseURL:        getEnv("SUPABASE_URL", "https://your-project.supabase.co"),
-		SupabaseKey:        getEnv("SUPABASE_KEY", "your-anon-key"),
-		SupabaseProjectRef: getEnv("SUPABASE_PROJECT_REF", "your-project-ref"),
-		BaseURL:            getEnv("BASE_URL", "http://localhost:8080"),
-		QRSize:             getEnvAsInt("QR_SIZE", 256),
-	}
-}
+	SupabaseURL        string `env:"SUPABASE_URL" default:"https://your-project.supabase.co" production:"required"`
+	SupabaseKey        string `env:"SUPABASE_KEY" default:"your-anon-key" secret:"true" production:"required"`
+	SupabaseProjectRef string `env:"SUPABASE_PROJECT_REF" default:"your-project-ref" production:"required"`
+	// SupabaseDBURL is the Postgres connection string slinkctl applies
+	// migrations with; the server itself doesn't use it.
+	SupabaseDBURL          string `env:"SUPABASE_DB_URL" secret:"true"`
+	BaseURL                string `env:"BASE_URL" default:"http://localhost:8080" production:"required"`
+	FrontendURL            string `env:"FRONTEND_URL" default:"http://localhost:3000" production:"required"`
+	ShortCodeStrategy      string `env:"SHORT_CODE_STRATEGY" default:"random" oneof:"random,counter,sqids,hash"`
+	ShortCodeAlphabet      string `env:"SHORT_CODE_ALPHABET" default:"abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"`
+	ShortCodeLength        int    `env:"SHORT_CODE_LENGTH" default:"6"`
+	ShortCodeMaxLength     int    `env:"SHORT_CODE_MAX_LENGTH" default:"12"`
+	ShortCodeGrowThreshold int    `env:"SHORT_CODE_GROW_THRESHOLD" default:"10"`
 
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
-	}
-	return defaultValue
-}
+	QRSize        int           `env:"QR_SIZE" default:"256"`
+	QRMinSize     int           `env:"QR_MIN_SIZE" default:"64"`
+	QRMaxSize     int           `env:"QR_MAX_SIZE" default:"2048"`
+	QRCacheSize   int           `env:"QR_CACHE_SIZE" default:"1000"`
+	QRCacheTTL    time.Duration `env:"QR_CACHE_TTL_SECONDS" default:"1h" unit:"s"`
+	QRCacheMaxAge time.Duration `env:"QR_CACHE_MAX_AGE" default:"5m" unit:"s"`
+	QRBatchMax    int           `env:"QR_BATCH_MAX" default:"500"`
+
+	PreviewFetchEnabled bool          `env:"PREVIEW_FETCH_ENABLED" default:"true"`
+	PreviewFetchTimeout time.Duration `env:"PREVIEW_FETCH_TIMEOUT_SECONDS" default:"5s" unit:"s"`
+
+	LinkCheckEnabled          bool          `env:"LINK_CHECK_ENABLED" default:"true"`
+	LinkCheckInterval         time.Duration `env:"LINK_CHECK_INTERVAL_MINUTES" default:"1h" unit:"m"`
+	LinkCheckConcurrency      int           `env:"LINK_CHECK_CONCURRENCY" default:"8"`
+	LinkCheckBatchSize        int           `env:"LINK_CHECK_BATCH_SIZE" default:"200"`
+	LinkCheckTimeout          time.Duration `env:"LINK_CHECK_TIMEOUT_SECONDS" default:"10s" unit:"s"`
+	LinkCheckFailureThreshold int           `env:"LINK_CHECK_FAILURE_THRESHOLD" default:"3"`
+
+	NotifyDriver     string `env:"NOTIFY_DRIVER" default:"log" oneof:"log,email,webhook"`
+	NotifyWebhookURL string `env:"NOTIFY_WEBHOOK_URL" secret:"true"`
+
+	WebhooksEnabled    bool          `env:"WEBHOOKS_ENABLED" default:"true"`
+	WebhookMaxAttempts int           `env:"WEBHOOK_MAX_ATTEMPTS" default:"10"`
+	WebhookBatchSize   int           `env:"WEBHOOK_BATCH_SIZE" default:"50"`
+	WebhookConcurrency int           `env:"WEBHOOK_CONCURRENCY" default:"4"`
+	WebhookTimeout     time.Duration `env:"WEBHOOK_TIMEOUT_SECONDS" default:"10s" unit:"s"`
+
+	IdempotencyKeyTTL time.Duration `env:"IDEMPOTENCY_KEY_TTL_HOURS" default:"24h" unit:"h"`
+
+	AliasReservedWords []string `env:"ALIAS_RESERVED_WORDS"`
+	AliasBlocklist     []string `env:"ALIAS_BLOCKLIST"`
+	AliasBlocklistFile string   `env:"ALIAS_BLOCKLIST_FILE"`
+	AliasPlanLimits    []string `env:"ALIAS_PLAN_LIMITS" default:"free:3-10,pro:1-32"`
+
+	MailDriver   string `env:"MAIL_DRIVER" default:"log" oneof:"log,file,smtp"`
+	MailFrom     string `env:"MAIL_FROM" default:"Slink <no-reply@localhost>"`
+	MailFileDir  string `env:"MAIL_FILE_DIR" default:"mail"`
+	SMTPHost     string `env:"SMTP_HOST" default:"localhost"`
+	SMTPPort     int    `env:"SMTP_PORT" default:"1025"`
+	SMTPUsername string `env:"SMTP_USERNAME"`
+	SMTPPassword string `env:"SMTP_PASSWORD" secret:"true"`
+
+	RequireEmailVerification bool          `env:"REQUIRE_EMAIL_VERIFICATION" default:"false"`
+	VerificationTokenTTL     time.Duration `env:"VERIFICATION_TOKEN_TTL_HOURS" default:"24h" unit:"h"`
+	PasswordResetTTL         time.Duration `env:"PASSWORD_RESET_TTL_MINUTES" default:"1h" unit:"m"`
+
+	TOTPIssuer string `env:"TOTP_ISSUER" default:"Slink"`
+
+	AccountDeletionPolicy string `env:"ACCOUNT_DELETION_POLICY" default:"delete" oneof:"delete,anonymize"`
+
+	LogLevel      string `env:"LOG_LEVEL" default:"info" oneof:"debug,info,warn,error"`
+	LogFormat     string `env:"LOG_FORMAT" default:"text" oneof:"text,json"`
+	LogRedactPII  bool   `env:"LOG_REDACT_PII" default:"true"`
+	SupabaseDebug bool   `env:"SUPABASE_DEBUG" default:"false"`
+
+	TracingEnabled     bool   `env:"TRACING_ENABLED" default:"false"`
+	TracingEndpoint    string `env:"TRACING_OTLP_ENDPOINT" default:"localhost:4318"`
+	TracingInsecure    bool   `env:"TRACING_OTLP_INSECURE" default:"true"`
+	TracingServiceName string `env:"TRACING_SERVICE_NAME" default:"slink-backend"`
+
+	MetricsEnabled bool   `env:"METRICS_ENABLED" default:"true"`
+	MetricsToken   string `env:"METRICS_TOKEN" secret:"true"`
+
+	ReadTimeout       time.Duration `env:"READ_TIMEOUT_MS" default:"5s" unit:"ms"`
+	WriteTimeout      time.Duration `env:"WRITE_TIMEOUT_MS" default:"10s" unit:"ms"`
+	RedirectTimeout   time.Duration `env:"REDIRECT_TIMEOUT_MS" default:"2s" unit:"ms"`
+	ExportTimeout     time.Duration `env:"EXPORT_TIMEOUT_MS" default:"5m" unit:"ms"`
+	BackgroundTimeout time.Duration `env:"BACKGROUND_TIMEOUT_MS" default:"30s" unit:"ms"`
+
+	HealthCheckTimeout time.Duration `env:"HEALTH_CHECK_TIMEOUT_MS" default:"2s" unit:"ms"`
+	HealthCacheTTL     time.Duration `env:"HEALTH_CACHE_SECONDS" default:"5s" unit:"s"`
+	HealthWorkerMaxAge time.Duration `env:"HEALTH_WORKER_MAX_AGE_SECONDS" default:"15m" unit:"s"`
+
+	// CORS for /api; with no origins configured only FRONTEND_URL may call it.
+	CORSAPIOrigins     []string `env:"CORS_API_ORIGINS"`
+	CORSAPIMethods     []string `env:"CORS_API_METHODS" default:"GET,POST,PUT,PATCH,DELETE"`
+	CORSAPIHeaders     []string `env:"CORS_API_HEADERS" default:"Content-Type,Authorization,Idempotency-Key,X-Request-ID"`
+	CORSAPICredentials bool     `env:"CORS_API_CREDENTIALS" default:"false"`
+	// CORS for short link redirects.
+	CORSPublicOrigins  []string      `env:"CORS_PUBLIC_ORIGINS" default:"*"`
+	CORSExposedHeaders []string      `env:"CORS_EXPOSED_HEADERS" default:"X-Request-ID,Idempotent-Replayed"`
+	CORSMaxAge         time.Duration `env:"CORS_MAX_AGE_SECONDS" default:"10m" unit:"s"`
 
-func getEnvAsInt(key string, defaultValue int) int {
-	if value := os.Getenv(key); value != "" {
-		if intValue := parseInt(value); intValue != 0 {
-			return intValue
-		}
-	}
-	return defaultValue
+	HSTSMaxAge            time.Duration `env:"HSTS_MAX_AGE_SECONDS" default:"8760h" unit:"s"`
+	HSTSIncludeSubdomains bool          `env:"HSTS_INCLUDE_SUBDOMAINS" default:"false"`
+	CSPFrameAncestors     string        `env:"CSP_FRAME_ANCESTORS" default:"'none'"`
+	ReferrerPolicy        string        `env:"REFERRER_POLICY" default:"strict-origin-when-cross-origin" oneof:"no-referrer,no-referrer-when-downgrade,origin,origin-when-cross-origin,same-origin,strict-origin,strict-origin-when-cross-origin,unsafe-url"`
+	ForceHTTPS            bool          `env:"FORCE_HTTPS" default:"false"`
+	// TrustedProxies may set X-Forwarded-For and X-Forwarded-Proto.
+	TrustedProxies []string `env:"TRUSTED_PROXIES"`
 }
 
-func parseInt(s string) int {
-	var result int
-	for _, char := range s {
-		if char >= '0' && char <= '9' {
-			result = result*10 + int(char-'0')
-		} else {
-			return 0
-		}
-	}
-	return result
+// IsProduction reports whether APP_ENV is production.
+func (c *Config) IsProduction() bool {
+	return c.Environment == EnvProduction
 }
@@ -0,0 +1,250 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// ErrPrintConfig is returned by Load, together with the loaded but
+// unvalidated configuration, when --print-config was given.
+var ErrPrintConfig = errors.New("print config requested")
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+var units = map[string]time.Duration{
+	"ms": time.Millisecond,
+	"s":  time.Second,
+	"m":  time.Minute,
+	"h":  time.Hour,
+}
+
+// setting is one Config field and its tags.
+type setting struct {
+	index      int
+	env        string
+	key        string
+	flag       string
+	def        string
+	unit       time.Duration
+	oneof      []string
+	secret     bool
+	production string
+}
+
+var settings = describe()
+
+func describe() []setting {
+	t := reflect.TypeOf(Config{})
+	list := make([]setting, t.NumField())
+	for i := range list {
+		f := t.Field(i)
+		env := f.Tag.Get("env")
+		list[i] = setting{
+			index:      i,
+			env:        env,
+			key:        strings.ToLower(env),
+			flag:       strings.ReplaceAll(strings.ToLower(env), "_", "-"),
+			def:        f.Tag.Get("default"),
+			unit:       units[f.Tag.Get("unit")],
+			oneof:      splitList(f.Tag.Get("oneof")),
+			secret:     f.Tag.Get("secret") == "true",
+			production: f.Tag.Get("production"),
+		}
+	}
+	return list
+}
+
+// Load builds the configuration from the defaults, the config file, the
+// environment and the flags in args, in that order of precedence. The file
+// is named by --config or CONFIG_FILE and may be YAML or TOML. Every
+// invalid value is reported, not just the first.
+func Load(args []string) (*Config, error) {
+	fs := flag.NewFlagSet("slink-backend", flag.ContinueOnError)
+	configFile := fs.String("config", os.Getenv("CONFIG_FILE"), "YAML or TOML config `file`")
+	printConfig := fs.Bool("print-config", false, "print the effective configuration with secrets redacted and exit")
+
+	var overrides []func(*Config) error
+	for _, s := range settings {
+		s := s
+		override := func(value string) error {
+			overrides = append(overrides, func(cfg *Config) error {
+				return cfg.set(s, value)
+			})
+			return nil
+		}
+		usage := "overrides " + s.env
+		if reflect.TypeOf(Config{}).Field(s.index).Type.Kind() == reflect.Bool {
+			fs.BoolFunc(s.flag, usage, override)
+		} else {
+			fs.Func(s.flag, usage, override)
+		}
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	cfg := &Config{}
+	var errs []error
+	for _, s := range settings {
+		if s.def != "" {
+			if err := cfg.set(s, s.def); err != nil {
+				panic(fmt.Sprintf("config: bad default for %s: %v", s.env, err))
+			}
+		}
+	}
+
+	if *configFile != "" {
+		if err := cfg.loadFile(*configFile); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	for _, s := range settings {
+		if value := os.Getenv(s.env); value != "" {
+			errs = append(errs, cfg.set(s, value))
+		}
+	}
+
+	for _, override := range overrides {
+		errs = append(errs, override(cfg))
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	if *printConfig {
+		return cfg, ErrPrintConfig
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// loadFile applies the settings in a YAML or TOML file, keyed by the
+// lower-case environment variable names.
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %v", err)
+	}
+
+	values := map[string]interface{}{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &values)
+	case ".toml":
+		err = toml.Unmarshal(data, &values)
+	default:
+		return fmt.Errorf("config file %s: unsupported format, use .yaml, .yml or .toml", path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to parse config file %s: %v", path, err)
+	}
+
+	byKey := make(map[string]setting, len(settings))
+	for _, s := range settings {
+		byKey[s.key] = s
+	}
+
+	var errs []error
+	for key, raw := range values {
+		s, ok := byKey[key]
+		if !ok {
+			errs = append(errs, fmt.Errorf("config file %s: unknown setting %q", path, key))
+			continue
+		}
+		if list, ok := raw.([]interface{}); ok {
+			items := make([]string, len(list))
+			for i, item := range list {
+				items[i] = fmt.Sprint(item)
+			}
+			errs = append(errs, c.setList(s, items))
+			continue
+		}
+		errs = append(errs, c.set(s, fmt.Sprint(raw)))
+	}
+	return errors.Join(errs...)
+}
+
+// set parses value into the setting's field.
+func (c *Config) set(s setting, value string) error {
+	field := reflect.ValueOf(c).Elem().Field(s.index)
+
+	switch {
+	case field.Type() == durationType:
+		d, err := parseDuration(value, s.unit)
+		if err != nil {
+			return fmt.Errorf("%s: %v", s.env, err)
+		}
+		field.SetInt(int64(d))
+	case field.Kind() == reflect.String:
+		field.SetString(value)
+	case field.Kind() == reflect.Int:
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("%s: %q is not a whole number", s.env, value)
+		}
+		field.SetInt(int64(n))
+	case field.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("%s: %q is not true or false", s.env, value)
+		}
+		field.SetBool(b)
+	case field.Kind() == reflect.Slice:
+		return c.setList(s, splitList(value))
+	default:
+		panic("config: unsupported type for " + s.env)
+	}
+	return nil
+}
+
+func (c *Config) setList(s setting, items []string) error {
+	field := reflect.ValueOf(c).Elem().Field(s.index)
+	if field.Kind() != reflect.Slice {
+		return fmt.Errorf("%s: expected a single value, not a list", s.env)
+	}
+	field.Set(reflect.ValueOf(items))
+	return nil
+}
+
+// parseDuration accepts Go durations like "1h30m", or a plain number in
+// the setting's unit so existing *_SECONDS style values keep working.
+func parseDuration(value string, unit time.Duration) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if n, err := strconv.Atoi(value); err == nil && unit != 0 {
+		if n < 0 {
+			return 0, fmt.Errorf("%q must not be negative", value)
+		}
+		return time.Duration(n) * unit, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a duration", value)
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("%q must not be negative", value)
+	}
+	return d, nil
+}
+
+func splitList(value string) []string {
+	items := []string{}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
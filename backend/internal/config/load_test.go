@@ -0,0 +1,143 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadDefaults(t *testing.T) {
+	cfg, err := Load(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Port != 8080 || cfg.ShortCodeStrategy != "random" || cfg.QRCacheTTL != time.Hour || !cfg.LogRedactPII {
+		t.Errorf("defaults not applied: port %d, strategy %q, QR cache TTL %s", cfg.Port, cfg.ShortCodeStrategy, cfg.QRCacheTTL)
+	}
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	file := writeFile(t, "slink.yaml", "port: 1111\nqr_size: 300\nbase_url: https://file.example\n")
+
+	tests := []struct {
+		name string
+		env  map[string]string
+		args []string
+		port int
+	}{
+		{"file over default", nil, nil, 1111},
+		{"env over file", map[string]string{"PORT": "2222"}, nil, 2222},
+		{"flag over env", map[string]string{"PORT": "2222"}, []string{"--port", "3333"}, 3333},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CONFIG_FILE", file)
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			cfg, err := Load(tt.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.Port != tt.port {
+				t.Errorf("Port = %d, want %d", cfg.Port, tt.port)
+			}
+			// Settings only the file sets survive the other layers.
+			if cfg.QRSize != 300 || cfg.BaseURL != "https://file.example" {
+				t.Errorf("file settings lost: QR size %d, base URL %q", cfg.QRSize, cfg.BaseURL)
+			}
+		})
+	}
+}
+
+func TestLoadConfigFlagOverridesConfigFileEnv(t *testing.T) {
+	t.Setenv("CONFIG_FILE", writeFile(t, "env.yaml", "port: 1111\n"))
+	flagFile := writeFile(t, "flag.toml", "port = 4444\ncors_api_origins = [\"https://a.example\", \"https://b.example\"]\n")
+
+	cfg, err := Load([]string{"--config", flagFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Port != 4444 {
+		t.Errorf("Port = %d, want the --config file's 4444", cfg.Port)
+	}
+	if want := []string{"https://a.example", "https://b.example"}; !slices.Equal(cfg.CORSAPIOrigins, want) {
+		t.Errorf("CORSAPIOrigins = %q, want %q", cfg.CORSAPIOrigins, want)
+	}
+}
+
+func TestLoadValueFormats(t *testing.T) {
+	t.Setenv("QR_CACHE_TTL_SECONDS", "90")
+	t.Setenv("CORS_API_ORIGINS", " https://a.example, ,https://b.example ")
+
+	cfg, err := Load([]string{"--preview-fetch-enabled=false", "--qr-cache-max-age", "1m30s"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.QRCacheTTL != 90*time.Second {
+		t.Errorf("QRCacheTTL = %s, want plain numbers read in the setting's unit", cfg.QRCacheTTL)
+	}
+	if cfg.QRCacheMaxAge != 90*time.Second {
+		t.Errorf("QRCacheMaxAge = %s, want Go durations accepted", cfg.QRCacheMaxAge)
+	}
+	if cfg.PreviewFetchEnabled {
+		t.Error("boolean flag not applied")
+	}
+	if want := []string{"https://a.example", "https://b.example"}; !slices.Equal(cfg.CORSAPIOrigins, want) {
+		t.Errorf("CORSAPIOrigins = %q, want %q", cfg.CORSAPIOrigins, want)
+	}
+}
+
+func TestLoadReportsEveryError(t *testing.T) {
+	t.Setenv("CONFIG_FILE", writeFile(t, "bad.yaml", "no_such_setting: 1\n"))
+	t.Setenv("PORT", "eighty")
+	t.Setenv("QR_CACHE_TTL_SECONDS", "-5")
+
+	_, err := Load([]string{"--link-check-enabled=maybe"})
+	if err == nil {
+		t.Fatal("Load accepted invalid settings")
+	}
+	for _, want := range []string{"no_such_setting", "PORT", "QR_CACHE_TTL_SECONDS", "LINK_CHECK_ENABLED"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error doesn't mention %s: %v", want, err)
+		}
+	}
+}
+
+func TestLoadRejectsPlaceholdersInProduction(t *testing.T) {
+	t.Setenv("APP_ENV", "production")
+	_, err := Load(nil)
+	if err == nil || !strings.Contains(err.Error(), "JWT_SECRET: must be set in production") || !strings.Contains(err.Error(), "SUPABASE_URL") {
+		t.Errorf("err = %v, want placeholders rejected", err)
+	}
+}
+
+func TestPrintConfigRedactsSecrets(t *testing.T) {
+	t.Setenv("JWT_SECRET", "super-secret-signing-key")
+	cfg, err := Load([]string{"--print-config"})
+	if !errors.Is(err, ErrPrintConfig) {
+		t.Fatalf("err = %v, want ErrPrintConfig", err)
+	}
+
+	var buf bytes.Buffer
+	if err := cfg.Print(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "super-secret") || !strings.Contains(buf.String(), "jwt_secret: '[REDACTED]'") {
+		t.Errorf("printed config:\n%s", buf.String())
+	}
+}
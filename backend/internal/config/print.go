@@ -0,0 +1,55 @@
+package config
+
+import (
+	"io"
+	"reflect"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Print writes the configuration as YAML, in the format config files use,
+// with secrets redacted.
+func (c *Config) Print(w io.Writer) error {
+	v := reflect.ValueOf(c).Elem()
+	doc := &yaml.Node{Kind: yaml.MappingNode}
+
+	for _, s := range settings {
+		var value interface{}
+		field := v.Field(s.index)
+		switch {
+		case field.Type() == durationType:
+			value = time.Duration(field.Int()).String()
+		case s.secret && field.Kind() == reflect.Slice:
+			items := make([]string, field.Len())
+			for i := range items {
+				items[i] = redact(s, field.Index(i).String())
+			}
+			value = items
+		case s.secret:
+			value = redact(s, field.String())
+		default:
+			value = field.Interface()
+		}
+
+		node := &yaml.Node{}
+		if err := node.Encode(value); err != nil {
+			return err
+		}
+		doc.Content = append(doc.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: s.key}, node)
+	}
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+func redact(s setting, value string) string {
+	if s.secret && value != "" {
+		return "[REDACTED]"
+	}
+	return value
+}
@@ -0,0 +1,74 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+const minProductionSecretLength = 32
+
+// Validate reports every setting that is out of range, not one of its
+// accepted values or, in production, still a placeholder.
+func (c *Config) Validate() error {
+	var errs []error
+	v := reflect.ValueOf(c).Elem()
+
+	for _, s := range settings {
+		field := v.Field(s.index)
+		if len(s.oneof) > 0 && !slices.Contains(s.oneof, field.String()) {
+			errs = append(errs, fmt.Errorf("%s: %q is not one of %s", s.env, field.String(), strings.Join(s.oneof, ", ")))
+		}
+		if c.IsProduction() && s.production == "required" && isPlaceholder(field.String(), s.def) {
+			if s.secret {
+				errs = append(errs, fmt.Errorf("%s: must be set in production", s.env))
+			} else {
+				errs = append(errs, fmt.Errorf("%s: must be set in production, found placeholder %q", s.env, field.String()))
+			}
+		}
+	}
+
+	if c.Port < 1 || c.Port > 65535 {
+		errs = append(errs, fmt.Errorf("PORT: %d is not a valid port", c.Port))
+	}
+	if c.IsProduction() && !isPlaceholder(c.JWTSecret, "") && len(c.JWTSecret) < minProductionSecretLength {
+		errs = append(errs, fmt.Errorf("JWT_SECRET: must be at least %d characters in production", minProductionSecretLength))
+	}
+	if c.ShortCodeLength < 1 || c.ShortCodeMaxLength < c.ShortCodeLength {
+		errs = append(errs, errors.New("SHORT_CODE_LENGTH: must be at least 1 and at most SHORT_CODE_MAX_LENGTH"))
+	}
+	if c.QRMinSize < 1 || c.QRSize < c.QRMinSize || c.QRMaxSize < c.QRSize {
+		errs = append(errs, errors.New("QR_SIZE: must lie between QR_MIN_SIZE and QR_MAX_SIZE, and QR_MIN_SIZE must be positive"))
+	}
+	for _, n := range []struct {
+		env   string
+		value int
+	}{
+		{"QR_CACHE_SIZE", c.QRCacheSize},
+		{"QR_BATCH_MAX", c.QRBatchMax},
+		{"LINK_CHECK_CONCURRENCY", c.LinkCheckConcurrency},
+		{"LINK_CHECK_BATCH_SIZE", c.LinkCheckBatchSize},
+		{"LINK_CHECK_FAILURE_THRESHOLD", c.LinkCheckFailureThreshold},
+		{"WEBHOOK_MAX_ATTEMPTS", c.WebhookMaxAttempts},
+		{"WEBHOOK_BATCH_SIZE", c.WebhookBatchSize},
+		{"WEBHOOK_CONCURRENCY", c.WebhookConcurrency},
+	} {
+		if n.value < 1 {
+			errs = append(errs, fmt.Errorf("%s: must be at least 1", n.env))
+		}
+	}
+	if c.NotifyDriver == "webhook" && c.NotifyWebhookURL == "" {
+		errs = append(errs, errors.New("NOTIFY_WEBHOOK_URL: required when NOTIFY_DRIVER is webhook"))
+	}
+
+	return errors.Join(errs...)
+}
+
+// isPlaceholder reports whether value is the example value from the
+// defaults or from .env.example.
+func isPlaceholder(value, def string) bool {
+	lower := strings.ToLower(value)
+	return value == "" || value == def || strings.Contains(lower, "your-") || strings.Contains(lower, "your_")
+}
@@ -0,0 +1,183 @@
+// Package cors implements the CORS policies applied to route groups.
+package cors
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Policy says which cross-origin requests a route group accepts.
+//
+// Origins are exact ("https://app.example.com"), wildcard subdomains
+// ("https://*.example.com", which doesn't match example.com itself) or "*"
+// for any origin. Headers may also be "*", which allows whatever the
+// browser asks for.
+type Policy struct {
+	Origins        []string
+	Methods        []string
+	Headers        []string
+	ExposedHeaders []string
+	Credentials    bool
+	MaxAge         time.Duration
+}
+
+type wildcard struct {
+	prefix string // scheme and "://"
+	suffix string // "." and the parent domain, with any port
+}
+
+type middleware struct {
+	anyOrigin   bool
+	origins     map[string]bool
+	wildcards   []wildcard
+	methods     map[string]bool
+	anyHeader   bool
+	credentials bool
+
+	allowMethods  string
+	allowHeaders  string
+	exposeHeaders string
+	maxAge        string
+}
+
+// New returns middleware enforcing p. It answers preflight requests itself,
+// so the group needs an OPTIONS route for them to reach it; see Preflight.
+func New(p Policy) (gin.HandlerFunc, error) {
+	m := &middleware{
+		origins:       map[string]bool{},
+		methods:       map[string]bool{},
+		credentials:   p.Credentials,
+		allowMethods:  strings.Join(p.Methods, ", "),
+		allowHeaders:  strings.Join(p.Headers, ", "),
+		exposeHeaders: strings.Join(p.ExposedHeaders, ", "),
+	}
+	if p.MaxAge > 0 {
+		m.maxAge = strconv.Itoa(int(p.MaxAge.Seconds()))
+	}
+
+	for _, origin := range p.Origins {
+		if err := m.addOrigin(origin); err != nil {
+			return nil, err
+		}
+	}
+	if m.anyOrigin && p.Credentials {
+		return nil, fmt.Errorf("cors: credentials can't be allowed for any origin")
+	}
+	for _, method := range p.Methods {
+		m.methods[strings.ToUpper(method)] = true
+	}
+	for _, header := range p.Headers {
+		if header == "*" {
+			m.anyHeader = true
+		}
+	}
+	return m.handle, nil
+}
+
+func (m *middleware) addOrigin(origin string) error {
+	origin = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(origin), "/"))
+	if origin == "*" {
+		m.anyOrigin = true
+		return nil
+	}
+
+	u, err := url.Parse(origin)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.Path != "" || u.RawQuery != "" {
+		return fmt.Errorf("cors: invalid origin %q, want scheme://host[:port]", origin)
+	}
+	if host, ok := strings.CutPrefix(u.Host, "*."); ok {
+		if host == "" || strings.Contains(host, "*") {
+			return fmt.Errorf("cors: invalid wildcard origin %q", origin)
+		}
+		m.wildcards = append(m.wildcards, wildcard{prefix: u.Scheme + "://", suffix: "." + host})
+		return nil
+	}
+	if strings.Contains(u.Host, "*") {
+		return fmt.Errorf("cors: wildcards are only allowed as the first label in %q", origin)
+	}
+	m.origins[origin] = true
+	return nil
+}
+
+func (m *middleware) allowed(origin string) bool {
+	if m.anyOrigin {
+		return true
+	}
+	origin = strings.ToLower(origin)
+	if m.origins[origin] {
+		return true
+	}
+	for _, w := range m.wildcards {
+		sub, ok := strings.CutPrefix(origin, w.prefix)
+		if !ok {
+			continue
+		}
+		sub, ok = strings.CutSuffix(sub, w.suffix)
+		if ok && sub != "" && !strings.ContainsAny(sub, "/:@") {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *middleware) handle(c *gin.Context) {
+	origin := c.GetHeader("Origin")
+	preflight := c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != ""
+
+	// Responses differ by origin unless every origin gets the same answer.
+	if !m.anyOrigin || m.credentials {
+		c.Writer.Header().Add("Vary", "Origin")
+	}
+	if origin == "" || !m.allowed(origin) {
+		if preflight {
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+		c.Next()
+		return
+	}
+
+	if m.anyOrigin {
+		c.Header("Access-Control-Allow-Origin", "*")
+	} else {
+		c.Header("Access-Control-Allow-Origin", origin)
+	}
+	if m.credentials {
+		c.Header("Access-Control-Allow-Credentials", "true")
+	}
+
+	if !preflight {
+		if m.exposeHeaders != "" {
+			c.Header("Access-Control-Expose-Headers", m.exposeHeaders)
+		}
+		c.Next()
+		return
+	}
+
+	if m.methods[strings.ToUpper(c.GetHeader("Access-Control-Request-Method"))] {
+		c.Header("Access-Control-Allow-Methods", m.allowMethods)
+		if m.anyHeader {
+			c.Writer.Header().Add("Vary", "Access-Control-Request-Headers")
+			c.Header("Access-Control-Allow-Headers", c.GetHeader("Access-Control-Request-Headers"))
+		} else if m.allowHeaders != "" {
+			c.Header("Access-Control-Allow-Headers", m.allowHeaders)
+		}
+		if m.maxAge != "" {
+			c.Header("Access-Control-Max-Age", m.maxAge)
+		}
+	}
+	c.AbortWithStatus(http.StatusNoContent)
+}
+
+// Preflight is the handler for the OPTIONS routes that let preflight
+// requests reach a group's CORS middleware. The middleware answers them, so
+// it only runs for plain OPTIONS requests.
+func Preflight(c *gin.Context) {
+	c.Status(http.StatusNoContent)
+}
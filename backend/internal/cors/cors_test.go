@@ -0,0 +1,152 @@
+package cors
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAllowedOrigins(t *testing.T) {
+	m := &middleware{origins: map[string]bool{}, methods: map[string]bool{}}
+	for _, origin := range []string{"https://app.example.com/", "https://*.example.org", "http://localhost:3000"} {
+		if err := m.addOrigin(origin); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		origin string
+		want   bool
+	}{
+		{"https://app.example.com", true},
+		{"HTTPS://APP.EXAMPLE.COM", true},
+		{"http://app.example.com", false},
+		{"https://app.example.com:8443", false},
+		{"https://evil.com", false},
+		{"https://app.example.com.evil.com", false},
+		{"https://a.example.org", true},
+		{"https://a.b.example.org", true},
+		{"https://example.org", false},
+		{"http://a.example.org", false},
+		{"https://a.example.org:444", false},
+		{"https://evil.com/.example.org", false},
+		{"https://user@a.example.org", false},
+		{"https://evilexample.org", false},
+		{"http://localhost:3000", true},
+		{"http://localhost:3001", false},
+		{"null", false},
+	}
+	for _, tt := range tests {
+		if got := m.allowed(tt.origin); got != tt.want {
+			t.Errorf("allowed(%q) = %v, want %v", tt.origin, got, tt.want)
+		}
+	}
+}
+
+func TestNewRejectsInvalidPolicies(t *testing.T) {
+	for _, p := range []Policy{
+		{Origins: []string{"*"}, Credentials: true},
+		{Origins: []string{"app.example.com"}},
+		{Origins: []string{"ftp://app.example.com"}},
+		{Origins: []string{"https://app.example.com/path"}},
+		{Origins: []string{"https://*."}},
+		{Origins: []string{"https://app.*.example.com"}},
+		{Origins: []string{"https://*.*.example.com"}},
+	} {
+		if _, err := New(p); err == nil {
+			t.Errorf("New(%+v) succeeded, want an error", p)
+		}
+	}
+}
+
+func serve(t *testing.T, p Policy, req *http.Request) *httptest.ResponseRecorder {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	handler, err := New(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	router := gin.New()
+	group := router.Group("/api", handler)
+	group.OPTIONS("/*path", Preflight)
+	group.GET("/links", func(c *gin.Context) { c.String(http.StatusOK, "ok") })
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+var apiPolicy = Policy{
+	Origins:        []string{"https://app.example.com"},
+	Methods:        []string{"GET", "POST"},
+	Headers:        []string{"Content-Type", "Authorization"},
+	ExposedHeaders: []string{"X-Request-ID"},
+	Credentials:    true,
+	MaxAge:         10 * time.Minute,
+}
+
+func preflight(origin, method string) *http.Request {
+	req := httptest.NewRequest(http.MethodOptions, "/api/links", nil)
+	req.Header.Set("Origin", origin)
+	req.Header.Set("Access-Control-Request-Method", method)
+	req.Header.Set("Access-Control-Request-Headers", "content-type")
+	return req
+}
+
+func TestPreflight(t *testing.T) {
+	rec := serve(t, apiPolicy, preflight("https://app.example.com", "POST"))
+	h := rec.Header()
+	if rec.Code != http.StatusNoContent ||
+		h.Get("Access-Control-Allow-Origin") != "https://app.example.com" ||
+		h.Get("Access-Control-Allow-Credentials") != "true" ||
+		h.Get("Access-Control-Allow-Methods") != "GET, POST" ||
+		h.Get("Access-Control-Allow-Headers") != "Content-Type, Authorization" ||
+		h.Get("Access-Control-Max-Age") != "600" ||
+		h.Get("Vary") != "Origin" {
+		t.Errorf("preflight answered %d with %v", rec.Code, h)
+	}
+
+	rec = serve(t, apiPolicy, preflight("https://app.example.com", "DELETE"))
+	if rec.Header().Get("Access-Control-Allow-Methods") != "" {
+		t.Errorf("disallowed method got Allow-Methods %q", rec.Header().Get("Access-Control-Allow-Methods"))
+	}
+
+	rec = serve(t, apiPolicy, preflight("https://evil.com", "GET"))
+	if rec.Code != http.StatusNoContent || rec.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Errorf("foreign origin answered %d with %v", rec.Code, rec.Header())
+	}
+}
+
+func TestSimpleRequest(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/api/links", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	rec := serve(t, apiPolicy, req)
+	if rec.Code != http.StatusOK || rec.Header().Get("Access-Control-Allow-Origin") != "https://app.example.com" ||
+		rec.Header().Get("Access-Control-Expose-Headers") != "X-Request-ID" {
+		t.Errorf("simple request answered %d with %v", rec.Code, rec.Header())
+	}
+
+	// Foreign origins still reach the handler; the browser withholds the
+	// response.
+	req.Header.Set("Origin", "https://evil.com")
+	rec = serve(t, apiPolicy, req)
+	if rec.Code != http.StatusOK || rec.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Errorf("foreign origin answered %d with %v", rec.Code, rec.Header())
+	}
+}
+
+func TestAnyOriginAndHeader(t *testing.T) {
+	p := Policy{Origins: []string{"*"}, Methods: []string{"GET"}, Headers: []string{"*"}}
+
+	rec := serve(t, p, preflight("https://anywhere.example", "GET"))
+	h := rec.Header()
+	if h.Get("Access-Control-Allow-Origin") != "*" || h.Get("Access-Control-Allow-Headers") != "content-type" {
+		t.Errorf("preflight answered with %v", h)
+	}
+	if vary := h.Values("Vary"); len(vary) != 1 || vary[0] != "Access-Control-Request-Headers" {
+		t.Errorf("Vary = %q, want only Access-Control-Request-Headers", vary)
+	}
+}
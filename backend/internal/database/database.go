@@ -2,30 +2,46 @@ package database
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"time"
 
 	"slink-backend/internal/models"
 
 	supa "github.com/lengzuo/supa"
+	"github.com/lengzuo/supa/postgres"
+	"github.com/lengzuo/supa/utils/enum"
 )
 
+// ErrNotFound is returned by lookups that match no row.
+var ErrNotFound = errors.New("record not found")
+
+// IsUniqueViolation reports whether err is PostgREST passing on a unique
+// constraint violation, e.g. from a concurrent insert of the same key.
+func IsUniqueViolation(err error) bool {
+	var pgErr *postgres.Error
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
 type SupabaseClient struct {
 	client *supa.Client
 }
 
-func ConnectSupabase(url, key, projectRef string) (*SupabaseClient, error) {
+// ConnectSupabase creates the client. debug makes the supa client log every
+// request and response body, user data included.
+func ConnectSupabase(url, key, projectRef string, debug bool) (*SupabaseClient, error) {
 	config := supa.Config{
 		ApiKey:     key,
 		ProjectRef: projectRef,
-		Debug:      true,
+		Debug:      debug,
 	}
 	client, err := supa.New(config)
 	if err != nil {
 		return nil, err
 	}
 
-	log.Println("Supabase connected successfully")
+	slog.Info("Supabase connected")
 	return &SupabaseClient{client: client}, nil
 }
 
@@ -33,12 +49,24 @@ func (s *SupabaseClient) GetClient() *supa.Client {
 	return s.client
 }
 
+// Ping runs the cheapest query there is, to tell whether Supabase is
+// reachable.
+func (s *SupabaseClient) Ping(ctx context.Context) error {
+	var rows []struct {
+		ID string `json:"id"`
+	}
+	if err := Execute(ctx, "select", "users", s.client.DB.From("users").Select("id").Limit(1), &rows); err != nil {
+		return fmt.Errorf("failed to reach database: %v", err)
+	}
+	return nil
+}
+
 // User operations
-func (s *SupabaseClient) CreateUser(user *models.User) error {
+func (s *SupabaseClient) CreateUser(ctx context.Context, user *models.User) error {
 	var result models.User
-	err := s.client.DB.From("users").Insert(user).Execute(context.Background(), &result)
+	err := Execute(ctx, "insert", "users", s.client.DB.From("users").Insert(user), &result)
 	if err != nil {
-		return fmt.Errorf("failed to create user: %v", err)
+		return fmt.Errorf("failed to create user: %w", err)
 	}
 	user.ID = result.ID
 	user.CreatedAt = result.CreatedAt
@@ -46,20 +74,96 @@ func (s *SupabaseClient) CreateUser(user *models.User) error {
 	return nil
 }
 
-func (s *SupabaseClient) GetUserByID(userID string) (*models.User, error) {
-	var user models.User
-	err := s.client.DB.From("users").Select("*").Eq("id", userID).Single().Execute(context.Background(), &user)
+func (s *SupabaseClient) GetUserByID(ctx context.Context, userID string) (*models.User, error) {
+	return s.getUser(ctx, "id", userID)
+}
+
+func (s *SupabaseClient) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	return s.getUser(ctx, "email", email)
+}
+
+func (s *SupabaseClient) getUser(ctx context.Context, column, value string) (*models.User, error) {
+	var users []models.User
+	err := Execute(ctx, "select", "users", s.client.DB.From("users").Select("*").Eq(column, value), &users)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user: %v", err)
 	}
-	return &user, nil
+	if len(users) == 0 {
+		return nil, ErrNotFound
+	}
+	return &users[0], nil
 }
 
-func (s *SupabaseClient) GetUserByEmail(email string) (*models.User, error) {
-	var user models.User
-	err := s.client.DB.From("users").Select("*").Eq("email", email).Single().Execute(context.Background(), &user)
+func (s *SupabaseClient) UpdateUser(ctx context.Context, userID string, updates map[string]interface{}) error {
+	updates["updated_at"] = time.Now()
+	err := Execute(ctx, "update", "users", s.client.DB.From("users").Update(updates).Eq("id", userID), nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get user: %v", err)
+		return fmt.Errorf("failed to update user: %v", err)
+	}
+	return nil
+}
+
+func (s *SupabaseClient) DeleteUser(ctx context.Context, userID string) error {
+	err := Execute(ctx, "delete", "users", s.client.DB.From("users").Delete().Eq("id", userID), nil)
+	if err != nil {
+		return fmt.Errorf("failed to delete user: %v", err)
+	}
+	return nil
+}
+
+// URL operations
+func (s *SupabaseClient) DeleteURLsByUser(ctx context.Context, userID string) error {
+	err := Execute(ctx, "delete", "urls", s.client.DB.From("urls").Delete().Eq("user_id", userID), nil)
+	if err != nil {
+		return fmt.Errorf("failed to delete URLs: %v", err)
+	}
+	return nil
+}
+
+// DisownURLsByUser detaches a user's links so they keep redirecting after the
+// account is gone.
+func (s *SupabaseClient) DisownURLsByUser(ctx context.Context, userID string) error {
+	updates := map[string]interface{}{
+		"user_id":    nil,
+		"updated_at": time.Now(),
+	}
+	err := Execute(ctx, "update", "urls", s.client.DB.From("urls").Update(updates).Eq("user_id", userID), nil)
+	if err != nil {
+		return fmt.Errorf("failed to disown URLs: %v", err)
+	}
+	return nil
+}
+
+// ListUsers returns users newest first. query matches the email address
+// case-insensitively.
+func (s *SupabaseClient) ListUsers(ctx context.Context, query string, limit, offset int) ([]models.User, error) {
+	var users []models.User
+	req := s.client.DB.From("users").Select("*").Order("created_at", enum.OrderDesc).Limit(limit).Offset(offset)
+	if query != "" {
+		req.Ilike("email", "*"+query+"*")
+	}
+	if err := Execute(ctx, "select", "users", req, &users); err != nil {
+		return nil, fmt.Errorf("failed to list users: %v", err)
+	}
+	return users, nil
+}
+
+// Stats
+func (s *SupabaseClient) GetSystemStats(ctx context.Context) (*models.SystemStats, error) {
+	var stats models.SystemStats
+	err := Execute(ctx, "rpc", "admin_stats", s.client.DB.RPC("admin_stats", map[string]interface{}{}), &stats)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stats: %v", err)
+	}
+	return &stats, nil
+}
+
+// NextShortCodeID returns the next value of the short code sequence.
+func (s *SupabaseClient) NextShortCodeID(ctx context.Context) (int64, error) {
+	var id int64
+	err := Execute(ctx, "rpc", "next_short_code_id", s.client.DB.RPC("next_short_code_id", map[string]interface{}{}), &id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get next short code ID: %v", err)
 	}
-	return &user, nil
+	return id, nil
 }
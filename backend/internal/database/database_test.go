@@ -0,0 +1,29 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/lengzuo/supa/postgres"
+)
+
+func TestIsUniqueViolation(t *testing.T) {
+	violation := &postgres.Error{Code: "23505", Message: `duplicate key value violates unique constraint "urls_short_code_key"`, HTTPStatusCode: 409}
+
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{violation, true},
+		{fmt.Errorf("insert failed: %w", violation), true},
+		{&postgres.Error{Code: "23503", Message: "foreign key violation"}, false},
+		{errors.New("23505"), false},
+		{nil, false},
+	}
+	for _, tt := range tests {
+		if got := IsUniqueViolation(tt.err); got != tt.want {
+			t.Errorf("IsUniqueViolation(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"slink-backend/internal/metrics"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("slink-backend/database")
+
+// Query is a PostgREST request built with the supa client, ready to run.
+type Query interface {
+	Execute(ctx context.Context, result interface{}) error
+}
+
+// Execute runs q inside a span named after the operation and the table (or
+// function, for RPC calls) it targets, and records its latency. The supa
+// client doesn't let us hook its HTTP transport, so database calls are
+// instrumented here instead.
+func Execute(ctx context.Context, operation, table string, q Query, result interface{}) error {
+	ctx, span := tracer.Start(ctx, operation+" "+table,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemPostgreSQL,
+			semconv.DBOperation(operation),
+			semconv.DBSQLTable(table),
+		),
+	)
+	defer span.End()
+
+	start := time.Now()
+	err := q.Execute(ctx, result)
+	status := "ok"
+	if err != nil {
+		status = "error"
+		// Errors can quote user data; the exporter scrubs it when
+		// LOG_REDACT_PII is on.
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	metrics.DBDuration.WithLabelValues(operation, table, status).Observe(time.Since(start).Seconds())
+	return err
+}
@@ -0,0 +1,148 @@
+// Package health runs the readiness checks behind /health/ready.
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Check reports whether a component works. It should give up once ctx is
+// done; checks that don't are abandoned when their timeout passes.
+type Check func(ctx context.Context) error
+
+type Status string
+
+const (
+	StatusOK   Status = "ok"
+	StatusFail Status = "fail"
+)
+
+// Result is the outcome of one component's check. Errors are logged rather
+// than reported, since they may describe internal infrastructure.
+type Result struct {
+	Status    Status `json:"status"`
+	LatencyMS int64  `json:"latency_ms"`
+}
+
+type Report struct {
+	Status     Status            `json:"status"`
+	CheckedAt  time.Time         `json:"checked_at"`
+	Components map[string]Result `json:"components"`
+}
+
+// OK reports whether every component passed.
+func (r *Report) OK() bool {
+	return r.Status == StatusOK
+}
+
+// Checker runs the registered checks concurrently, each bounded by timeout,
+// and reuses the report for ttl so frequent probes can't overload the
+// database. Probes arriving while the checks run wait for their result.
+type Checker struct {
+	timeout time.Duration
+	ttl     time.Duration
+
+	mu     sync.Mutex
+	names  []string
+	checks []Check
+	last   *Report
+}
+
+func NewChecker(timeout, ttl time.Duration) *Checker {
+	return &Checker{timeout: timeout, ttl: ttl}
+}
+
+// Add registers a component. Components must be added before the first
+// report is requested.
+func (c *Checker) Add(name string, check Check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.names = append(c.names, name)
+	c.checks = append(c.checks, check)
+}
+
+// Report returns the latest report, running the checks if it's older than
+// the cache TTL.
+func (c *Checker) Report() *Report {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.last != nil && time.Since(c.last.CheckedAt) < c.ttl {
+		return c.last
+	}
+	c.last = c.run()
+	return c.last
+}
+
+func (c *Checker) run() *Report {
+	// Checks don't use the probe's context: the report is shared, so one
+	// impatient client mustn't mark components as failing for everyone.
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	results := make([]Result, len(c.checks))
+	var wg sync.WaitGroup
+	for i, check := range c.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			start := time.Now()
+			err := runCheck(ctx, check)
+			results[i] = Result{Status: StatusOK, LatencyMS: time.Since(start).Milliseconds()}
+			if err != nil {
+				results[i].Status = StatusFail
+				slog.Warn("Health check failed", "component", c.names[i], "error", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	report := &Report{Status: StatusOK, CheckedAt: time.Now(), Components: make(map[string]Result, len(results))}
+	for i, result := range results {
+		report.Components[c.names[i]] = result
+		if result.Status != StatusOK {
+			report.Status = StatusFail
+		}
+	}
+	return report
+}
+
+func runCheck(ctx context.Context, check Check) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- check(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return errors.New("check timed out")
+	}
+}
+
+// Recent returns a check for a background worker that records when it last
+// completed a cycle successfully. It fails when the worker hasn't done so
+// within maxAge. A worker that hasn't finished a cycle yet gets maxAge from
+// the call to Recent, so a slow first cycle doesn't fail readiness at
+// startup.
+func Recent(lastRun func() time.Time, maxAge time.Duration) Check {
+	started := time.Now()
+	return func(context.Context) error {
+		last := lastRun()
+		if last.IsZero() {
+			if time.Since(started) <= maxAge {
+				return nil
+			}
+			return fmt.Errorf("worker has not completed a cycle in the %s since startup", maxAge)
+		}
+		if age := time.Since(last); age > maxAge {
+			return fmt.Errorf("worker last completed a cycle %s ago", age.Round(time.Second))
+		}
+		return nil
+	}
+}
@@ -0,0 +1,53 @@
+package health
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRecent(t *testing.T) {
+	var last time.Time
+	check := Recent(func() time.Time { return last }, time.Hour)
+
+	if err := check(context.Background()); err != nil {
+		t.Errorf("worker that hasn't run yet failed during the grace period: %v", err)
+	}
+
+	last = time.Now().Add(-time.Minute)
+	if err := check(context.Background()); err != nil {
+		t.Errorf("recent run failed the check: %v", err)
+	}
+
+	last = time.Now().Add(-2 * time.Hour)
+	if err := check(context.Background()); err == nil {
+		t.Error("stale run passed the check")
+	}
+}
+
+func TestRecentGracePeriodEnds(t *testing.T) {
+	check := Recent(func() time.Time { return time.Time{} }, 10*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	if err := check(context.Background()); err == nil {
+		t.Error("worker that never ran passed after the grace period")
+	}
+}
+
+func TestCheckerReport(t *testing.T) {
+	calls := 0
+	c := NewChecker(50*time.Millisecond, time.Hour)
+	c.Add("ok", func(context.Context) error { calls++; return nil })
+	c.Add("slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		time.Sleep(time.Second)
+		return nil
+	})
+
+	report := c.Report()
+	if report.OK() || report.Components["ok"].Status != StatusOK || report.Components["slow"].Status != StatusFail {
+		t.Errorf("report = %+v, want ok passing and slow failing", report)
+	}
+	if c.Report() != report || calls != 1 {
+		t.Error("report was not reused within its TTL")
+	}
+}
@@ -0,0 +1,93 @@
+// Package logging configures the application's structured logger.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"slink-backend/internal/config"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+type contextKey struct{}
+
+// New builds the logger described by LOG_LEVEL, LOG_FORMAT and
+// LOG_REDACT_PII. Records logged with a context carry its request ID and
+// trace IDs.
+func New(cfg *config.Config) (*slog.Logger, error) {
+	return newLogger(os.Stderr, cfg)
+}
+
+func newLogger(w io.Writer, cfg *config.Config) (*slog.Logger, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(cfg.LogLevel)); err != nil {
+		return nil, fmt.Errorf("invalid LOG_LEVEL %q", cfg.LogLevel)
+	}
+
+	opts := &slog.HandlerOptions{Level: level}
+	if cfg.LogRedactPII {
+		opts.ReplaceAttr = redactAttr
+	}
+
+	var handler slog.Handler
+	switch strings.ToLower(cfg.LogFormat) {
+	case "json":
+		handler = slog.NewJSONHandler(w, opts)
+	case "text":
+		handler = slog.NewTextHandler(w, opts)
+	default:
+		return nil, fmt.Errorf("unknown LOG_FORMAT %q", cfg.LogFormat)
+	}
+
+	return slog.New(&contextHandler{Handler: handler, redact: cfg.LogRedactPII}), nil
+}
+
+// WithRequestID returns a copy of ctx carrying the request ID.
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, contextKey{}, requestID)
+}
+
+// RequestID returns the request ID carried by ctx, or "".
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
+
+// contextHandler adds the request and trace IDs from the record's context,
+// and scrubs PII from messages when redaction is on.
+type contextHandler struct {
+	slog.Handler
+	redact bool
+}
+
+func (h *contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if h.redact {
+		scrubbed := slog.NewRecord(r.Time, r.Level, Scrub(r.Message), r.PC)
+		r.Attrs(func(a slog.Attr) bool {
+			scrubbed.AddAttrs(a)
+			return true
+		})
+		r = scrubbed
+	}
+
+	if id := RequestID(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	if span := trace.SpanContextFromContext(ctx); span.IsValid() {
+		r.AddAttrs(slog.String("trace_id", span.TraceID().String()), slog.String("span_id", span.SpanID().String()))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs), redact: h.redact}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name), redact: h.redact}
+}
@@ -0,0 +1,97 @@
+package logging
+
+import (
+	"fmt"
+	"log/slog"
+	"net"
+	"regexp"
+	"strings"
+)
+
+const redacted = "[REDACTED]"
+
+// Attributes under these keys are never logged.
+var secretKeys = map[string]bool{
+	"password":      true,
+	"new_password":  true,
+	"token":         true,
+	"secret":        true,
+	"authorization": true,
+	"cookie":        true,
+	"api_key":       true,
+	"recovery_code": true,
+	"totp_secret":   true,
+}
+
+var (
+	emailRegex = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+	ipv4Regex  = regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}\b`)
+)
+
+// redactAttr drops secrets, masks IP addresses and hides the local part of
+// email addresses, wherever they appear in string values. Errors, Stringers
+// and other non-string values are checked in their printed form.
+func redactAttr(_ []string, a slog.Attr) slog.Attr {
+	key := strings.ToLower(a.Key)
+	if secretKeys[key] {
+		return slog.String(a.Key, redacted)
+	}
+
+	a.Value = a.Value.Resolve()
+	var value string
+	switch a.Value.Kind() {
+	case slog.KindString:
+		value = a.Value.String()
+	case slog.KindAny:
+		value = fmt.Sprint(a.Value.Any())
+	default:
+		return a
+	}
+
+	switch key {
+	case "ip", "client_ip", "remote_addr":
+		return slog.String(a.Key, maskIP(value))
+	case slog.MessageKey:
+		// Handled by contextHandler, which sees the message before the
+		// built-in handlers format it.
+		return a
+	}
+	if scrubbed := Scrub(value); scrubbed != value || a.Value.Kind() == slog.KindString {
+		return slog.String(a.Key, scrubbed)
+	}
+	// Leave clean values alone so handlers can format them as usual.
+	return a
+}
+
+// Scrub redacts email and IPv4 addresses embedded in free text.
+func Scrub(s string) string {
+	s = emailRegex.ReplaceAllStringFunc(s, maskEmail)
+	return ipv4Regex.ReplaceAllStringFunc(s, maskIP)
+}
+
+// maskEmail keeps the domain, which is useful when debugging deliverability.
+func maskEmail(email string) string {
+	_, domain, ok := strings.Cut(email, "@")
+	if !ok {
+		return redacted
+	}
+	return "***@" + domain
+}
+
+// maskIP zeroes the host part: the last octet of IPv4 addresses and the last
+// 80 bits of IPv6 addresses.
+func maskIP(value string) string {
+	ip := net.ParseIP(value)
+	if ip == nil {
+		if host, _, err := net.SplitHostPort(value); err == nil {
+			ip = net.ParseIP(host)
+		}
+	}
+	if ip == nil {
+		return redacted
+	}
+	if v4 := ip.To4(); v4 != nil {
+		return v4.Mask(net.CIDRMask(24, 32)).String()
+	}
+	return ip.Mask(net.CIDRMask(48, 128)).String()
+}
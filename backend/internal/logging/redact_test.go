@@ -0,0 +1,77 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"strings"
+	"testing"
+
+	"slink-backend/internal/config"
+)
+
+type user struct{ email string }
+
+func (u user) String() string { return "user " + u.email }
+
+type secretValuer struct{}
+
+func (secretValuer) LogValue() slog.Value { return slog.StringValue("owner is jane@example.com") }
+
+func logLine(t *testing.T, format string, redact bool, log func(*slog.Logger)) string {
+	t.Helper()
+	var buf bytes.Buffer
+	logger, err := newLogger(&buf, &config.Config{LogLevel: "info", LogFormat: format, LogRedactPII: redact})
+	if err != nil {
+		t.Fatal(err)
+	}
+	log(logger)
+	return buf.String()
+}
+
+func TestRedactsErrorsAndOtherValues(t *testing.T) {
+	for _, format := range []string{"text", "json"} {
+		t.Run(format, func(t *testing.T) {
+			out := logLine(t, format, true, func(l *slog.Logger) {
+				err := fmt.Errorf("update failed: %w", errors.New("duplicate email jane.doe@example.com from 203.0.113.7"))
+				l.Error("Failed to update profile", "error", err, "who", user{"bob@example.org"},
+					"valuer", secretValuer{}, "ip", net.ParseIP("198.51.100.23"), "count", 3)
+			})
+
+			for _, leaked := range []string{"jane.doe@", "203.0.113.7", "bob@", "jane@", "198.51.100.23"} {
+				if strings.Contains(out, leaked) {
+					t.Errorf("log line leaks %q: %s", leaked, out)
+				}
+			}
+			for _, kept := range []string{"***@example.com", "203.0.113.0", "***@example.org", "198.51.100.0", "update failed"} {
+				if !strings.Contains(out, kept) {
+					t.Errorf("log line is missing %q: %s", kept, out)
+				}
+			}
+		})
+	}
+}
+
+func TestRedactsSecretsAndMessages(t *testing.T) {
+	out := logLine(t, "json", true, func(l *slog.Logger) {
+		l.InfoContext(context.Background(), "Sent mail to jane@example.com", "password", "hunter2", "token", errors.New("abc"))
+	})
+	if strings.Contains(out, "hunter2") || strings.Contains(out, `"abc"`) || strings.Contains(out, "jane@") {
+		t.Errorf("log line leaks a secret: %s", out)
+	}
+	if !strings.Contains(out, "Sent mail to ***@example.com") {
+		t.Errorf("message was not scrubbed: %s", out)
+	}
+}
+
+func TestRedactionCanBeDisabled(t *testing.T) {
+	out := logLine(t, "text", false, func(l *slog.Logger) {
+		l.Error("failed", "error", errors.New("jane@example.com"))
+	})
+	if !strings.Contains(out, "jane@example.com") {
+		t.Errorf("redaction ran although disabled: %s", out)
+	}
+}
@@ -0,0 +1,31 @@
+package mailer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FileMailer stores every message as an .eml file in dir.
+type FileMailer struct {
+	dir  string
+	from string
+}
+
+func NewFileMailer(dir, from string) (*FileMailer, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create mail directory: %v", err)
+	}
+	return &FileMailer{dir: dir, from: from}, nil
+}
+
+func (m *FileMailer) Send(msg Message) error {
+	name := fmt.Sprintf("%s-%s.eml", time.Now().Format("20060102T150405"), uuid.New().String()[:8])
+	if err := os.WriteFile(filepath.Join(m.dir, name), buildMessage(m.from, msg), 0o644); err != nil {
+		return fmt.Errorf("failed to write mail: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,19 @@
+package mailer
+
+import (
+	"log/slog"
+)
+
+// LogMailer writes outgoing mail to the application log instead of sending it.
+type LogMailer struct {
+	from string
+}
+
+func NewLogMailer(from string) *LogMailer {
+	return &LogMailer{from: from}
+}
+
+func (m *LogMailer) Send(msg Message) error {
+	slog.Info("Mail", "to", msg.To, "subject", msg.Subject, "body", msg.Body)
+	return nil
+}
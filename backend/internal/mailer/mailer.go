@@ -0,0 +1,50 @@
+package mailer
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"slink-backend/internal/config"
+
+	"github.com/google/uuid"
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+type Mailer interface {
+	Send(msg Message) error
+}
+
+// New returns the mailer selected by MAIL_DRIVER.
+func New(cfg *config.Config) (Mailer, error) {
+	switch cfg.MailDriver {
+	case "smtp":
+		return NewSMTPMailer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.MailFrom), nil
+	case "file":
+		return NewFileMailer(cfg.MailFileDir, cfg.MailFrom)
+	case "log", "":
+		return NewLogMailer(cfg.MailFrom), nil
+	default:
+		return nil, fmt.Errorf("unknown mail driver: %s", cfg.MailDriver)
+	}
+}
+
+// buildMessage renders msg as a plain-text RFC 5322 message.
+func buildMessage(from string, msg Message) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", msg.Subject)
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "Message-ID: <%s@slink>\r\n", uuid.New().String())
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	buf.WriteString("\r\n")
+	buf.WriteString(msg.Body)
+	return buf.Bytes()
+}
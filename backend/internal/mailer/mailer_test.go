@@ -0,0 +1,141 @@
+package mailer
+
+import (
+	"bufio"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"slink-backend/internal/config"
+)
+
+type received struct {
+	from, to, data string
+}
+
+// smtpSink accepts one SMTP session and sends the envelope and data it
+// received on the returned channel.
+func smtpSink(t *testing.T) (host string, port int, result <-chan received) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	ch := make(chan received, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		r := bufio.NewReader(conn)
+		reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+		var got received
+		reply("220 sink ready")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			cmd := strings.TrimRight(line, "\r\n")
+			switch upper := strings.ToUpper(cmd); {
+			case strings.HasPrefix(upper, "EHLO"), strings.HasPrefix(upper, "HELO"):
+				reply("250 sink")
+			case strings.HasPrefix(upper, "MAIL FROM:"):
+				got.from = strings.Trim(cmd[len("MAIL FROM:"):], "<>")
+				reply("250 ok")
+			case strings.HasPrefix(upper, "RCPT TO:"):
+				got.to = strings.Trim(cmd[len("RCPT TO:"):], "<>")
+				reply("250 ok")
+			case upper == "DATA":
+				reply("354 go ahead")
+				var data strings.Builder
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					if line == ".\r\n" {
+						break
+					}
+					data.WriteString(line)
+				}
+				got.data = data.String()
+				reply("250 queued")
+			case upper == "QUIT":
+				reply("221 bye")
+				ch <- got
+				return
+			default:
+				reply("250 ok")
+			}
+		}
+	}()
+
+	addr := ln.Addr().(*net.TCPAddr)
+	return addr.IP.String(), addr.Port, ch
+}
+
+func TestSMTPMailerSend(t *testing.T) {
+	host, port, result := smtpSink(t)
+	m := NewSMTPMailer(host, port, "", "", "Slink <no-reply@example.com>")
+
+	err := m.Send(Message{To: "user@example.com", Subject: "Verify your email", Body: "Open this link"})
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	got := <-result
+	if got.from != "no-reply@example.com" {
+		t.Errorf("MAIL FROM = %q", got.from)
+	}
+	if got.to != "user@example.com" {
+		t.Errorf("RCPT TO = %q", got.to)
+	}
+	for _, want := range []string{"Subject: Verify your email\r\n", "To: user@example.com\r\n", "\r\n\r\nOpen this link"} {
+		if !strings.Contains(got.data, want) {
+			t.Errorf("message lacks %q:\n%s", want, got.data)
+		}
+	}
+}
+
+func TestSMTPMailerInvalidSender(t *testing.T) {
+	m := NewSMTPMailer("127.0.0.1", 1, "", "", "not an address")
+	if err := m.Send(Message{To: "user@example.com"}); err == nil {
+		t.Error("expected an error for an invalid sender")
+	}
+}
+
+func TestFileMailerSend(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "mail")
+	m, err := NewFileMailer(dir, "Slink <no-reply@example.com>")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Send(Message{To: "user@example.com", Subject: "Reset", Body: "body"}); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("found %d messages, want 1 (%v)", len(files), err)
+	}
+	data, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "Subject: Reset\r\n") {
+		t.Errorf("message = %q", data)
+	}
+}
+
+func TestNewRejectsUnknownDriver(t *testing.T) {
+	if _, err := New(&config.Config{MailDriver: "pigeon"}); err == nil {
+		t.Error("expected an error for an unknown driver")
+	}
+}
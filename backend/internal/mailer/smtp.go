@@ -0,0 +1,43 @@
+package mailer
+
+import (
+	"fmt"
+	"net/mail"
+	"net/smtp"
+)
+
+type SMTPMailer struct {
+	host     string
+	port     int
+	username string
+	password string
+	from     string
+}
+
+func NewSMTPMailer(host string, port int, username, password, from string) *SMTPMailer {
+	return &SMTPMailer{
+		host:     host,
+		port:     port,
+		username: username,
+		password: password,
+		from:     from,
+	}
+}
+
+func (m *SMTPMailer) Send(msg Message) error {
+	sender, err := mail.ParseAddress(m.from)
+	if err != nil {
+		return fmt.Errorf("invalid sender address: %v", err)
+	}
+
+	var auth smtp.Auth
+	if m.username != "" {
+		auth = smtp.PlainAuth("", m.username, m.password, m.host)
+	}
+
+	addr := fmt.Sprintf("%s:%d", m.host, m.port)
+	if err := smtp.SendMail(addr, auth, sender.Address, []string{msg.To}, buildMessage(m.from, msg)); err != nil {
+		return fmt.Errorf("failed to send mail: %v", err)
+	}
+	return nil
+}
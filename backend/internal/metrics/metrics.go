@@ -0,0 +1,92 @@
+// Package metrics defines the Prometheus metrics served on /metrics.
+package metrics
+
+import (
+	"errors"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "slink"
+
+var (
+	HTTPRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by route template, method and status code.",
+	}, []string{"method", "route", "status"})
+
+	HTTPDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by route template and method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	// Redirects counts visits to short links. Source is link or qr; outcome
+	// is redirected, preview, not_found, expired or taken_down.
+	Redirects = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "redirects_total",
+		Help:      "Short link visits by source and outcome.",
+	}, []string{"source", "outcome"})
+
+	ShortCodeCollisions = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "short_code_collisions_total",
+		Help:      "Generated short codes that were already taken and had to be retried.",
+	})
+
+	DBDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_query_duration_seconds",
+		Help:      "Supabase call latency by operation, table and result.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"operation", "table", "status"})
+
+	QRGenerationDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "qr_generation_duration_seconds",
+		Help:      "Time spent rendering QR codes by output format.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+	}, []string{"format"})
+
+	PendingHitUpdates = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "pending_hit_updates",
+		Help:      "Click and scan counts still being written in the background.",
+	})
+)
+
+// RegisterCache exports the hit and miss counts of a cache under the given
+// name. The hit ratio is hits / (hits + misses). Registering the same name
+// twice is a no-op.
+func RegisterCache(name string, stats func() (hits, misses uint64)) {
+	labels := prometheus.Labels{"cache": name}
+	register(prometheus.NewCounterFunc(prometheus.CounterOpts{
+		Namespace:   namespace,
+		Name:        "cache_hits_total",
+		Help:        "Cache lookups that found an entry.",
+		ConstLabels: labels,
+	}, func() float64 {
+		hits, _ := stats()
+		return float64(hits)
+	}))
+	register(prometheus.NewCounterFunc(prometheus.CounterOpts{
+		Namespace:   namespace,
+		Name:        "cache_misses_total",
+		Help:        "Cache lookups that found no entry.",
+		ConstLabels: labels,
+	}, func() float64 {
+		_, misses := stats()
+		return float64(misses)
+	}))
+}
+
+func register(c prometheus.Collector) {
+	var exists prometheus.AlreadyRegisteredError
+	if err := prometheus.Register(c); err != nil && !errors.As(err, &exists) {
+		panic(err)
+	}
+}
@@ -0,0 +1,30 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestRegisterCacheTwice(t *testing.T) {
+	RegisterCache("test", func() (uint64, uint64) { return 3, 1 })
+	RegisterCache("test", func() (uint64, uint64) { return 0, 0 })
+
+	families, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]float64{}
+	for _, family := range families {
+		for _, m := range family.GetMetric() {
+			for _, label := range m.GetLabel() {
+				if label.GetName() == "cache" && label.GetValue() == "test" {
+					got[family.GetName()] = m.GetCounter().GetValue()
+				}
+			}
+		}
+	}
+	if got["slink_cache_hits_total"] != 3 || got["slink_cache_misses_total"] != 1 {
+		t.Errorf("cache metrics = %v, want the first registration's 3 hits and 1 miss", got)
+	}
+}
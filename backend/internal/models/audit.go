@@ -0,0 +1,65 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	AuditLoginSuccess     = "auth.login.success"
+	AuditLoginFailure     = "auth.login.failure"
+	AuditTokenIssued      = "auth.token.issued"
+	AuditPasswordChanged  = "auth.password.changed"
+	AuditPasswordReset    = "auth.password.reset"
+	AuditMFAEnabled       = "auth.mfa.enabled"
+	AuditMFADisabled      = "auth.mfa.disabled"
+	AuditUserRegistered   = "user.registered"
+	AuditUserUpdated      = "user.updated"
+	AuditUserDeleted      = "user.deleted"
+	AuditLinkCreated      = "link.created"
+	AuditLinkUpdated      = "link.updated"
+	AuditLinkDeleted      = "link.deleted"
+	AuditWebhookCreated   = "webhook.created"
+	AuditWebhookUpdated   = "webhook.updated"
+	AuditWebhookDeleted   = "webhook.deleted"
+	AuditAdminListUsers   = "admin.user.list"
+	AuditAdminUserDisable = "admin.user.disable"
+	AuditAdminUserEnable  = "admin.user.enable"
+	AuditAdminRequireMFA  = "admin.user.require_mfa"
+	AuditAdminSetPlan     = "admin.user.set_plan"
+	AuditAdminListLinks   = "admin.link.list"
+	AuditAdminTakedown    = "admin.link.takedown"
+	AuditAdminRestore     = "admin.link.restore"
+	AuditAdminViewStats   = "admin.stats.view"
+)
+
+type AuditEvent struct {
+	ID         string                 `json:"id,omitempty"`
+	ActorID    *string                `json:"actor_id,omitempty"`
+	Action     string                 `json:"action"`
+	TargetType string                 `json:"target_type,omitempty"`
+	TargetID   string                 `json:"target_id,omitempty"`
+	IP         string                 `json:"ip,omitempty"`
+	UserAgent  string                 `json:"user_agent,omitempty"`
+	Before     json.RawMessage        `json:"before,omitempty"`
+	After      json.RawMessage        `json:"after,omitempty"`
+	Changes    map[string]AuditChange `json:"changes,omitempty"`
+	Metadata   map[string]string      `json:"metadata,omitempty"`
+	CreatedAt  time.Time              `json:"created_at"`
+}
+
+type AuditChange struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+type AuditFilter struct {
+	ActorID    string
+	Action     string
+	TargetType string
+	TargetID   string
+	Since      *time.Time
+	Until      *time.Time
+	Limit      int
+	Offset     int
+}
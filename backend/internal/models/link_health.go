@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+const (
+	LinkHealthy = "healthy"
+	LinkFailing = "failing"
+)
+
+// LinkHealth is the outcome of the latest check of a link's destination.
+type LinkHealth struct {
+	LinkID              string     `json:"-"`
+	Status              string     `json:"status"`
+	StatusCode          *int       `json:"status_code,omitempty"`
+	LatencyMS           int64      `json:"latency_ms"`
+	RedirectChain       []string   `json:"redirect_chain"`
+	Error               string     `json:"error,omitempty"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	CheckedAt           time.Time  `json:"checked_at"`
+	NextCheckAt         time.Time  `json:"next_check_at"`
+	NotifiedAt          *time.Time `json:"-"`
+}
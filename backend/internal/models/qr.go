@@ -0,0 +1,23 @@
+package models
+
+// QRStyle holds the user-facing QR rendering options, taken from the query
+// string of GET /api/qr/:shortCode or the body of a batch export.
+type QRStyle struct {
+	Size            int    `json:"size,omitempty" form:"size"`
+	Foreground      string `json:"fg,omitempty" form:"fg"`
+	Background      string `json:"bg,omitempty" form:"bg"`
+	ErrorCorrection string `json:"ec,omitempty" form:"ec"`
+	Border          int    `json:"border,omitempty" form:"border"`
+	Logo            bool   `json:"logo,omitempty" form:"logo"`
+
+	// Variant labels a printed code so its scans can be told apart from
+	// other codes for the same link.
+	Variant string `json:"variant,omitempty" form:"variant"`
+}
+
+type QRBatchRequest struct {
+	LinkIDs []string `json:"link_ids,omitempty"`
+	Tag     string   `json:"tag,omitempty"`
+	Format  string   `json:"format,omitempty"`
+	QRStyle
+}
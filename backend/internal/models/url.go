@@ -5,25 +5,107 @@ import (
 )
 
 type URL struct {
-	ID          string    `json:"id" db:"id"`
-	OriginalURL string    `json:"original_url" db:"original_url"`
-	ShortCode   string    `json:"short_code" db:"short_code"`
-	CustomAlias *string   `json:"custom_alias,omitempty" db:"custom_alias"`
-	HitCount    int       `json:"hit_count" db:"hit_count"`
-	CreatedAt   time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+	ID             string        `json:"id" db:"id"`
+	OriginalURL    string        `json:"original_url" db:"original_url"`
+	ShortCode      string        `json:"short_code" db:"short_code"`
+	CustomAlias    *string       `json:"custom_alias,omitempty" db:"custom_alias"`
+	UserID         *string       `json:"user_id,omitempty" db:"user_id"`
+	HitCount       int           `json:"hit_count" db:"hit_count"`
+	QRScanCount    int           `json:"qr_scan_count" db:"qr_scan_count"`
+	Tags           []string      `json:"tags" db:"tags"`
+	TakenDownAt    *time.Time    `json:"taken_down_at,omitempty" db:"taken_down_at"`
+	TakedownReason *string       `json:"takedown_reason,omitempty" db:"takedown_reason"`
+	Metadata       *LinkMetadata `json:"metadata,omitempty" db:"-"`
+	OGTitle        *string       `json:"og_title,omitempty" db:"og_title"`
+	OGDescription  *string       `json:"og_description,omitempty" db:"og_description"`
+	OGImage        *string       `json:"og_image,omitempty" db:"og_image"`
+	Health         *LinkHealth   `json:"health,omitempty" db:"-"`
+	ExpiresAt      *time.Time    `json:"expires_at,omitempty" db:"expires_at"`
+	HideReferrer   bool          `json:"hide_referrer" db:"hide_referrer"`
+	CreatedAt      time.Time     `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time     `json:"updated_at" db:"updated_at"`
+}
+
+// Expired reports whether the link's expiry has passed.
+func (u *URL) Expired() bool {
+	return u.ExpiresAt != nil && !u.ExpiresAt.After(time.Now())
 }
 
 type ShortenRequest struct {
-	OriginalURL string  `json:"original_url" binding:"required"`
-	CustomAlias *string `json:"custom_alias,omitempty"`
+	OriginalURL string     `json:"original_url" binding:"required"`
+	CustomAlias *string    `json:"custom_alias,omitempty"`
+	Tags        []string   `json:"tags,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	// ReuseExisting overrides the user's default for returning an existing
+	// link to the same destination instead of creating a new one.
+	ReuseExisting *bool `json:"reuse_existing,omitempty"`
+	// HideReferrer sends visitors on without telling the destination where
+	// they came from.
+	HideReferrer bool `json:"hide_referrer,omitempty"`
 }
 
 type ShortenResponse struct {
-	ID          string  `json:"id"`
-	OriginalURL string  `json:"original_url"`
-	ShortCode   string  `json:"short_code"`
-	ShortURL    string  `json:"short_url"`
-	QRCodeURL   string  `json:"qr_code_url"`
-	CustomAlias *string `json:"custom_alias,omitempty"`
+	ID           string     `json:"id"`
+	OriginalURL  string     `json:"original_url"`
+	ShortCode    string     `json:"short_code"`
+	ShortURL     string     `json:"short_url"`
+	QRCodeURL    string     `json:"qr_code_url"`
+	CustomAlias  *string    `json:"custom_alias,omitempty"`
+	Tags         []string   `json:"tags"`
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
+	HideReferrer bool       `json:"hide_referrer"`
+	Reused       bool       `json:"reused"`
+}
+
+// AliasSuggestion answers whether a custom alias can be claimed, with
+// available alternatives when it can't.
+type AliasSuggestion struct {
+	Alias       string   `json:"alias"`
+	Available   bool     `json:"available"`
+	Reason      string   `json:"reason,omitempty"`
+	MinLength   int      `json:"min_length"`
+	MaxLength   int      `json:"max_length"`
+	Suggestions []string `json:"suggestions"`
+}
+
+type TakedownRequest struct {
+	Reason string `json:"reason" binding:"required,max=500"`
+}
+
+type UpdateLinkRequest struct {
+	OriginalURL  *string   `json:"original_url,omitempty"`
+	CustomAlias  *string   `json:"custom_alias,omitempty"` // empty string removes the alias
+	Tags         *[]string `json:"tags,omitempty"`
+	ExpiresAt    *string   `json:"expires_at,omitempty"` // RFC 3339; empty string removes the expiry
+	HideReferrer *bool     `json:"hide_referrer,omitempty"`
+
+	// Open Graph overrides shown to social crawlers; empty strings clear them.
+	OGTitle       *string `json:"og_title,omitempty"`
+	OGDescription *string `json:"og_description,omitempty"`
+	OGImage       *string `json:"og_image,omitempty"`
+}
+
+// LinkMetadata is what the destination page says about itself, fetched
+// when the link is created or its destination changes.
+type LinkMetadata struct {
+	Title       string     `json:"title,omitempty"`
+	Description string     `json:"description,omitempty"`
+	Image       string     `json:"image,omitempty"`
+	FetchedAt   *time.Time `json:"fetched_at,omitempty"`
+}
+
+// LinkStats splits a link's visits into QR code scans and other clicks.
+type LinkStats struct {
+	LinkID     string           `json:"link_id"`
+	ShortCode  string           `json:"short_code"`
+	TotalHits  int              `json:"total_hits"`
+	LinkClicks int              `json:"link_clicks"`
+	QRScans    int              `json:"qr_scans"`
+	QRVariants []QRVariantStats `json:"qr_variants"`
+}
+
+type QRVariantStats struct {
+	Variant       string    `json:"variant"`
+	Scans         int       `json:"scans"`
+	LastScannedAt time.Time `json:"last_scanned_at"`
 }
@@ -2,13 +2,33 @@ package models
 
 import "time"
 
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
 type User struct {
-	ID           string    `json:"id,omitempty" db:"id"`
-	Email        string    `json:"email" db:"email"`
-	PasswordHash string    `json:"password_hash" db:"password_hash"` // Include for DB operations
-	Name         *string   `json:"name,omitempty" db:"name"`
-	CreatedAt    time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
+	ID              string     `json:"id,omitempty" db:"id"`
+	Email           string     `json:"email" db:"email"`
+	PasswordHash    string     `json:"password_hash" db:"password_hash"` // Include for DB operations
+	Name            *string    `json:"name,omitempty" db:"name"`
+	EmailVerified   bool       `json:"email_verified" db:"email_verified"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty" db:"email_verified_at"`
+	TOTPSecret      *string    `json:"totp_secret,omitempty" db:"totp_secret"`
+	TOTPEnabled     bool       `json:"totp_enabled" db:"totp_enabled"`
+	TOTPLastStep    int64      `json:"totp_last_step" db:"totp_last_step"`
+	RecoveryCodes   []string   `json:"recovery_codes,omitempty" db:"recovery_codes"` // SHA-256 hashes
+	MFARequired     bool       `json:"mfa_required" db:"mfa_required"`
+	TokenVersion    int        `json:"token_version" db:"token_version"`
+	Role            string     `json:"role,omitempty" db:"role"`
+	Plan            string     `json:"plan,omitempty" db:"plan"`
+	Disabled        bool       `json:"disabled" db:"disabled"`
+	// ReuseExistingLinks makes shortening a destination the user already
+	// has a link for return that link, unless the request says otherwise.
+	ReuseExistingLinks bool       `json:"reuse_existing_links" db:"reuse_existing_links"`
+	DeletedAt          *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
+	CreatedAt          time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt          time.Time  `json:"updated_at" db:"updated_at"`
 }
 
 type RegisterRequest struct {
@@ -22,14 +42,108 @@ type LoginRequest struct {
 	Password string `json:"password" binding:"required"`
 }
 
+type VerifyEmailRequest struct {
+	Token string `json:"token" form:"token" binding:"required"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required,min=6"`
+}
+
+type MFALoginRequest struct {
+	MFAToken     string `json:"mfa_token" binding:"required"`
+	Code         string `json:"code,omitempty"`
+	RecoveryCode string `json:"recovery_code,omitempty"`
+}
+
+type MFACodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type DisableMFARequest struct {
+	Password     string `json:"password" binding:"required"`
+	Code         string `json:"code,omitempty"`
+	RecoveryCode string `json:"recovery_code,omitempty"`
+}
+
+type MFAChallengeResponse struct {
+	MFARequired bool   `json:"mfa_required"`
+	MFAToken    string `json:"mfa_token"`
+}
+
+type MFAEnrollmentResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+	QRCode     string `json:"qr_code"` // data URI of a PNG image
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+	Token         string   `json:"token,omitempty"`
+}
+
+type UpdateProfileRequest struct {
+	Name               *string `json:"name,omitempty"`
+	Email              *string `json:"email,omitempty" binding:"omitempty,email"`
+	ReuseExistingLinks *bool   `json:"reuse_existing_links,omitempty"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required,min=6"`
+}
+
+type DeleteAccountRequest struct {
+	Password string `json:"password" binding:"required"`
+}
+
 type AuthResponse struct {
-	Token string       `json:"token"`
-	User  UserResponse `json:"user"`
+	Token                 string       `json:"token"`
+	User                  UserResponse `json:"user"`
+	MFAEnrollmentRequired bool         `json:"mfa_enrollment_required,omitempty"`
 }
 
 type UserResponse struct {
-	ID        string    `json:"id"`
-	Email     string    `json:"email"`
-	Name      *string   `json:"name,omitempty"`
-	CreatedAt time.Time `json:"created_at"`
+	ID                 string    `json:"id"`
+	Email              string    `json:"email"`
+	Name               *string   `json:"name,omitempty"`
+	EmailVerified      bool      `json:"email_verified"`
+	TOTPEnabled        bool      `json:"totp_enabled"`
+	Role               string    `json:"role"`
+	Plan               string    `json:"plan"`
+	ReuseExistingLinks bool      `json:"reuse_existing_links"`
+	CreatedAt          time.Time `json:"created_at"`
+}
+
+// AdminUserResponse is the user representation returned by the admin API.
+type AdminUserResponse struct {
+	UserResponse
+	Disabled    bool       `json:"disabled"`
+	MFARequired bool       `json:"mfa_required"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+type SetMFARequiredRequest struct {
+	Required bool `json:"required"`
+}
+
+type SetPlanRequest struct {
+	Plan string `json:"plan" binding:"required"`
+}
+
+type SystemStats struct {
+	TotalUsers       int `json:"total_users"`
+	DisabledUsers    int `json:"disabled_users"`
+	AdminUsers       int `json:"admin_users"`
+	TotalLinks       int `json:"total_links"`
+	TakenDownLinks   int `json:"taken_down_links"`
+	TotalClicks      int `json:"total_clicks"`
+	LinksLast7Days   int `json:"links_last_7_days"`
+	SignupsLast7Days int `json:"signups_last_7_days"`
 }
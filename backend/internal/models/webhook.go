@@ -0,0 +1,93 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	WebhookLinkCreated = "link.created"
+	WebhookLinkUpdated = "link.updated"
+	WebhookLinkDeleted = "link.deleted"
+	WebhookLinkClicked = "link.clicked"
+	WebhookLinkExpired = "link.expired"
+)
+
+// WebhookEventTypes lists the events endpoints can subscribe to.
+var WebhookEventTypes = []string{
+	WebhookLinkCreated,
+	WebhookLinkUpdated,
+	WebhookLinkDeleted,
+	WebhookLinkClicked,
+	WebhookLinkExpired,
+}
+
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryDead      = "dead"
+)
+
+type WebhookEndpoint struct {
+	ID          string    `json:"id"`
+	UserID      string    `json:"user_id"`
+	URL         string    `json:"url"`
+	Description string    `json:"description,omitempty"`
+	Events      []string  `json:"events"`
+	Active      bool      `json:"active"`
+	Secret      string    `json:"secret,omitempty"` // only returned when created or rotated
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type CreateWebhookRequest struct {
+	URL         string   `json:"url" binding:"required"`
+	Description string   `json:"description,omitempty"`
+	Events      []string `json:"events" binding:"required,min=1"`
+}
+
+type UpdateWebhookRequest struct {
+	URL         *string   `json:"url,omitempty"`
+	Description *string   `json:"description,omitempty"`
+	Events      *[]string `json:"events,omitempty"`
+	Active      *bool     `json:"active,omitempty"`
+}
+
+// WebhookPayload is the body POSTed to endpoints.
+type WebhookPayload struct {
+	ID        string          `json:"id"`
+	Type      string          `json:"type"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// WebhookDelivery is one attempt series at sending an event to an endpoint.
+// Failed attempts are retried with exponential backoff until MaxAttempts,
+// after which the delivery is dead-lettered.
+type WebhookDelivery struct {
+	ID             string          `json:"id"`
+	EndpointID     string          `json:"endpoint_id"`
+	EventID        string          `json:"event_id"`
+	EventType      string          `json:"event_type"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  *time.Time      `json:"next_attempt_at,omitempty"`
+	LastStatusCode *int            `json:"last_status_code,omitempty"`
+	LastError      *string         `json:"last_error,omitempty"`
+	RedeliveryOf   *string         `json:"redelivery_of,omitempty"`
+	DeliveredAt    *time.Time      `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+}
+
+// LinkClick is the data of a link.clicked event.
+type LinkClick struct {
+	LinkID    string    `json:"link_id"`
+	ShortCode string    `json:"short_code"`
+	Source    string    `json:"source"`
+	Variant   string    `json:"variant,omitempty"`
+	Referrer  string    `json:"referrer,omitempty"`
+	UserAgent string    `json:"user_agent,omitempty"`
+	ClickedAt time.Time `json:"clicked_at"`
+}
@@ -0,0 +1,38 @@
+package notify
+
+import (
+	"fmt"
+
+	"slink-backend/internal/mailer"
+)
+
+// EmailNotifier mails alerts to the link's owner.
+type EmailNotifier struct {
+	mailer mailer.Mailer
+}
+
+func NewEmailNotifier(m mailer.Mailer) *EmailNotifier {
+	return &EmailNotifier{mailer: m}
+}
+
+func (n *EmailNotifier) LinkFailing(alert LinkAlert) error {
+	if alert.OwnerEmail == "" {
+		return nil
+	}
+
+	body := fmt.Sprintf(`Your short link %s appears to be broken.
+
+Destination: %s
+Problem: %s
+Failed checks in a row: %d
+Last checked: %s
+
+Update the destination or delete the link if it is no longer needed.
+`, alert.ShortURL, alert.OriginalURL, alert.reason(), alert.Failures, alert.CheckedAt.Format("2006-01-02 15:04 MST"))
+
+	return n.mailer.Send(mailer.Message{
+		To:      alert.OwnerEmail,
+		Subject: fmt.Sprintf("Your link %s is broken", alert.ShortURL),
+		Body:    body,
+	})
+}
@@ -0,0 +1,15 @@
+package notify
+
+import "log/slog"
+
+// LogNotifier writes alerts to the application log.
+type LogNotifier struct{}
+
+func NewLogNotifier() *LogNotifier {
+	return &LogNotifier{}
+}
+
+func (n *LogNotifier) LinkFailing(alert LinkAlert) error {
+	slog.Warn("Link is failing", "short_url", alert.ShortURL, "original_url", alert.OriginalURL, "owner_id", alert.OwnerID, "reason", alert.reason())
+	return nil
+}
@@ -0,0 +1,51 @@
+package notify
+
+import (
+	"fmt"
+	"time"
+
+	"slink-backend/internal/config"
+	"slink-backend/internal/mailer"
+)
+
+// LinkAlert tells a link's owner that its destination has started failing.
+type LinkAlert struct {
+	LinkID      string    `json:"link_id"`
+	ShortCode   string    `json:"short_code"`
+	ShortURL    string    `json:"short_url"`
+	OriginalURL string    `json:"original_url"`
+	OwnerID     string    `json:"owner_id"`
+	OwnerEmail  string    `json:"owner_email"`
+	StatusCode  *int      `json:"status_code,omitempty"`
+	Error       string    `json:"error,omitempty"`
+	Failures    int       `json:"consecutive_failures"`
+	CheckedAt   time.Time `json:"checked_at"`
+}
+
+type Notifier interface {
+	LinkFailing(alert LinkAlert) error
+}
+
+// New returns the notifier selected by NOTIFY_DRIVER.
+func New(cfg *config.Config, m mailer.Mailer) (Notifier, error) {
+	switch cfg.NotifyDriver {
+	case "email":
+		return NewEmailNotifier(m), nil
+	case "webhook":
+		if cfg.NotifyWebhookURL == "" {
+			return nil, fmt.Errorf("NOTIFY_WEBHOOK_URL is required for the webhook notifier")
+		}
+		return NewWebhookNotifier(cfg.NotifyWebhookURL), nil
+	case "log", "":
+		return NewLogNotifier(), nil
+	default:
+		return nil, fmt.Errorf("unknown notify driver: %s", cfg.NotifyDriver)
+	}
+}
+
+func (a LinkAlert) reason() string {
+	if a.StatusCode != nil {
+		return fmt.Sprintf("HTTP %d", *a.StatusCode)
+	}
+	return a.Error
+}
@@ -0,0 +1,106 @@
+package notify
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"slink-backend/internal/mailer"
+)
+
+func testAlert() LinkAlert {
+	status := http.StatusNotFound
+	return LinkAlert{
+		LinkID:      "link-1",
+		ShortCode:   "abc123",
+		ShortURL:    "https://sl.ink/abc123",
+		OriginalURL: "https://example.com/page",
+		OwnerID:     "user-1",
+		OwnerEmail:  "owner@example.com",
+		StatusCode:  &status,
+		Failures:    3,
+		CheckedAt:   time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC),
+	}
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	var got struct {
+		Event string    `json:"event"`
+		Data  LinkAlert `json:"data"`
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("Content-Type = %q", ct)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("body: %v", err)
+		}
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer srv.Close()
+
+	alert := testAlert()
+	if err := NewWebhookNotifier(srv.URL + "/ok").LinkFailing(alert); err != nil {
+		t.Fatalf("LinkFailing: %v", err)
+	}
+	if got.Event != "link.failing" || got.Data.LinkID != alert.LinkID || got.Data.Failures != 3 || *got.Data.StatusCode != http.StatusNotFound {
+		t.Errorf("payload = %+v", got)
+	}
+
+	if err := NewWebhookNotifier(srv.URL + "/fail").LinkFailing(alert); err == nil || !strings.Contains(err.Error(), "500") {
+		t.Errorf("LinkFailing = %v, want the 500 reported", err)
+	}
+}
+
+type sentMail struct {
+	messages []mailer.Message
+}
+
+func (m *sentMail) Send(msg mailer.Message) error {
+	m.messages = append(m.messages, msg)
+	return nil
+}
+
+func TestEmailNotifier(t *testing.T) {
+	m := &sentMail{}
+	alert := testAlert()
+	if err := NewEmailNotifier(m).LinkFailing(alert); err != nil {
+		t.Fatal(err)
+	}
+	if len(m.messages) != 1 {
+		t.Fatalf("sent %d messages, want 1", len(m.messages))
+	}
+
+	msg := m.messages[0]
+	if msg.To != "owner@example.com" || !strings.Contains(msg.Subject, "https://sl.ink/abc123") {
+		t.Errorf("message to %q, subject %q", msg.To, msg.Subject)
+	}
+	for _, want := range []string{
+		"https://sl.ink/abc123",
+		"Destination: https://example.com/page",
+		"Problem: HTTP 404",
+		"Failed checks in a row: 3",
+		"Last checked: 2024-05-01 12:30 UTC",
+	} {
+		if !strings.Contains(msg.Body, want) {
+			t.Errorf("body is missing %q:\n%s", want, msg.Body)
+		}
+	}
+
+	alert.StatusCode, alert.Error = nil, "dial tcp: connection refused"
+	NewEmailNotifier(m).LinkFailing(alert)
+	if !strings.Contains(m.messages[1].Body, "Problem: dial tcp: connection refused") {
+		t.Errorf("body does not give the error:\n%s", m.messages[1].Body)
+	}
+
+	alert.OwnerEmail = ""
+	NewEmailNotifier(m).LinkFailing(alert)
+	if len(m.messages) != 2 {
+		t.Error("mailed an owner without an email address")
+	}
+}
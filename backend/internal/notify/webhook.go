@@ -0,0 +1,43 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// WebhookNotifier POSTs alerts as JSON to a fixed URL.
+type WebhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+func NewWebhookNotifier(url string) *WebhookNotifier {
+	return &WebhookNotifier{
+		url:    url,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (n *WebhookNotifier) LinkFailing(alert LinkAlert) error {
+	payload, err := json.Marshal(struct {
+		Event string    `json:"event"`
+		Data  LinkAlert `json:"data"`
+	}{Event: "link.failing", Data: alert})
+	if err != nil {
+		return err
+	}
+
+	resp, err := n.client.Post(n.url, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to send webhook: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
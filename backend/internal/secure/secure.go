@@ -0,0 +1,165 @@
+// Package secure adds security headers to responses and moves plain HTTP
+// requests to HTTPS.
+package secure
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Policy configures the middleware.
+type Policy struct {
+	// HSTSMaxAge is how long browsers should insist on HTTPS; zero leaves
+	// out Strict-Transport-Security. It is only sent over HTTPS.
+	HSTSMaxAge            time.Duration
+	HSTSIncludeSubdomains bool
+	// FrameAncestors is the CSP frame-ancestors source list, e.g. 'none'.
+	FrameAncestors string
+	ReferrerPolicy string
+
+	// ForceHTTPS redirects plain HTTP requests to the same path on Host,
+	// except for paths starting with one of ExemptPaths, such as load
+	// balancer health checks. The request's own Host header is not used,
+	// since a client could point the redirect anywhere.
+	ForceHTTPS  bool
+	Host        string
+	ExemptPaths []string
+	// TrustedProxies are the IPs and CIDR ranges whose X-Forwarded-Proto
+	// header is believed.
+	TrustedProxies []string
+}
+
+type middleware struct {
+	hsts          string
+	csp           string
+	referrer      string
+	forceHTTPS    bool
+	host          string
+	exemptPaths   []string
+	trustedRanges []*net.IPNet
+}
+
+// New returns middleware enforcing p.
+func New(p Policy) (gin.HandlerFunc, error) {
+	m := &middleware{
+		referrer:    p.ReferrerPolicy,
+		forceHTTPS:  p.ForceHTTPS,
+		host:        p.Host,
+		exemptPaths: p.ExemptPaths,
+	}
+	if p.ForceHTTPS && p.Host == "" {
+		return nil, fmt.Errorf("secure: ForceHTTPS needs a host to redirect to")
+	}
+	if p.HSTSMaxAge > 0 {
+		m.hsts = "max-age=" + strconv.Itoa(int(p.HSTSMaxAge.Seconds()))
+		if p.HSTSIncludeSubdomains {
+			m.hsts += "; includeSubDomains"
+		}
+	}
+	if p.FrameAncestors != "" {
+		m.csp = "frame-ancestors " + p.FrameAncestors
+	}
+
+	ranges, err := parseProxies(p.TrustedProxies)
+	if err != nil {
+		return nil, err
+	}
+	m.trustedRanges = ranges
+	return m.handle, nil
+}
+
+// parseProxies parses IP addresses and CIDR ranges.
+func parseProxies(proxies []string) ([]*net.IPNet, error) {
+	ranges := make([]*net.IPNet, 0, len(proxies))
+	for _, proxy := range proxies {
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				return nil, fmt.Errorf("secure: invalid trusted proxy %q", proxy)
+			}
+			bits := 128
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			ranges = append(ranges, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, ipNet, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("secure: invalid trusted proxy range %q", proxy)
+		}
+		ranges = append(ranges, ipNet)
+	}
+	return ranges, nil
+}
+
+func (m *middleware) handle(c *gin.Context) {
+	secure := m.isHTTPS(c)
+
+	if m.forceHTTPS && !secure && !m.exempt(c.Request.URL.Path) {
+		// 308 keeps the method and body, so API clients aren't silently
+		// switched to GET.
+		target := "https://" + m.host + c.Request.URL.RequestURI()
+		c.Redirect(http.StatusPermanentRedirect, target)
+		c.Abort()
+		return
+	}
+
+	h := c.Writer.Header()
+	h.Set("X-Content-Type-Options", "nosniff")
+	if m.csp != "" {
+		h.Set("Content-Security-Policy", m.csp)
+	}
+	if m.referrer != "" {
+		h.Set("Referrer-Policy", m.referrer)
+	}
+	if secure && m.hsts != "" {
+		h.Set("Strict-Transport-Security", m.hsts)
+	}
+	c.Next()
+}
+
+// isHTTPS reports whether the client connected over HTTPS, either to us or
+// to a trusted proxy in front of us.
+func (m *middleware) isHTTPS(c *gin.Context) bool {
+	if c.Request.TLS != nil {
+		return true
+	}
+	if !m.trusted(c.Request.RemoteAddr) {
+		return false
+	}
+	proto, _, _ := strings.Cut(c.GetHeader("X-Forwarded-Proto"), ",")
+	return strings.EqualFold(strings.TrimSpace(proto), "https")
+}
+
+func (m *middleware) trusted(remoteAddr string) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	for _, r := range m.trustedRanges {
+		if r.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *middleware) exempt(path string) bool {
+	for _, prefix := range m.exemptPaths {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
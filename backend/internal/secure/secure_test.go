@@ -0,0 +1,164 @@
+package secure
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestParseProxies(t *testing.T) {
+	tests := []struct {
+		proxies []string
+		ip      string
+		want    bool
+	}{
+		{[]string{"10.0.0.1"}, "10.0.0.1", true},
+		{[]string{"10.0.0.1"}, "10.0.0.2", false},
+		{[]string{"10.0.0.0/8"}, "10.200.3.4", true},
+		{[]string{"10.0.0.0/8"}, "11.0.0.1", false},
+		{[]string{"::1"}, "::1", true},
+		{[]string{"fd00::/8"}, "fd12::1", true},
+		{[]string{"192.168.1.1", "172.16.0.0/12"}, "172.20.1.1", true},
+		{nil, "10.0.0.1", false},
+	}
+	for _, tt := range tests {
+		ranges, err := parseProxies(tt.proxies)
+		if err != nil {
+			t.Fatalf("parseProxies(%q): %v", tt.proxies, err)
+		}
+		m := &middleware{trustedRanges: ranges}
+		if got := m.trusted(net.JoinHostPort(tt.ip, "1234")); got != tt.want {
+			t.Errorf("%q trusts %s = %v, want %v", tt.proxies, tt.ip, got, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"proxy.internal", "10.0.0.300", "10.0.0.0/33", ""} {
+		if _, err := parseProxies([]string{bad}); err == nil {
+			t.Errorf("parseProxies(%q) accepted an invalid proxy", bad)
+		}
+	}
+}
+
+func TestNewRequiresHostToForceHTTPS(t *testing.T) {
+	if _, err := New(Policy{ForceHTTPS: true}); err == nil {
+		t.Error("New accepted ForceHTTPS without a host")
+	}
+}
+
+type request struct {
+	path       string
+	remoteAddr string
+	proto      string
+	host       string
+	tls        bool
+}
+
+func serve(t *testing.T, p Policy, r request) *httptest.ResponseRecorder {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	handler, err := New(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	router := gin.New()
+	router.Use(handler)
+	router.Any("/*path", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	req := httptest.NewRequest(http.MethodPost, r.path, nil)
+	req.RemoteAddr = r.remoteAddr
+	if r.host != "" {
+		req.Host = r.host
+	}
+	if r.proto != "" {
+		req.Header.Set("X-Forwarded-Proto", r.proto)
+	}
+	if r.tls {
+		req.TLS = &tls.ConnectionState{}
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestForceHTTPS(t *testing.T) {
+	policy := Policy{
+		ForceHTTPS:     true,
+		Host:           "sl.ink",
+		ExemptPaths:    []string{"/health"},
+		TrustedProxies: []string{"10.0.0.0/8"},
+	}
+
+	tests := []struct {
+		name     string
+		req      request
+		location string
+	}{
+		{"plain HTTP", request{path: "/api/shorten?x=1", remoteAddr: "203.0.113.5:1234"}, "https://sl.ink/api/shorten?x=1"},
+		{"spoofed host", request{path: "/abc", remoteAddr: "203.0.113.5:1234", host: "evil.example"}, "https://sl.ink/abc"},
+		{"untrusted forwarded proto", request{path: "/abc", remoteAddr: "203.0.113.5:1234", proto: "https"}, "https://sl.ink/abc"},
+		{"trusted forwarded proto", request{path: "/abc", remoteAddr: "10.1.2.3:1234", proto: "https"}, ""},
+		{"trusted proxy over HTTP", request{path: "/abc", remoteAddr: "10.1.2.3:1234", proto: "http"}, "https://sl.ink/abc"},
+		{"direct TLS", request{path: "/abc", remoteAddr: "203.0.113.5:1234", tls: true}, ""},
+		{"exempt path", request{path: "/health/ready", remoteAddr: "203.0.113.5:1234"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(t, policy, tt.req)
+			if tt.location == "" {
+				if rec.Code != http.StatusOK {
+					t.Errorf("status = %d, want 200", rec.Code)
+				}
+				return
+			}
+			if rec.Code != http.StatusPermanentRedirect {
+				t.Errorf("status = %d, want 308", rec.Code)
+			}
+			if got := rec.Header().Get("Location"); got != tt.location {
+				t.Errorf("Location = %q, want %q", got, tt.location)
+			}
+		})
+	}
+}
+
+func TestHeaders(t *testing.T) {
+	policy := Policy{
+		HSTSMaxAge:            365 * 24 * time.Hour,
+		HSTSIncludeSubdomains: true,
+		FrameAncestors:        "'none'",
+		ReferrerPolicy:        "strict-origin-when-cross-origin",
+		TrustedProxies:        []string{"10.0.0.1"},
+	}
+
+	tests := []struct {
+		name string
+		req  request
+		hsts string
+	}{
+		{"plain HTTP", request{path: "/", remoteAddr: "203.0.113.5:1234"}, ""},
+		{"untrusted forwarded proto", request{path: "/", remoteAddr: "203.0.113.5:1234", proto: "https"}, ""},
+		{"trusted forwarded proto", request{path: "/", remoteAddr: "10.0.0.1:1234", proto: "https"}, "max-age=31536000; includeSubDomains"},
+		{"direct TLS", request{path: "/", remoteAddr: "203.0.113.5:1234", tls: true}, "max-age=31536000; includeSubDomains"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := serve(t, policy, tt.req).Header()
+			if got := h.Get("Strict-Transport-Security"); got != tt.hsts {
+				t.Errorf("Strict-Transport-Security = %q, want %q", got, tt.hsts)
+			}
+			if got := h.Get("X-Content-Type-Options"); got != "nosniff" {
+				t.Errorf("X-Content-Type-Options = %q", got)
+			}
+			if got := h.Get("Content-Security-Policy"); got != "frame-ancestors 'none'" {
+				t.Errorf("Content-Security-Policy = %q", got)
+			}
+			if got := h.Get("Referrer-Policy"); got != "strict-origin-when-cross-origin" {
+				t.Errorf("Referrer-Policy = %q", got)
+			}
+		})
+	}
+}
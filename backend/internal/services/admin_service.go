@@ -0,0 +1,19 @@
+package services
+
+import (
+	"context"
+	"slink-backend/internal/database"
+	"slink-backend/internal/models"
+)
+
+type AdminService struct {
+	db *database.SupabaseClient
+}
+
+func NewAdminService(db *database.SupabaseClient) *AdminService {
+	return &AdminService{db: db}
+}
+
+func (s *AdminService) GetSystemStats(ctx context.Context) (*models.SystemStats, error) {
+	return s.db.GetSystemStats(ctx)
+}
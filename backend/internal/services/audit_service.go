@@ -0,0 +1,184 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"time"
+
+	"slink-backend/internal/database"
+	"slink-backend/internal/models"
+
+	"github.com/lengzuo/supa/postgres"
+	"github.com/lengzuo/supa/utils/enum"
+)
+
+const auditExportPageSize = 500
+
+// redactedAuditFields never leave the process in before/after snapshots.
+var redactedAuditFields = map[string]bool{
+	"password_hash":  true,
+	"totp_secret":    true,
+	"recovery_codes": true,
+	"secret":         true,
+}
+
+// AuditService appends to and reads from the audit_logs table. Events are
+// only ever inserted; the table itself rejects updates and deletes.
+type AuditService struct {
+	supabase *database.SupabaseClient
+}
+
+func NewAuditService(supabaseClient *database.SupabaseClient) *AuditService {
+	return &AuditService{supabase: supabaseClient}
+}
+
+// Record stores event, filling in the before/after snapshots and the
+// field-level diff between them.
+func (s *AuditService) Record(ctx context.Context, event models.AuditEvent, before, after interface{}) error {
+	beforeMap, err := auditSnapshot(before)
+	if err != nil {
+		return err
+	}
+	afterMap, err := auditSnapshot(after)
+	if err != nil {
+		return err
+	}
+
+	if beforeMap != nil {
+		event.Before, _ = json.Marshal(beforeMap)
+	}
+	if afterMap != nil {
+		event.After, _ = json.Marshal(afterMap)
+	}
+	if beforeMap != nil && afterMap != nil {
+		event.Changes = auditDiff(beforeMap, afterMap)
+	}
+	event.CreatedAt = time.Now()
+
+	client := s.supabase.GetClient()
+	var result models.AuditEvent
+	if err := database.Execute(ctx, "insert", "audit_logs", client.DB.From("audit_logs").Insert(event), &result); err != nil {
+		return fmt.Errorf("failed to record audit event: %v", err)
+	}
+	return nil
+}
+
+func (s *AuditService) List(ctx context.Context, filter models.AuditFilter) ([]models.AuditEvent, error) {
+	req := s.selectEvents(filter).Limit(filter.Limit).Offset(filter.Offset)
+	return s.list(ctx, req)
+}
+
+// Export writes every event matching filter to w as newline-delimited JSON,
+// paging through the table so large exports don't have to fit in memory.
+// filter.Limit and filter.Offset are ignored.
+//
+// Pages are keyed on the last event written rather than an offset, since
+// events appended during the export would otherwise shift later pages and
+// repeat rows. Each page holds events no newer than the last one written,
+// minus those at that same timestamp that already were.
+func (s *AuditService) Export(ctx context.Context, filter models.AuditFilter, w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	var last time.Time
+	var written []string
+
+	for {
+		req := s.selectEvents(filter).Limit(auditExportPageSize)
+		if len(written) > 0 {
+			req.Lte("created_at", last.UTC().Format(time.RFC3339Nano))
+			req.Not().In("id", written)
+		}
+		events, err := s.list(ctx, req)
+		if err != nil {
+			return err
+		}
+		for _, event := range events {
+			if err := encoder.Encode(event); err != nil {
+				return err
+			}
+			if !event.CreatedAt.Equal(last) {
+				last, written = event.CreatedAt, nil
+			}
+			written = append(written, event.ID)
+		}
+		if len(events) < auditExportPageSize {
+			return nil
+		}
+	}
+}
+
+// selectEvents builds the query for events matching filter, newest first.
+func (s *AuditService) selectEvents(filter models.AuditFilter) *postgres.SelectRequestBuilder {
+	client := s.supabase.GetClient()
+	req := client.DB.From("audit_logs").Select("*").Order("created_at", enum.OrderDesc)
+	if filter.ActorID != "" {
+		req.Eq("actor_id", filter.ActorID)
+	}
+	if filter.Action != "" {
+		req.Eq("action", filter.Action)
+	}
+	if filter.TargetType != "" {
+		req.Eq("target_type", filter.TargetType)
+	}
+	if filter.TargetID != "" {
+		req.Eq("target_id", filter.TargetID)
+	}
+	if filter.Since != nil {
+		req.Gte("created_at", filter.Since.UTC().Format(time.RFC3339))
+	}
+	if filter.Until != nil {
+		req.Lt("created_at", filter.Until.UTC().Format(time.RFC3339))
+	}
+	return req
+}
+
+func (s *AuditService) list(ctx context.Context, req *postgres.SelectRequestBuilder) ([]models.AuditEvent, error) {
+	var events []models.AuditEvent
+	if err := database.Execute(ctx, "select", "audit_logs", req, &events); err != nil {
+		return nil, fmt.Errorf("failed to list audit events: %v", err)
+	}
+	return events, nil
+}
+
+func auditSnapshot(v interface{}) (map[string]interface{}, error) {
+	if v == nil || (reflect.ValueOf(v).Kind() == reflect.Ptr && reflect.ValueOf(v).IsNil()) {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to snapshot audit value: %v", err)
+	}
+
+	var snapshot map[string]interface{}
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("failed to snapshot audit value: %v", err)
+	}
+
+	for field := range snapshot {
+		if redactedAuditFields[field] {
+			snapshot[field] = "[REDACTED]"
+		}
+	}
+	return snapshot, nil
+}
+
+func auditDiff(before, after map[string]interface{}) map[string]models.AuditChange {
+	changes := map[string]models.AuditChange{}
+	for field, to := range after {
+		if field == "updated_at" {
+			continue
+		}
+		if from, ok := before[field]; !ok || !reflect.DeepEqual(from, to) {
+			changes[field] = models.AuditChange{From: before[field], To: to}
+		}
+	}
+	for field, from := range before {
+		if _, ok := after[field]; !ok {
+			changes[field] = models.AuditChange{From: from, To: nil}
+		}
+	}
+	return changes
+}
@@ -0,0 +1,51 @@
+package services
+
+import (
+	"testing"
+
+	"slink-backend/internal/models"
+)
+
+func TestAuditSnapshotRedactsSecrets(t *testing.T) {
+	secret := "JBSWY3DPEHPK3PXP"
+	user := &models.User{ID: "user-1", Email: "a@example.com", PasswordHash: "$2a$10$hash", TOTPSecret: &secret}
+
+	snapshot, err := auditSnapshot(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, field := range []string{"password_hash", "totp_secret"} {
+		if snapshot[field] != "[REDACTED]" {
+			t.Errorf("%s = %v, want [REDACTED]", field, snapshot[field])
+		}
+	}
+	if snapshot["email"] != "a@example.com" {
+		t.Errorf("email = %v, want it kept", snapshot["email"])
+	}
+
+	endpoint, err := auditSnapshot(map[string]interface{}{"url": "https://example.com/hook", "secret": "whsec_test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if endpoint["secret"] != "[REDACTED]" {
+		t.Errorf("secret = %v, want [REDACTED]", endpoint["secret"])
+	}
+
+	var nilUser *models.User
+	if snapshot, err := auditSnapshot(nilUser); snapshot != nil || err != nil {
+		t.Errorf("auditSnapshot(nil) = %v, %v; want nil, nil", snapshot, err)
+	}
+}
+
+func TestAuditDiffOmitsRedactedFields(t *testing.T) {
+	before, _ := auditSnapshot(&models.User{ID: "user-1", PasswordHash: "old", Role: models.RoleUser})
+	after, _ := auditSnapshot(&models.User{ID: "user-1", PasswordHash: "new", Role: models.RoleAdmin})
+
+	changes := auditDiff(before, after)
+	if _, ok := changes["password_hash"]; ok {
+		t.Error("password change shows up in the diff")
+	}
+	if got := changes["role"]; got.From != models.RoleUser || got.To != models.RoleAdmin {
+		t.Errorf("role change = %+v", got)
+	}
+}
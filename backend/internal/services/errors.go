@@ -0,0 +1,97 @@
+package services
+
+import "errors"
+
+// Sentinel errors classifying what went wrong. Errors returned to API clients
+// wrap exactly one of them; use errors.Is to check the kind.
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrConflict     = errors.New("conflict")
+	ErrInvalidInput = errors.New("invalid input")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrForbidden    = errors.New("forbidden")
+	ErrGone         = errors.New("gone")
+	ErrExpired      = errors.New("expired")
+)
+
+// Error is an error whose message is safe to show to API clients. Code is a
+// stable, machine-readable identifier such as "alias_taken". Extensions are
+// extra members added to the response body.
+type Error struct {
+	Kind       error
+	Code       string
+	Message    string
+	Fields     []FieldError
+	Extensions map[string]interface{}
+}
+
+// FieldError describes a problem with one field of the request.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Kind
+}
+
+// With returns a copy of e with an extension member set, leaving shared
+// error values untouched.
+func (e *Error) With(key string, value interface{}) *Error {
+	copied := *e
+	copied.Extensions = map[string]interface{}{key: value}
+	for k, v := range e.Extensions {
+		if k != key {
+			copied.Extensions[k] = v
+		}
+	}
+	return &copied
+}
+
+func NotFound(code, message string) *Error {
+	return &Error{Kind: ErrNotFound, Code: code, Message: message}
+}
+
+func Conflict(code, message string) *Error {
+	return &Error{Kind: ErrConflict, Code: code, Message: message}
+}
+
+func InvalidInput(code, message string) *Error {
+	return &Error{Kind: ErrInvalidInput, Code: code, Message: message}
+}
+
+func Unauthorized(code, message string) *Error {
+	return &Error{Kind: ErrUnauthorized, Code: code, Message: message}
+}
+
+func Forbidden(code, message string) *Error {
+	return &Error{Kind: ErrForbidden, Code: code, Message: message}
+}
+
+func Gone(code, message string) *Error {
+	return &Error{Kind: ErrGone, Code: code, Message: message}
+}
+
+// Expired reports something that existed but has passed its expiry. It
+// maps to the same status as Gone.
+func Expired(code, message string) *Error {
+	return &Error{Kind: ErrExpired, Code: code, Message: message}
+}
+
+// InvalidField reports a problem with a single request field.
+func InvalidField(field, code, message string) *Error {
+	err := InvalidInput(code, message)
+	err.Fields = []FieldError{{Field: field, Code: code, Message: message}}
+	return err
+}
+
+// HasCode reports whether err is an *Error with the given code.
+func HasCode(err error, code string) bool {
+	var e *Error
+	return errors.As(err, &e) && e.Code == code
+}
@@ -0,0 +1,83 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"slink-backend/internal/database"
+)
+
+// IdempotencyKey is the stored outcome of a request made with an
+// Idempotency-Key header. StatusCode is nil while the first request is still
+// being processed.
+type IdempotencyKey struct {
+	UserID       string          `json:"user_id"`
+	Key          string          `json:"key"`
+	RequestHash  string          `json:"request_hash"`
+	StatusCode   *int            `json:"status_code"`
+	ResponseBody json.RawMessage `json:"response_body"`
+	CreatedAt    time.Time       `json:"created_at"`
+	Claimed      bool            `json:"claimed"`
+}
+
+// IdempotencyService remembers responses to keyed requests so retries get
+// the original response instead of repeating the side effect.
+type IdempotencyService struct {
+	supabase *database.SupabaseClient
+	ttl      time.Duration
+}
+
+func NewIdempotencyService(supabase *database.SupabaseClient, ttl time.Duration) *IdempotencyService {
+	return &IdempotencyService{supabase: supabase, ttl: ttl}
+}
+
+// Begin claims key for userID. If the key is new (or its previous use has
+// expired) the returned record has Claimed set and the caller must later
+// call Complete or Release. Otherwise the existing record is returned.
+func (s *IdempotencyService) Begin(ctx context.Context, userID, key, requestHash string) (*IdempotencyKey, error) {
+	client := s.supabase.GetClient()
+	var results []IdempotencyKey
+
+	params := map[string]interface{}{
+		"p_user_id":      userID,
+		"p_key":          key,
+		"p_request_hash": requestHash,
+		"p_ttl_seconds":  int(s.ttl.Seconds()),
+	}
+	if err := database.Execute(ctx, "rpc", "claim_idempotency_key", client.DB.RPC("claim_idempotency_key", params), &results); err != nil {
+		return nil, fmt.Errorf("failed to claim idempotency key: %v", err)
+	}
+	if len(results) == 0 {
+		return nil, fmt.Errorf("failed to claim idempotency key")
+	}
+
+	return &results[0], nil
+}
+
+// Complete stores the response for a claimed key.
+func (s *IdempotencyService) Complete(ctx context.Context, userID, key string, statusCode int, body []byte) error {
+	client := s.supabase.GetClient()
+	updateData := map[string]interface{}{
+		"status_code":   statusCode,
+		"response_body": json.RawMessage(body),
+	}
+
+	err := database.Execute(ctx, "update", "idempotency_keys", client.DB.From("idempotency_keys").Update(updateData).Eq("user_id", userID).Eq("key", key), nil)
+	if err != nil {
+		return fmt.Errorf("failed to store idempotent response: %v", err)
+	}
+	return nil
+}
+
+// Release frees a claimed key whose request failed, so it can be retried.
+func (s *IdempotencyService) Release(ctx context.Context, userID, key string) error {
+	client := s.supabase.GetClient()
+
+	err := database.Execute(ctx, "delete", "idempotency_keys", client.DB.From("idempotency_keys").Delete().Eq("user_id", userID).Eq("key", key), nil)
+	if err != nil {
+		return fmt.Errorf("failed to release idempotency key: %v", err)
+	}
+	return nil
+}
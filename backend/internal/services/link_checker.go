@@ -0,0 +1,249 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"slink-backend/internal/config"
+	"slink-backend/internal/database"
+	"slink-backend/internal/models"
+	"slink-backend/internal/notify"
+)
+
+const (
+	maxCheckRedirects = 10
+	maxCheckBackoff   = 24 * time.Hour
+	checkUserAgent    = "SlinkBot/1.0 (+link health check)"
+)
+
+// LinkChecker periodically requests each link's destination and records
+// whether it still works. Failing links are rechecked with exponential
+// backoff, and their owner is notified once a link has failed
+// failureThreshold checks in a row.
+type LinkChecker struct {
+	supabase         *database.SupabaseClient
+	health           *LinkHealthService
+	notifier         notify.Notifier
+	client           *http.Client
+	baseURL          string
+	interval         time.Duration
+	concurrency      int
+	batchSize        int
+	failureThreshold int
+
+	lastRun atomic.Int64
+}
+
+func NewLinkChecker(supabase *database.SupabaseClient, n notify.Notifier, cfg *config.Config) *LinkChecker {
+	return &LinkChecker{
+		supabase:         supabase,
+		health:           NewLinkHealthService(supabase),
+		notifier:         n,
+		client:           NewPublicHTTPClient(cfg.LinkCheckTimeout),
+		baseURL:          cfg.BaseURL,
+		interval:         cfg.LinkCheckInterval,
+		concurrency:      max(cfg.LinkCheckConcurrency, 1),
+		batchSize:        max(cfg.LinkCheckBatchSize, 1),
+		failureThreshold: max(cfg.LinkCheckFailureThreshold, 1),
+	}
+}
+
+// Run checks due links until ctx is cancelled. A full batch means more links
+// are waiting, so the next batch starts right away; otherwise it polls once a
+// minute for links that became due.
+func (lc *LinkChecker) Run(ctx context.Context) {
+	for {
+		n, err := lc.RunOnce(ctx)
+		if err != nil {
+			slog.Error("Link check failed", "error", err)
+		} else {
+			lc.lastRun.Store(time.Now().UnixNano())
+		}
+
+		wait := time.Minute
+		if n == lc.batchSize {
+			wait = 0
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// LastRun returns when Run last finished a batch without error, or the zero
+// time if it hasn't yet.
+func (lc *LinkChecker) LastRun() time.Time {
+	if ns := lc.lastRun.Load(); ns != 0 {
+		return time.Unix(0, ns)
+	}
+	return time.Time{}
+}
+
+// RunOnce checks one batch of due links and returns how many were checked.
+func (lc *LinkChecker) RunOnce(ctx context.Context) (int, error) {
+	links, err := lc.health.DueLinks(ctx, lc.batchSize)
+	if err != nil {
+		return 0, err
+	}
+	if len(links) == 0 {
+		return 0, nil
+	}
+
+	ids := make([]string, len(links))
+	for i, link := range links {
+		ids[i] = link.ID
+	}
+	previous, err := lc.health.GetHealth(ctx, ids)
+	if err != nil {
+		return 0, err
+	}
+
+	sem := make(chan struct{}, lc.concurrency)
+	var wg sync.WaitGroup
+	for i := range links {
+		link := &links[i]
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			lc.checkLink(ctx, link, previous[link.ID])
+		}()
+	}
+	wg.Wait()
+
+	return len(links), nil
+}
+
+func (lc *LinkChecker) checkLink(ctx context.Context, link *models.URL, previous *models.LinkHealth) {
+	health := lc.Probe(ctx, link.OriginalURL)
+	health.LinkID = link.ID
+
+	if lc.schedule(health, previous) && lc.notifyOwner(ctx, link, health) {
+		notifiedAt := time.Now()
+		health.NotifiedAt = &notifiedAt
+	}
+
+	if err := lc.health.SaveHealth(ctx, health); err != nil {
+		slog.Error("Failed to save link health", "link_id", link.ID, "error", err)
+	}
+}
+
+// schedule fills in health's failure count and next check from the previous
+// result and reports whether the owner should be notified. A failing link
+// keeps the previous NotifiedAt so its owner hears about it once; a healthy
+// check clears it, so the next run of failures is reported again.
+func (lc *LinkChecker) schedule(health, previous *models.LinkHealth) bool {
+	if health.Status == models.LinkHealthy {
+		health.NextCheckAt = health.CheckedAt.Add(lc.interval)
+		return false
+	}
+
+	health.ConsecutiveFailures = 1
+	if previous != nil {
+		health.ConsecutiveFailures = previous.ConsecutiveFailures + 1
+		health.NotifiedAt = previous.NotifiedAt
+	}
+	health.NextCheckAt = health.CheckedAt.Add(lc.backoff(health.ConsecutiveFailures))
+	return health.ConsecutiveFailures >= lc.failureThreshold && health.NotifiedAt == nil
+}
+
+// backoff doubles the check interval for every failure after the first.
+func (lc *LinkChecker) backoff(failures int) time.Duration {
+	d := lc.interval
+	for i := 1; i < failures && d < maxCheckBackoff; i++ {
+		d *= 2
+	}
+	return min(d, maxCheckBackoff)
+}
+
+// notifyOwner reports whether the owner was notified.
+func (lc *LinkChecker) notifyOwner(ctx context.Context, link *models.URL, health *models.LinkHealth) bool {
+	if link.UserID == nil {
+		return false
+	}
+	owner, err := lc.supabase.GetUserByID(ctx, *link.UserID)
+	if err != nil || owner.DeletedAt != nil {
+		return false
+	}
+
+	alert := notify.LinkAlert{
+		LinkID:      link.ID,
+		ShortCode:   link.ShortCode,
+		ShortURL:    fmt.Sprintf("%s/%s", lc.baseURL, link.ShortCode),
+		OriginalURL: link.OriginalURL,
+		OwnerID:     owner.ID,
+		OwnerEmail:  owner.Email,
+		StatusCode:  health.StatusCode,
+		Error:       health.Error,
+		Failures:    health.ConsecutiveFailures,
+		CheckedAt:   health.CheckedAt,
+	}
+	if err := lc.notifier.LinkFailing(alert); err != nil {
+		slog.Error("Failed to notify link owner", "link_id", link.ID, "error", err)
+		return false
+	}
+	return true
+}
+
+// Probe requests target and reports its status, latency and redirect chain.
+// It tries HEAD first and falls back to GET for servers that don't support
+// HEAD. Any response below 400 counts as healthy.
+func (lc *LinkChecker) Probe(ctx context.Context, target string) *models.LinkHealth {
+	health := lc.request(ctx, http.MethodHead, target)
+	if health.StatusCode != nil && (*health.StatusCode == http.StatusMethodNotAllowed || *health.StatusCode == http.StatusNotImplemented || *health.StatusCode == http.StatusForbidden) {
+		health = lc.request(ctx, http.MethodGet, target)
+	}
+	return health
+}
+
+func (lc *LinkChecker) request(ctx context.Context, method, target string) *models.LinkHealth {
+	health := &models.LinkHealth{
+		Status:        models.LinkFailing,
+		RedirectChain: []string{},
+		CheckedAt:     time.Now(),
+	}
+
+	client := *lc.client
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) >= maxCheckRedirects {
+			return fmt.Errorf("stopped after %d redirects", maxCheckRedirects)
+		}
+		if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+			return fmt.Errorf("unsupported redirect scheme %q", req.URL.Scheme)
+		}
+		health.RedirectChain = append(health.RedirectChain, req.URL.String())
+		return nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target, nil)
+	if err != nil {
+		health.Error = fmt.Sprintf("invalid URL: %v", err)
+		return health
+	}
+	req.Header.Set("User-Agent", checkUserAgent)
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	health.LatencyMS = time.Since(start).Milliseconds()
+	if err != nil {
+		health.Error = err.Error()
+		return health
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	health.StatusCode = &resp.StatusCode
+	if resp.StatusCode < 400 {
+		health.Status = models.LinkHealthy
+	}
+	return health
+}
@@ -0,0 +1,127 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"slink-backend/internal/models"
+)
+
+func TestLinkCheckBackoff(t *testing.T) {
+	lc := &LinkChecker{interval: time.Hour}
+
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{1, time.Hour},
+		{2, 2 * time.Hour},
+		{3, 4 * time.Hour},
+		{5, 16 * time.Hour},
+		{6, maxCheckBackoff},
+		{100, maxCheckBackoff},
+	}
+	for _, tt := range tests {
+		if got := lc.backoff(tt.failures); got != tt.want {
+			t.Errorf("backoff(%d) = %v, want %v", tt.failures, got, tt.want)
+		}
+	}
+}
+
+func TestLinkCheckNotifiesOnce(t *testing.T) {
+	lc := &LinkChecker{interval: time.Hour, failureThreshold: 2}
+	now := time.Now()
+	check := func(status string, previous *models.LinkHealth) (*models.LinkHealth, bool) {
+		health := &models.LinkHealth{Status: status, CheckedAt: now}
+		return health, lc.schedule(health, previous)
+	}
+
+	first, notify := check(models.LinkFailing, nil)
+	if notify || first.ConsecutiveFailures != 1 {
+		t.Errorf("first failure: notify = %v, failures = %d; want false, 1", notify, first.ConsecutiveFailures)
+	}
+
+	second, notify := check(models.LinkFailing, first)
+	if !notify || second.ConsecutiveFailures != 2 {
+		t.Fatalf("second failure: notify = %v, failures = %d; want true, 2", notify, second.ConsecutiveFailures)
+	}
+	if want := now.Add(2 * time.Hour); !second.NextCheckAt.Equal(want) {
+		t.Errorf("next check = %v, want %v", second.NextCheckAt, want)
+	}
+	second.NotifiedAt = &now
+
+	third, notify := check(models.LinkFailing, second)
+	if notify || third.NotifiedAt == nil || !third.NotifiedAt.Equal(now) {
+		t.Errorf("third failure: notify = %v, notified at %v; want false and the earlier notification kept", notify, third.NotifiedAt)
+	}
+
+	recovered, notify := check(models.LinkHealthy, third)
+	if notify || recovered.NotifiedAt != nil || recovered.ConsecutiveFailures != 0 {
+		t.Errorf("recovery: notify = %v, notified at %v, failures = %d; want false, nil, 0", notify, recovered.NotifiedAt, recovered.ConsecutiveFailures)
+	}
+	if want := now.Add(time.Hour); !recovered.NextCheckAt.Equal(want) {
+		t.Errorf("next check = %v, want %v", recovered.NextCheckAt, want)
+	}
+
+	if _, notify := check(models.LinkFailing, recovered); notify {
+		t.Error("first failure after recovery notified before the threshold")
+	}
+	again, _ := check(models.LinkFailing, recovered)
+	if _, notify := check(models.LinkFailing, again); !notify {
+		t.Error("failing again after recovery did not notify")
+	}
+}
+
+func TestProbeFallsBackToGet(t *testing.T) {
+	var methods []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		methods = append(methods, r.Method)
+		if r.Header.Get("User-Agent") != checkUserAgent {
+			t.Errorf("User-Agent = %q", r.Header.Get("User-Agent"))
+		}
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}))
+	defer srv.Close()
+
+	lc := &LinkChecker{client: srv.Client()}
+	health := lc.Probe(context.Background(), srv.URL)
+	if health.Status != models.LinkHealthy || health.StatusCode == nil || *health.StatusCode != http.StatusOK {
+		t.Errorf("health = %s %v, want healthy 200", health.Status, health.StatusCode)
+	}
+	if !slices.Equal(methods, []string{http.MethodHead, http.MethodGet}) {
+		t.Errorf("methods = %v, want HEAD then GET", methods)
+	}
+}
+
+func TestProbeRecordsRedirects(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle("/a", http.RedirectHandler("/b", http.StatusMovedPermanently))
+	mux.Handle("/b", http.RedirectHandler("/gone", http.StatusFound))
+	mux.Handle("/gone", http.NotFoundHandler())
+	mux.HandleFunc("/loop", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/loop", http.StatusFound)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	lc := &LinkChecker{client: srv.Client()}
+	health := lc.Probe(context.Background(), srv.URL+"/a")
+	if want := []string{srv.URL + "/b", srv.URL + "/gone"}; !slices.Equal(health.RedirectChain, want) {
+		t.Errorf("redirect chain = %v, want %v", health.RedirectChain, want)
+	}
+	if health.Status != models.LinkFailing || health.StatusCode == nil || *health.StatusCode != http.StatusNotFound {
+		t.Errorf("health = %s %v, want failing 404", health.Status, health.StatusCode)
+	}
+
+	health = lc.Probe(context.Background(), srv.URL+"/loop")
+	if health.Status != models.LinkFailing || !strings.Contains(health.Error, "stopped after") {
+		t.Errorf("loop: status %s, error %q; want failing after %d redirects", health.Status, health.Error, maxCheckRedirects)
+	}
+}
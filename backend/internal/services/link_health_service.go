@@ -0,0 +1,136 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"slink-backend/internal/database"
+	"slink-backend/internal/models"
+
+	"github.com/lengzuo/supa/utils/enum"
+)
+
+type linkHealthRecord struct {
+	URLID               string     `json:"url_id"`
+	Status              string     `json:"status"`
+	StatusCode          *int       `json:"status_code"`
+	LatencyMS           int64      `json:"latency_ms"`
+	RedirectChain       []string   `json:"redirect_chain"`
+	Error               *string    `json:"error"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	CheckedAt           time.Time  `json:"checked_at"`
+	NextCheckAt         time.Time  `json:"next_check_at"`
+	NotifiedAt          *time.Time `json:"notified_at"`
+}
+
+func (r linkHealthRecord) toModel() *models.LinkHealth {
+	health := &models.LinkHealth{
+		LinkID:              r.URLID,
+		Status:              r.Status,
+		StatusCode:          r.StatusCode,
+		LatencyMS:           r.LatencyMS,
+		RedirectChain:       r.RedirectChain,
+		ConsecutiveFailures: r.ConsecutiveFailures,
+		CheckedAt:           r.CheckedAt,
+		NextCheckAt:         r.NextCheckAt,
+		NotifiedAt:          r.NotifiedAt,
+	}
+	if r.Error != nil {
+		health.Error = *r.Error
+	}
+	if health.RedirectChain == nil {
+		health.RedirectChain = []string{}
+	}
+	return health
+}
+
+// LinkHealthService stores the results of destination health checks.
+type LinkHealthService struct {
+	supabase *database.SupabaseClient
+}
+
+func NewLinkHealthService(supabase *database.SupabaseClient) *LinkHealthService {
+	return &LinkHealthService{supabase: supabase}
+}
+
+// DueLinks returns up to limit links that have never been checked or whose
+// next check is due, least recently scheduled first. Taken-down links are
+// skipped.
+func (s *LinkHealthService) DueLinks(ctx context.Context, limit int) ([]models.URL, error) {
+	client := s.supabase.GetClient()
+	var results []URLRecord
+
+	err := database.Execute(ctx, "rpc", "links_due_for_check", client.DB.RPC("links_due_for_check", map[string]interface{}{"p_limit": limit}), &results)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list links due for check: %v", err)
+	}
+
+	return toModels(results), nil
+}
+
+// GetHealth returns the latest health of each of linkIDs that has been
+// checked, keyed by link ID.
+func (s *LinkHealthService) GetHealth(ctx context.Context, linkIDs []string) (map[string]*models.LinkHealth, error) {
+	health := make(map[string]*models.LinkHealth, len(linkIDs))
+	if len(linkIDs) == 0 {
+		return health, nil
+	}
+
+	client := s.supabase.GetClient()
+	var results []linkHealthRecord
+
+	err := database.Execute(ctx, "select", "link_health", client.DB.From("link_health").Select("*").Order("checked_at", enum.OrderDesc).In("url_id", linkIDs), &results)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get link health: %v", err)
+	}
+
+	for _, record := range results {
+		health[record.URLID] = record.toModel()
+	}
+	return health, nil
+}
+
+// AttachHealth fills in the Health field of links.
+func (s *LinkHealthService) AttachHealth(ctx context.Context, links []models.URL) error {
+	ids := make([]string, len(links))
+	for i, link := range links {
+		ids[i] = link.ID
+	}
+
+	health, err := s.GetHealth(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	for i := range links {
+		links[i].Health = health[links[i].ID]
+	}
+	return nil
+}
+
+func (s *LinkHealthService) SaveHealth(ctx context.Context, health *models.LinkHealth) error {
+	record := linkHealthRecord{
+		URLID:               health.LinkID,
+		Status:              health.Status,
+		StatusCode:          health.StatusCode,
+		LatencyMS:           health.LatencyMS,
+		RedirectChain:       health.RedirectChain,
+		ConsecutiveFailures: health.ConsecutiveFailures,
+		CheckedAt:           health.CheckedAt,
+		NextCheckAt:         health.NextCheckAt,
+		NotifiedAt:          health.NotifiedAt,
+	}
+	if health.Error != "" {
+		record.Error = &health.Error
+	}
+	if record.RedirectChain == nil {
+		record.RedirectChain = []string{}
+	}
+
+	client := s.supabase.GetClient()
+	if err := database.Execute(ctx, "upsert", "link_health", client.DB.From("link_health").Upsert(record), nil); err != nil {
+		return fmt.Errorf("failed to save link health: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,220 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+	"unicode/utf8"
+
+	"slink-backend/internal/models"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/charset"
+)
+
+const (
+	maxMetadataBodyBytes   = 512 << 10
+	maxMetadataRedirects   = 5
+	maxMetaTitleLength     = 300
+	maxMetaDescriptionLen  = 1000
+	metadataFetchUserAgent = "SlinkBot/1.0 (+link preview)"
+)
+
+var errNonPublicAddress = errors.New("destination resolves to a non-public address")
+
+// MetadataFetcher reads the title, description and preview image of a link's
+// destination. The HTTP client is injected so tests can point it at a local
+// server; production uses NewPublicHTTPClient.
+type MetadataFetcher struct {
+	client *http.Client
+}
+
+func NewMetadataFetcher(client *http.Client) *MetadataFetcher {
+	return &MetadataFetcher{client: client}
+}
+
+// NewPublicHTTPClient returns a client that refuses to connect to loopback,
+// private, link-local and other non-public addresses, so user-supplied URLs
+// can't be used to probe the internal network. The check runs on the dialed
+// address, which also covers redirects and DNS rebinding.
+func NewPublicHTTPClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil || !isPublicIP(ip) {
+				return errNonPublicAddress
+			}
+			return nil
+		},
+	}
+
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: timeout,
+			MaxIdleConns:        10,
+			IdleConnTimeout:     30 * time.Second,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxMetadataRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxMetadataRedirects)
+			}
+			if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+				return fmt.Errorf("unsupported redirect scheme %q", req.URL.Scheme)
+			}
+			return nil
+		},
+	}
+}
+
+var carrierGradeNAT = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+func isPublicIP(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() ||
+		carrierGradeNAT.Contains(ip))
+}
+
+// Fetch downloads rawURL and extracts its metadata. Only HTML responses are
+// parsed, and only the first maxMetadataBodyBytes of them.
+func (f *MetadataFetcher) Fetch(ctx context.Context, rawURL string) (*models.LinkMetadata, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL: %v", err)
+	}
+	req.Header.Set("User-Agent", metadataFetchUserAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml;q=0.9,*/*;q=0.1")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %v", rawURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("failed to fetch %s: status %d", rawURL, resp.StatusCode)
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	if mediaType, _, _ := mime.ParseMediaType(contentType); mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return &models.LinkMetadata{}, nil
+	}
+
+	body, err := charset.NewReader(io.LimitReader(resp.Body, maxMetadataBodyBytes), contentType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %v", rawURL, err)
+	}
+
+	return ParseMetadata(body, resp.Request.URL), nil
+}
+
+// ParseMetadata extracts page metadata from the <head> of an HTML document,
+// preferring Open Graph tags over Twitter card tags over plain <title> and
+// description. Relative image URLs are resolved against base.
+func ParseMetadata(r io.Reader, base *url.URL) *models.LinkMetadata {
+	found := map[string]string{}
+	set := func(key, value string) {
+		value = strings.Join(strings.Fields(value), " ")
+		if value != "" && found[key] == "" {
+			found[key] = value
+		}
+	}
+
+	z := html.NewTokenizer(r)
+	inTitle := false
+loop:
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			break loop
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			switch string(name) {
+			case "head":
+				break loop
+			case "title":
+				inTitle = false
+			}
+		case html.TextToken:
+			if inTitle {
+				set("title", string(z.Text()))
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			switch string(name) {
+			case "body":
+				break loop
+			case "title":
+				inTitle = true
+			case "meta":
+				if !hasAttr {
+					continue
+				}
+				var key, content string
+				for {
+					attr, val, more := z.TagAttr()
+					switch strings.ToLower(string(attr)) {
+					case "property", "name":
+						key = strings.ToLower(string(val))
+					case "content":
+						content = string(val)
+					}
+					if !more {
+						break
+					}
+				}
+				set(key, content)
+			}
+		}
+	}
+
+	meta := &models.LinkMetadata{
+		Title:       firstNonEmpty(found["og:title"], found["twitter:title"], found["title"]),
+		Description: firstNonEmpty(found["og:description"], found["twitter:description"], found["description"]),
+	}
+	meta.Title = truncateRunes(meta.Title, maxMetaTitleLength)
+	meta.Description = truncateRunes(meta.Description, maxMetaDescriptionLen)
+
+	if image := firstNonEmpty(found["og:image:secure_url"], found["og:image"], found["twitter:image"]); image != "" {
+		if ref, err := url.Parse(image); err == nil {
+			abs := base.ResolveReference(ref)
+			if abs.Scheme == "http" || abs.Scheme == "https" {
+				meta.Image = abs.String()
+			}
+		}
+	}
+
+	return meta
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func truncateRunes(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:n])
+}
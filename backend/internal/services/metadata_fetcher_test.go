@@ -0,0 +1,156 @@
+package services
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseMetadata(t *testing.T) {
+	base, _ := url.Parse("https://example.com/articles/1")
+
+	tests := []struct {
+		name                    string
+		html                    string
+		title, description, img string
+	}{
+		{
+			name:        "open graph wins",
+			html:        `<head><title>Plain</title><meta name="description" content="plain desc"><meta property="og:title" content="OG title"><meta property="og:description" content="OG desc"><meta name="twitter:title" content="Card title"></head>`,
+			title:       "OG title",
+			description: "OG desc",
+		},
+		{
+			name:        "twitter card before plain tags",
+			html:        `<head><title>Plain</title><meta name="twitter:title" content="Card title"><meta name="twitter:description" content="card desc"></head>`,
+			title:       "Card title",
+			description: "card desc",
+		},
+		{
+			name:        "plain title with collapsed whitespace",
+			html:        "<html><head><title>\n  Hello\n   world  </title><meta name=\"Description\" content=\"desc\"></head></html>",
+			title:       "Hello world",
+			description: "desc",
+		},
+		{
+			name: "relative image resolved",
+			html: `<head><meta property="og:image" content="/img/cover.png"></head>`,
+			img:  "https://example.com/img/cover.png",
+		},
+		{
+			name: "secure image preferred",
+			html: `<head><meta property="og:image" content="http://cdn.example.com/a.png"><meta property="og:image:secure_url" content="https://cdn.example.com/a.png"></head>`,
+			img:  "https://cdn.example.com/a.png",
+		},
+		{
+			name: "non-http image dropped",
+			html: `<head><meta property="og:image" content="javascript:alert(1)"></head>`,
+		},
+		{
+			name: "stops at body",
+			html: `<head></head><body><title>Not a title</title><meta property="og:title" content="late"></body>`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			meta := ParseMetadata(strings.NewReader(tt.html), base)
+			if meta.Title != tt.title || meta.Description != tt.description || meta.Image != tt.img {
+				t.Errorf("got title %q, description %q, image %q; want %q, %q, %q",
+					meta.Title, meta.Description, meta.Image, tt.title, tt.description, tt.img)
+			}
+		})
+	}
+}
+
+func TestParseMetadataTruncates(t *testing.T) {
+	long := strings.Repeat("é", maxMetaTitleLength+10)
+	meta := ParseMetadata(strings.NewReader("<title>"+long+"</title>"), &url.URL{})
+	if got := len([]rune(meta.Title)); got != maxMetaTitleLength {
+		t.Errorf("title has %d runes, want %d", got, maxMetaTitleLength)
+	}
+}
+
+func TestMetadataFetcherFetch(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/page", func(w http.ResponseWriter, r *http.Request) {
+		if r.UserAgent() != metadataFetchUserAgent {
+			t.Errorf("User-Agent = %q", r.UserAgent())
+		}
+		w.Header().Set("Content-Type", "text/html; charset=iso-8859-1")
+		// "Café" in Latin-1.
+		w.Write([]byte("<head><title>Caf\xe9</title><meta property=\"og:image\" content=\"cover.png\"></head>"))
+	})
+	mux.HandleFunc("/moved", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/page", http.StatusFound)
+	})
+	mux.HandleFunc("/file.pdf", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Write([]byte("%PDF-1.7"))
+	})
+	mux.HandleFunc("/missing", http.NotFound)
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	f := NewMetadataFetcher(srv.Client())
+	ctx := context.Background()
+
+	meta, err := f.Fetch(ctx, srv.URL+"/moved")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.Title != "Café" {
+		t.Errorf("Title = %q, want Café", meta.Title)
+	}
+	// Relative images resolve against the page redirected to.
+	if want := srv.URL + "/cover.png"; meta.Image != want {
+		t.Errorf("Image = %q, want %q", meta.Image, want)
+	}
+
+	meta, err = f.Fetch(ctx, srv.URL+"/file.pdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.Title != "" || meta.Description != "" || meta.Image != "" {
+		t.Errorf("non-HTML response gave metadata %+v", meta)
+	}
+
+	if _, err := f.Fetch(ctx, srv.URL+"/missing"); err == nil {
+		t.Error("404 response did not fail")
+	}
+}
+
+func TestPublicHTTPClientRefusesLoopback(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("request reached the loopback server")
+	}))
+	defer srv.Close()
+
+	_, err := NewMetadataFetcher(NewPublicHTTPClient(time.Second)).Fetch(context.Background(), srv.URL)
+	if err == nil || !strings.Contains(err.Error(), errNonPublicAddress.Error()) {
+		t.Errorf("err = %v, want %v", err, errNonPublicAddress)
+	}
+}
+
+func TestIsPublicIP(t *testing.T) {
+	for addr, want := range map[string]bool{
+		"8.8.8.8":     true,
+		"127.0.0.1":   false,
+		"10.1.2.3":    false,
+		"192.168.0.1": false,
+		"169.254.1.1": false,
+		"100.64.0.1":  false,
+		"0.0.0.0":     false,
+		"::1":         false,
+		"fd00::1":     false,
+		"2001:4860::": true,
+	} {
+		if got := isPublicIP(net.ParseIP(addr)); got != want {
+			t.Errorf("isPublicIP(%s) = %v, want %v", addr, got, want)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"slink-backend/internal/cache"
+	"slink-backend/internal/models"
+)
+
+// CachedQR is a rendered QR code together with what's needed to invalidate
+// it when the link or its owner's logo changes.
+type CachedQR struct {
+	Data        []byte
+	ContentType string
+	ETag        string
+	LinkID      string
+	OwnerID     string
+	UsesLogo    bool
+	// ExpiresAt is the link's expiry, so entries stop being served once the
+	// link has expired even though nothing invalidated them.
+	ExpiresAt *time.Time
+}
+
+// Expired reports whether the link the entry was rendered for has expired.
+func (e *CachedQR) Expired() bool {
+	return e.ExpiresAt != nil && !e.ExpiresAt.After(time.Now())
+}
+
+// QRCache keeps rendered QR codes keyed by the requested code and rendering
+// options, so repeated requests skip both the database and the encoder.
+type QRCache struct {
+	lru *cache.LRU[string, *CachedQR]
+}
+
+func NewQRCache(size int, ttl time.Duration) *QRCache {
+	return &QRCache{lru: cache.NewLRU[string, *CachedQR](size, ttl)}
+}
+
+// QRCacheKey identifies one rendering of code. The logo itself isn't part of
+// the key; entries using it are dropped by InvalidateLogo instead.
+func QRCacheKey(code, variant string, opts QROptions, useLogo bool, format QRFormat) string {
+	return fmt.Sprintf("%s|%s|%d|%02x%02x%02x%02x|%02x%02x%02x%02x|%d|%d|%t|%s",
+		code, variant, opts.Size,
+		opts.Foreground.R, opts.Foreground.G, opts.Foreground.B, opts.Foreground.A,
+		opts.Background.R, opts.Background.G, opts.Background.B, opts.Background.A,
+		opts.Level, opts.Border, useLogo, format)
+}
+
+// NewCachedQR wraps data rendered for link with a strong ETag derived from
+// its bytes.
+func NewCachedQR(data []byte, format QRFormat, link *models.URL, usesLogo bool) *CachedQR {
+	sum := sha256.Sum256(data)
+	entry := &CachedQR{
+		Data:        data,
+		ContentType: format.ContentType(),
+		ETag:        `"` + hex.EncodeToString(sum[:16]) + `"`,
+		LinkID:      link.ID,
+		UsesLogo:    usesLogo,
+		ExpiresAt:   link.ExpiresAt,
+	}
+	if link.UserID != nil {
+		entry.OwnerID = *link.UserID
+	}
+	return entry
+}
+
+func (c *QRCache) Get(key string) (*CachedQR, bool) {
+	return c.lru.Get(key)
+}
+
+func (c *QRCache) Set(key string, entry *CachedQR) {
+	c.lru.Set(key, entry)
+}
+
+// InvalidateLink drops every rendering of the link, whichever code it was
+// requested by.
+func (c *QRCache) InvalidateLink(linkID string) {
+	c.lru.DeleteFunc(func(_ string, entry *CachedQR) bool {
+		return entry.LinkID == linkID
+	})
+}
+
+func (c *QRCache) InvalidateOwner(userID string) {
+	c.lru.DeleteFunc(func(_ string, entry *CachedQR) bool {
+		return entry.OwnerID == userID
+	})
+}
+
+func (c *QRCache) InvalidateLogo(userID string) {
+	c.lru.DeleteFunc(func(_ string, entry *CachedQR) bool {
+		return entry.UsesLogo && entry.OwnerID == userID
+	})
+}
+
+func (c *QRCache) Len() int {
+	return c.lru.Len()
+}
+
+func (c *QRCache) Stats() (hits, misses uint64) {
+	return c.lru.Stats()
+}
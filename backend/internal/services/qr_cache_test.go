@@ -0,0 +1,62 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"slink-backend/internal/models"
+)
+
+func TestCachedQRExpired(t *testing.T) {
+	past := time.Now().Add(-time.Minute)
+	future := time.Now().Add(time.Hour)
+	owner := "user-1"
+
+	tests := []struct {
+		name      string
+		expiresAt *time.Time
+		want      bool
+	}{
+		{"no expiry", nil, false},
+		{"expires later", &future, false},
+		{"expired", &past, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			link := &models.URL{ID: "link-1", UserID: &owner, ExpiresAt: tt.expiresAt}
+			entry := NewCachedQR([]byte("png"), QRFormatPNG, link, false)
+			if got := entry.Expired(); got != tt.want {
+				t.Errorf("Expired() = %v, want %v", got, tt.want)
+			}
+			if entry.LinkID != "link-1" || entry.OwnerID != owner {
+				t.Errorf("entry is for link %q owned by %q", entry.LinkID, entry.OwnerID)
+			}
+		})
+	}
+}
+
+func TestQRCacheInvalidation(t *testing.T) {
+	alice, bob := "alice", "bob"
+	c := NewQRCache(10, 0)
+	c.Set("a1", NewCachedQR([]byte("1"), QRFormatPNG, &models.URL{ID: "a1", UserID: &alice}, true))
+	c.Set("a2", NewCachedQR([]byte("2"), QRFormatPNG, &models.URL{ID: "a2", UserID: &alice}, false))
+	c.Set("b1", NewCachedQR([]byte("3"), QRFormatPNG, &models.URL{ID: "b1", UserID: &bob}, true))
+
+	c.InvalidateLink("b1")
+	if _, ok := c.Get("b1"); ok {
+		t.Error("InvalidateLink left the link's entry")
+	}
+
+	c.InvalidateLogo(alice)
+	if _, ok := c.Get("a1"); ok {
+		t.Error("InvalidateLogo left an entry rendered with the logo")
+	}
+	if _, ok := c.Get("a2"); !ok {
+		t.Error("InvalidateLogo removed an entry rendered without the logo")
+	}
+
+	c.InvalidateOwner(alice)
+	if c.Len() != 0 {
+		t.Errorf("Len() = %d after invalidating every owner, want 0", c.Len())
+	}
+}
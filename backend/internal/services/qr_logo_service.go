@@ -0,0 +1,112 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+	"time"
+
+	"slink-backend/internal/database"
+)
+
+const (
+	MaxLogoBytes     = 1 << 20
+	maxLogoDimension = 4096
+	// Logos are stored downscaled; they never cover more than a fraction of
+	// a QR code, so larger originals would only waste space.
+	storedLogoSize = 512
+)
+
+type logoRecord struct {
+	UserID    string    `json:"user_id"`
+	Data      string    `json:"data"` // base64-encoded PNG
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+var errLogoFormat = InvalidField("logo", "invalid_logo", "logo must be a PNG, JPEG or GIF image")
+
+// QRLogoService stores the per-user logo that can be drawn into QR codes.
+type QRLogoService struct {
+	supabase *database.SupabaseClient
+}
+
+func NewQRLogoService(supabaseClient *database.SupabaseClient) *QRLogoService {
+	return &QRLogoService{supabase: supabaseClient}
+}
+
+// SaveLogo validates an uploaded PNG, JPEG or GIF and stores it as PNG.
+func (s *QRLogoService) SaveLogo(ctx context.Context, userID string, data []byte) error {
+	if len(data) > MaxLogoBytes {
+		return InvalidField("logo", "logo_too_large", fmt.Sprintf("logo must be at most %d bytes", MaxLogoBytes))
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return errLogoFormat
+	}
+	if cfg.Width > maxLogoDimension || cfg.Height > maxLogoDimension {
+		return InvalidField("logo", "logo_too_large", fmt.Sprintf("logo must be at most %dx%d pixels", maxLogoDimension, maxLogoDimension))
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return errLogoFormat
+	}
+	if img.Bounds().Dx() > storedLogoSize || img.Bounds().Dy() > storedLogoSize {
+		img = scaleToFit(img, storedLogoSize)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return fmt.Errorf("failed to encode logo: %v", err)
+	}
+
+	record := logoRecord{
+		UserID:    userID,
+		Data:      base64.StdEncoding.EncodeToString(buf.Bytes()),
+		UpdatedAt: time.Now(),
+	}
+
+	client := s.supabase.GetClient()
+	if err := database.Execute(ctx, "upsert", "qr_logos", client.DB.From("qr_logos").Upsert(record), nil); err != nil {
+		return fmt.Errorf("failed to save logo: %v", err)
+	}
+	return nil
+}
+
+// GetLogoPNG returns the stored logo as PNG bytes.
+func (s *QRLogoService) GetLogoPNG(ctx context.Context, userID string) ([]byte, error) {
+	client := s.supabase.GetClient()
+	var results []logoRecord
+
+	err := database.Execute(ctx, "select", "qr_logos", client.DB.From("qr_logos").Select("*").Eq("user_id", userID), &results)
+	if err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return nil, NotFound("logo_not_found", "logo not found")
+	}
+
+	return base64.StdEncoding.DecodeString(results[0].Data)
+}
+
+func (s *QRLogoService) GetLogo(ctx context.Context, userID string) (image.Image, error) {
+	data, err := s.GetLogoPNG(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return png.Decode(bytes.NewReader(data))
+}
+
+func (s *QRLogoService) DeleteLogo(ctx context.Context, userID string) error {
+	client := s.supabase.GetClient()
+	if err := database.Execute(ctx, "delete", "qr_logos", client.DB.From("qr_logos").Delete().Eq("user_id", userID), nil); err != nil {
+		return fmt.Errorf("failed to delete logo: %v", err)
+	}
+	return nil
+}
@@ -2,34 +2,274 @@ package services
 
 import (
 	"bytes"
-	"log"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"log/slog"
+	"math"
+	"strconv"
+	"strings"
 
 	"github.com/skip2/go-qrcode"
 )
 
+const (
+	MaxQRBorder = 16
+	// Logos cover at most this fraction of the code's width; with the
+	// highest recovery level that stays comfortably within the ~30% of
+	// modules a scanner can reconstruct.
+	qrLogoRatio = 0.22
+)
+
+// QROptions controls how a QR code is rendered. The zero value of every field
+// falls back to the service default.
+type QROptions struct {
+	Size       int
+	Foreground color.RGBA
+	Background color.RGBA
+	Level      qrcode.RecoveryLevel
+	Border     int // quiet zone width in modules
+	Logo       image.Image
+}
+
 type QRService struct {
-	size int
+	size    int
+	minSize int
+	maxSize int
+}
+
+func NewQRService(size, minSize, maxSize int) *QRService {
+	return &QRService{size: size, minSize: minSize, maxSize: maxSize}
+}
+
+// DefaultOptions returns the rendering used when a request doesn't ask for
+// anything else: black on white at QR_SIZE with medium recovery and no border.
+func (s *QRService) DefaultOptions() QROptions {
+	return QROptions{
+		Size:       s.size,
+		Foreground: color.RGBA{A: 0xff},
+		Background: color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff},
+		Level:      qrcode.Medium,
+	}
 }
 
-func NewQRService(size int) *QRService {
-	return &QRService{size: size}
+// ValidateOptions checks opts against the configured bounds.
+func (s *QRService) ValidateOptions(opts QROptions) error {
+	if opts.Size < s.minSize || opts.Size > s.maxSize {
+		return InvalidField("size", "out_of_range", fmt.Sprintf("size must be between %d and %d", s.minSize, s.maxSize))
+	}
+	if opts.Border < 0 || opts.Border > MaxQRBorder {
+		return InvalidField("border", "out_of_range", fmt.Sprintf("border must be between 0 and %d", MaxQRBorder))
+	}
+	// Judge the colors as they will be seen: a translucent background over
+	// a white page and the foreground over that.
+	bg := flatten(opts.Background, color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff})
+	if contrastRatio(flatten(opts.Foreground, bg), bg) < 3 {
+		return InvalidField("fg", "low_contrast", "foreground and background colors do not have enough contrast")
+	}
+	return nil
 }
 
 func (s *QRService) GenerateQRCode(url string) ([]byte, error) {
-	qrCode, err := qrcode.New(url, qrcode.Medium)
+	return s.GenerateStyledQRCode(url, s.DefaultOptions())
+}
+
+func (s *QRService) GenerateStyledQRCode(url string, opts QROptions) ([]byte, error) {
+	img, err := s.Render(url, opts)
 	if err != nil {
-		log.Printf("Error generating QR code: %v", err)
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	encoder := png.Encoder{CompressionLevel: png.BestCompression}
+	if err := encoder.Encode(&buf, img); err != nil {
+		slog.Error("Failed to write QR code to buffer", "error", err)
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Matrix returns the QR modules for url without any quiet zone;
+// matrix[y][x] is true for dark modules.
+func (s *QRService) Matrix(url string, opts QROptions) ([][]bool, error) {
+	level := opts.Level
+	if opts.Logo != nil {
+		level = qrcode.Highest
+	}
+
+	qrCode, err := qrcode.New(url, level)
+	if err != nil {
+		slog.Error("Failed to generate QR code", "error", err)
 		return nil, err
 	}
 
 	qrCode.DisableBorder = true
+	return qrCode.Bitmap(), nil
+}
 
-	var buf bytes.Buffer
-	err = qrCode.Write(s.size, &buf)
+// Render draws the QR code for url as an opts.Size square image.
+func (s *QRService) Render(url string, opts QROptions) (image.Image, error) {
+	matrix, err := s.Matrix(url, opts)
 	if err != nil {
-		log.Printf("Error writing QR code to buffer: %v", err)
 		return nil, err
 	}
 
-	return buf.Bytes(), nil
+	size := opts.Size
+	modules := len(matrix) + 2*opts.Border
+	if size < modules {
+		size = modules
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+	draw.Draw(img, img.Bounds(), image.NewUniform(opts.Background), image.Point{}, draw.Src)
+
+	// Map each pixel to the nearest module, like go-qrcode does.
+	modulesPerPixel := float64(modules) / float64(size)
+	for y := 0; y < size; y++ {
+		my := int(float64(y)*modulesPerPixel) - opts.Border
+		if my < 0 || my >= len(matrix) {
+			continue
+		}
+		for x := 0; x < size; x++ {
+			mx := int(float64(x)*modulesPerPixel) - opts.Border
+			if mx >= 0 && mx < len(matrix) && matrix[my][mx] {
+				img.SetRGBA(x, y, opts.Foreground)
+			}
+		}
+	}
+
+	if opts.Logo != nil {
+		codeSize := float64(len(matrix)) / modulesPerPixel
+		overlayLogo(img, opts.Logo, int(codeSize*qrLogoRatio), opts.Background)
+	}
+
+	return img, nil
+}
+
+// overlayLogo draws logo scaled to fit a width x width box in the center of
+// img, on a pad of the background color so it stays legible.
+func overlayLogo(img *image.RGBA, logo image.Image, width int, background color.RGBA) {
+	if width <= 0 {
+		return
+	}
+
+	bounds := img.Bounds()
+	pad := width / 10
+	center := image.Pt(bounds.Dx()/2, bounds.Dy()/2)
+	box := image.Rect(center.X-width/2-pad, center.Y-width/2-pad, center.X+width/2+pad, center.Y+width/2+pad)
+	draw.Draw(img, box, image.NewUniform(background), image.Point{}, draw.Src)
+
+	scaled := scaleToFit(logo, width)
+	sb := scaled.Bounds()
+	offset := image.Pt(center.X-sb.Dx()/2, center.Y-sb.Dy()/2)
+	draw.Draw(img, sb.Add(offset), scaled, sb.Min, draw.Over)
+}
+
+// scaleToFit resizes src so its longer side is max pixels, averaging the
+// source pixels that fall into each destination pixel.
+func scaleToFit(src image.Image, max int) image.Image {
+	sb := src.Bounds()
+	w, h := sb.Dx(), sb.Dy()
+	if w == 0 || h == 0 {
+		return image.NewRGBA(image.Rect(0, 0, 0, 0))
+	}
+
+	dw, dh := max, max
+	if w > h {
+		dh = int(math.Max(1, float64(h)*float64(max)/float64(w)))
+	} else {
+		dw = int(math.Max(1, float64(w)*float64(max)/float64(h)))
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		y0, y1 := sb.Min.Y+y*h/dh, sb.Min.Y+(y+1)*h/dh
+		if y1 <= y0 {
+			y1 = y0 + 1
+		}
+		for x := 0; x < dw; x++ {
+			x0, x1 := sb.Min.X+x*w/dw, sb.Min.X+(x+1)*w/dw
+			if x1 <= x0 {
+				x1 = x0 + 1
+			}
+
+			var r, g, b, a, n uint32
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := src.At(sx, sy).RGBA()
+					r, g, b, a, n = r+cr, g+cg, b+cb, a+ca, n+1
+				}
+			}
+			dst.SetRGBA64(x, y, color.RGBA64{R: uint16(r / n), G: uint16(g / n), B: uint16(b / n), A: uint16(a / n)})
+		}
+	}
+	return dst
+}
+
+// ParseHexColor parses #rgb, #rrggbb or #rrggbbaa (the leading # is optional).
+func ParseHexColor(s string) (color.RGBA, error) {
+	s = strings.TrimPrefix(s, "#")
+	if len(s) == 3 {
+		s = string([]byte{s[0], s[0], s[1], s[1], s[2], s[2]})
+	}
+	if len(s) == 6 {
+		s += "ff"
+	}
+	if len(s) != 8 {
+		return color.RGBA{}, InvalidInput("invalid_color", fmt.Sprintf("invalid color %q", s))
+	}
+
+	v, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return color.RGBA{}, InvalidInput("invalid_color", fmt.Sprintf("invalid color %q", s))
+	}
+	return color.RGBA{R: uint8(v >> 24), G: uint8(v >> 16), B: uint8(v >> 8), A: uint8(v)}, nil
+}
+
+// ParseRecoveryLevel accepts the standard L/M/Q/H error correction names.
+func ParseRecoveryLevel(s string) (qrcode.RecoveryLevel, error) {
+	switch strings.ToUpper(s) {
+	case "L":
+		return qrcode.Low, nil
+	case "M":
+		return qrcode.Medium, nil
+	case "Q":
+		return qrcode.High, nil
+	case "H":
+		return qrcode.Highest, nil
+	}
+	return 0, InvalidField("ec", "invalid_error_correction", "error correction must be one of L, M, Q, H")
+}
+
+// contrastRatio is the WCAG contrast ratio between two opaque colors.
+func contrastRatio(a, b color.RGBA) float64 {
+	la, lb := relativeLuminance(a), relativeLuminance(b)
+	if la < lb {
+		la, lb = lb, la
+	}
+	return (la + 0.05) / (lb + 0.05)
+}
+
+// flatten composites c, whose channels aren't premultiplied, over an opaque
+// backdrop.
+func flatten(c, backdrop color.RGBA) color.RGBA {
+	alpha := float64(c.A) / 0xff
+	mix := func(front, back uint8) uint8 {
+		return uint8(math.Round(float64(front)*alpha + float64(back)*(1-alpha)))
+	}
+	return color.RGBA{R: mix(c.R, backdrop.R), G: mix(c.G, backdrop.G), B: mix(c.B, backdrop.B), A: 0xff}
+}
+
+func relativeLuminance(c color.RGBA) float64 {
+	channel := func(v uint8) float64 {
+		f := float64(v) / 255
+		if f <= 0.03928 {
+			return f / 12.92
+		}
+		return math.Pow((f+0.055)/1.055, 2.4)
+	}
+	return 0.2126*channel(c.R) + 0.7152*channel(c.G) + 0.0722*channel(c.B)
 }
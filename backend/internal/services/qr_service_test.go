@@ -0,0 +1,60 @@
+package services
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateOptionsContrast(t *testing.T) {
+	s := NewQRService(256, 64, 2048)
+
+	for _, tc := range []struct {
+		name   string
+		fg, bg string
+		ok     bool
+	}{
+		{"black on white", "#000000", "#ffffff", true},
+		{"dark gray on white", "#333", "#fff", true},
+		{"light gray on white", "#dddddd", "#ffffff", false},
+		{"transparent foreground", "#00000000", "#ffffff", false},
+		{"faint foreground", "#00000020", "#ffffff", false},
+		{"mostly opaque foreground", "#000000e0", "#ffffff", true},
+		{"black on transparent background", "#000000", "#ffffff00", true},
+		{"white on transparent background", "#ffffff", "#00000000", false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			opts := s.DefaultOptions()
+			var err error
+			if opts.Foreground, err = ParseHexColor(tc.fg); err != nil {
+				t.Fatal(err)
+			}
+			if opts.Background, err = ParseHexColor(tc.bg); err != nil {
+				t.Fatal(err)
+			}
+
+			err = s.ValidateOptions(opts)
+			if tc.ok && err != nil {
+				t.Errorf("rejected: %v", err)
+			}
+			if !tc.ok && !errors.Is(err, ErrInvalidInput) {
+				t.Errorf("err = %v, want invalid input", err)
+			}
+		})
+	}
+}
+
+func TestParseHexColor(t *testing.T) {
+	c, err := ParseHexColor("#1a2b3c80")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.R != 0x1a || c.G != 0x2b || c.B != 0x3c || c.A != 0x80 {
+		t.Errorf("got %+v", c)
+	}
+	if c, _ := ParseHexColor("abc"); c.R != 0xaa || c.A != 0xff {
+		t.Errorf("short form: got %+v", c)
+	}
+	if _, err := ParseHexColor("#12345"); err == nil {
+		t.Error("accepted a 5-digit color")
+	}
+}
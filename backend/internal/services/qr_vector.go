@@ -0,0 +1,252 @@
+package services
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"time"
+
+	"slink-backend/internal/metrics"
+)
+
+// QRFormat is an output format supported by QRService.
+type QRFormat string
+
+const (
+	QRFormatPNG QRFormat = "png"
+	QRFormatSVG QRFormat = "svg"
+	QRFormatPDF QRFormat = "pdf"
+)
+
+func (f QRFormat) ContentType() string {
+	switch f {
+	case QRFormatSVG:
+		return "image/svg+xml"
+	case QRFormatPDF:
+		return "application/pdf"
+	default:
+		return "image/png"
+	}
+}
+
+func ParseQRFormat(s string) (QRFormat, error) {
+	switch QRFormat(s) {
+	case QRFormatPNG, QRFormatSVG, QRFormatPDF:
+		return QRFormat(s), nil
+	}
+	return "", InvalidField("format", "invalid_format", "format must be one of png, svg, pdf")
+}
+
+// Generate renders the QR code for url in the requested format.
+func (s *QRService) Generate(url string, opts QROptions, format QRFormat) ([]byte, error) {
+	defer func(start time.Time) {
+		metrics.QRGenerationDuration.WithLabelValues(string(format)).Observe(time.Since(start).Seconds())
+	}(time.Now())
+
+	switch format {
+	case QRFormatSVG:
+		return s.GenerateSVG(url, opts)
+	case QRFormatPDF:
+		return s.GeneratePDF(url, opts)
+	default:
+		return s.GenerateStyledQRCode(url, opts)
+	}
+}
+
+// GenerateSVG renders the QR code as an SVG document. Coordinates are in
+// modules, so the image scales without loss; opts.Size only sets the
+// default display size.
+func (s *QRService) GenerateSVG(url string, opts QROptions) ([]byte, error) {
+	matrix, err := s.Matrix(url, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	modules := len(matrix) + 2*opts.Border
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<?xml version="1.0" encoding="UTF-8"?>`+"\n")
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`+"\n",
+		opts.Size, opts.Size, modules, modules)
+	fmt.Fprintf(&buf, `<rect width="%d" height="%d" %s/>`+"\n", modules, modules, svgFill(opts.Background))
+
+	buf.WriteString(`<path `)
+	buf.WriteString(svgFill(opts.Foreground))
+	buf.WriteString(` d="`)
+	forEachRun(matrix, func(x, y, length int) {
+		fmt.Fprintf(&buf, "M%d %dh%dv1h-%dz", x+opts.Border, y+opts.Border, length, length)
+	})
+	buf.WriteString(`"/>` + "\n")
+
+	if opts.Logo != nil {
+		var logo bytes.Buffer
+		if err := png.Encode(&logo, opts.Logo); err != nil {
+			return nil, fmt.Errorf("failed to encode logo: %v", err)
+		}
+
+		box := logoBox(float64(len(matrix)), float64(opts.Border), opts.Logo.Bounds())
+		fmt.Fprintf(&buf, `<rect x="%.3f" y="%.3f" width="%.3f" height="%.3f" %s/>`+"\n",
+			box.padX, box.padY, box.padSize, box.padSize, svgFill(opts.Background))
+		fmt.Fprintf(&buf, `<image x="%.3f" y="%.3f" width="%.3f" height="%.3f" href="data:image/png;base64,%s"/>`+"\n",
+			box.x, box.y, box.w, box.h, base64.StdEncoding.EncodeToString(logo.Bytes()))
+	}
+
+	buf.WriteString("</svg>\n")
+	return buf.Bytes(), nil
+}
+
+// GeneratePDF renders the QR code as a single-page PDF whose page is
+// opts.Size points square, with every module drawn as a vector rectangle.
+func (s *QRService) GeneratePDF(url string, opts QROptions) ([]byte, error) {
+	matrix, err := s.Matrix(url, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	modules := float64(len(matrix) + 2*opts.Border)
+	page := float64(opts.Size)
+	scale := page / modules
+
+	// PDF's origin is the bottom-left corner, so flip the y axis once and
+	// draw in module units.
+	var content bytes.Buffer
+	fmt.Fprintf(&content, "%s rg 0 0 %.3f %.3f re f\n", pdfColor(opts.Background), page, page)
+	fmt.Fprintf(&content, "q %.5f 0 0 %.5f 0 %.3f cm\n", scale, -scale, page)
+	fmt.Fprintf(&content, "%s rg\n", pdfColor(opts.Foreground))
+	forEachRun(matrix, func(x, y, length int) {
+		fmt.Fprintf(&content, "%d %d %d 1 re\n", x+opts.Border, y+opts.Border, length)
+	})
+	content.WriteString("f\n")
+
+	var objects []string
+	resources := ""
+	if opts.Logo != nil {
+		box := logoBox(float64(len(matrix)), float64(opts.Border), opts.Logo.Bounds())
+		fmt.Fprintf(&content, "%s rg %.3f %.3f %.3f %.3f re f\n",
+			pdfColor(opts.Background), box.padX, box.padY, box.padSize, box.padSize)
+		// Images are drawn into the unit square; flip back so they aren't
+		// upside down.
+		fmt.Fprintf(&content, "q %.3f 0 0 %.3f %.3f %.3f cm /Logo Do Q\n", box.w, -box.h, box.x, box.y+box.h)
+
+		rgb, alpha := pdfImageData(opts.Logo)
+		b := opts.Logo.Bounds()
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceRGB /BitsPerComponent 8 /Filter /FlateDecode /SMask 7 0 R /Length %d >>\nstream\n%s\nendstream",
+				b.Dx(), b.Dy(), len(rgb), rgb),
+			fmt.Sprintf("<< /Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceGray /BitsPerComponent 8 /Filter /FlateDecode /Length %d >>\nstream\n%s\nendstream",
+				b.Dx(), b.Dy(), len(alpha), alpha),
+		)
+		resources = " /XObject << /Logo 6 0 R >>"
+	}
+	content.WriteString("Q\n")
+
+	// Objects 1-5 are fixed; the logo image and its mask, if any, are 6 and 7.
+	objects = append([]string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.3f %.3f] /Contents 4 0 R /Resources 5 0 R >>", page, page),
+		fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()),
+		fmt.Sprintf("<< /ProcSet [/PDF /ImageC]%s >>", resources),
+	}, objects...)
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+
+	return buf.Bytes(), nil
+}
+
+// forEachRun calls fn for every horizontal run of dark modules.
+func forEachRun(matrix [][]bool, fn func(x, y, length int)) {
+	for y, row := range matrix {
+		for x := 0; x < len(row); x++ {
+			if !row[x] {
+				continue
+			}
+			start := x
+			for x < len(row) && row[x] {
+				x++
+			}
+			fn(start, y, x-start)
+		}
+	}
+}
+
+type logoPlacement struct {
+	padX, padY, padSize float64
+	x, y, w, h          float64
+}
+
+// logoBox mirrors overlayLogo for vector output, in module units.
+func logoBox(codeModules, border float64, bounds image.Rectangle) logoPlacement {
+	width := codeModules * qrLogoRatio
+	pad := width / 10
+	center := border + codeModules/2
+
+	w, h := width, width
+	if bounds.Dx() > bounds.Dy() {
+		h = width * float64(bounds.Dy()) / float64(bounds.Dx())
+	} else if bounds.Dy() > bounds.Dx() {
+		w = width * float64(bounds.Dx()) / float64(bounds.Dy())
+	}
+
+	return logoPlacement{
+		padX:    center - width/2 - pad,
+		padY:    center - width/2 - pad,
+		padSize: width + 2*pad,
+		x:       center - w/2,
+		y:       center - h/2,
+		w:       w,
+		h:       h,
+	}
+}
+
+func svgFill(c color.RGBA) string {
+	fill := fmt.Sprintf(`fill="#%02x%02x%02x"`, c.R, c.G, c.B)
+	if c.A != 0xff {
+		fill += fmt.Sprintf(` fill-opacity="%.3f"`, float64(c.A)/255)
+	}
+	return fill
+}
+
+func pdfColor(c color.RGBA) string {
+	return fmt.Sprintf("%.3f %.3f %.3f", float64(c.R)/255, float64(c.G)/255, float64(c.B)/255)
+}
+
+// pdfImageData returns the zlib-compressed RGB samples and alpha mask of img.
+func pdfImageData(img image.Image) ([]byte, []byte) {
+	b := img.Bounds()
+	rgb := make([]byte, 0, b.Dx()*b.Dy()*3)
+	alpha := make([]byte, 0, b.Dx()*b.Dy())
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			rgb = append(rgb, c.R, c.G, c.B)
+			alpha = append(alpha, c.A)
+		}
+	}
+	return deflate(rgb), deflate(alpha)
+}
+
+func deflate(data []byte) []byte {
+	var buf bytes.Buffer
+	w := zlib.NewWriter(&buf)
+	w.Write(data)
+	w.Close()
+	return buf.Bytes()
+}
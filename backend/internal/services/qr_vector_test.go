@@ -0,0 +1,241 @@
+package services
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/xml"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+const vectorTestURL = "https://sl.ink/abc123?src=qr"
+
+func testLogo() image.Image {
+	logo := image.NewNRGBA(image.Rect(0, 0, 8, 4))
+	for i := range logo.Pix {
+		logo.Pix[i] = 0x80
+	}
+	logo.Set(0, 0, color.NRGBA{R: 0xff, A: 0xff})
+	return logo
+}
+
+func darkModules(matrix [][]bool) int {
+	n := 0
+	for _, row := range matrix {
+		for _, dark := range row {
+			if dark {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+var svgRun = regexp.MustCompile(`^M(\d+) (\d+)h(\d+)v1h-(\d+)z`)
+
+func TestGenerateSVG(t *testing.T) {
+	s := NewQRService(256, 64, 2048)
+	for _, withLogo := range []bool{false, true} {
+		t.Run(fmt.Sprintf("logo=%t", withLogo), func(t *testing.T) {
+			opts := s.DefaultOptions()
+			opts.Border = 4
+			if withLogo {
+				opts.Logo = testLogo()
+			}
+			matrix, err := s.Matrix(vectorTestURL, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, err := s.GenerateSVG(vectorTestURL, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var doc struct {
+				XMLName xml.Name `xml:"http://www.w3.org/2000/svg svg"`
+				ViewBox string   `xml:"viewBox,attr"`
+				Width   string   `xml:"width,attr"`
+				Paths   []struct {
+					D string `xml:"d,attr"`
+				} `xml:"path"`
+				Images []struct {
+					Href string `xml:"href,attr"`
+				} `xml:"image"`
+			}
+			if err := xml.Unmarshal(data, &doc); err != nil {
+				t.Fatalf("SVG is not well-formed: %v", err)
+			}
+
+			modules := len(matrix) + 2*opts.Border
+			if want := fmt.Sprintf("0 0 %d %d", modules, modules); doc.ViewBox != want {
+				t.Errorf("viewBox = %q, want %q", doc.ViewBox, want)
+			}
+			if doc.Width != "256" {
+				t.Errorf("width = %q, want 256", doc.Width)
+			}
+			if len(doc.Paths) != 1 {
+				t.Fatalf("%d paths, want 1", len(doc.Paths))
+			}
+
+			drawn := 0
+			for d := doc.Paths[0].D; d != ""; {
+				m := svgRun.FindStringSubmatch(d)
+				if m == nil {
+					t.Fatalf("unexpected path data at %.20q", d)
+				}
+				x, _ := strconv.Atoi(m[1])
+				y, _ := strconv.Atoi(m[2])
+				length, _ := strconv.Atoi(m[3])
+				if m[3] != m[4] || x < opts.Border || y < opts.Border || x+length > modules-opts.Border || y >= modules-opts.Border {
+					t.Errorf("run %q is outside the code", m[0])
+				}
+				drawn += length
+				d = d[len(m[0]):]
+			}
+			if want := darkModules(matrix); drawn != want {
+				t.Errorf("path draws %d modules, want %d", drawn, want)
+			}
+
+			if withLogo != (len(doc.Images) == 1) {
+				t.Errorf("%d images with logo=%t", len(doc.Images), withLogo)
+			}
+			if withLogo && !strings.HasPrefix(doc.Images[0].Href, "data:image/png;base64,") {
+				t.Errorf("logo href = %.40q", doc.Images[0].Href)
+			}
+		})
+	}
+}
+
+var pdfObject = regexp.MustCompile(`(?s)^(\d+) 0 obj\n(.*?)\nendobj\n`)
+
+func TestGeneratePDF(t *testing.T) {
+	s := NewQRService(256, 64, 2048)
+	for _, withLogo := range []bool{false, true} {
+		t.Run(fmt.Sprintf("logo=%t", withLogo), func(t *testing.T) {
+			opts := s.DefaultOptions()
+			if withLogo {
+				opts.Logo = testLogo()
+			}
+			data, err := s.GeneratePDF(vectorTestURL, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.HasPrefix(data, []byte("%PDF-1.4\n")) || !bytes.HasSuffix(data, []byte("%%EOF\n")) {
+				t.Fatal("missing PDF header or trailer")
+			}
+
+			want := 5
+			if withLogo {
+				want = 7
+			}
+			objects := checkPDFStructure(t, data, want)
+
+			page := objects[3]
+			if !strings.Contains(page, "/MediaBox [0 0 256.000 256.000]") {
+				t.Errorf("page = %q", page)
+			}
+			if withLogo {
+				if !strings.Contains(objects[5], "/XObject << /Logo 6 0 R >>") || !strings.Contains(objects[6], "/SMask 7 0 R") {
+					t.Errorf("logo is not wired up: resources %q, image %.80q", objects[5], objects[6])
+				}
+				if !strings.Contains(objects[4], "/Logo Do") {
+					t.Error("content stream does not draw the logo")
+				}
+				b := opts.Logo.Bounds()
+				if n := len(inflate(t, pdfStream(t, objects[6]))); n != b.Dx()*b.Dy()*3 {
+					t.Errorf("logo has %d RGB bytes, want %d", n, b.Dx()*b.Dy()*3)
+				}
+				if n := len(inflate(t, pdfStream(t, objects[7]))); n != b.Dx()*b.Dy() {
+					t.Errorf("mask has %d bytes, want %d", n, b.Dx()*b.Dy())
+				}
+			}
+		})
+	}
+}
+
+// checkPDFStructure checks that the xref table and trailer point at each of
+// the want objects and that every stream is as long as its /Length says. It
+// returns the objects' bodies by number.
+func checkPDFStructure(t *testing.T, data []byte, want int) map[int]string {
+	t.Helper()
+
+	var xref int
+	tail := data[bytes.LastIndex(data, []byte("startxref\n")):]
+	if _, err := fmt.Sscanf(string(tail), "startxref\n%d\n%%%%EOF", &xref); err != nil {
+		t.Fatalf("startxref: %v", err)
+	}
+	if xref >= len(data) || !bytes.HasPrefix(data[xref:], []byte("xref\n")) {
+		t.Fatalf("startxref %d does not point at the xref table", xref)
+	}
+
+	lines := strings.Split(string(data[xref:]), "\n")
+	if lines[1] != fmt.Sprintf("0 %d", want+1) || lines[2] != "0000000000 65535 f " {
+		t.Fatalf("xref header = %q", lines[1:3])
+	}
+	objects := map[int]string{}
+	for i := 1; i <= want; i++ {
+		var offset int
+		if _, err := fmt.Sscanf(lines[2+i], "%010d 00000 n ", &offset); err != nil {
+			t.Fatalf("xref entry %d %q: %v", i, lines[2+i], err)
+		}
+		if len(lines[2+i]) != 19 {
+			t.Errorf("xref entry %d is %d bytes, want 20 with the newline", i, len(lines[2+i])+1)
+		}
+		m := pdfObject.FindSubmatch(data[offset:])
+		if m == nil || string(m[1]) != strconv.Itoa(i) {
+			t.Fatalf("xref entry %d points at %.20q", i, data[offset:])
+		}
+		objects[i] = string(m[2])
+	}
+	if lines[3+want] != "trailer" || lines[4+want] != fmt.Sprintf("<< /Size %d /Root 1 0 R >>", want+1) {
+		t.Errorf("trailer = %q", lines[3+want:5+want])
+	}
+	if !strings.Contains(objects[1], "/Type /Catalog") {
+		t.Errorf("root object = %q", objects[1])
+	}
+
+	for i, obj := range objects {
+		if strings.Contains(obj, "stream\n") {
+			pdfStream(t, obj)
+		} else if strings.Contains(obj, "/Length") {
+			t.Errorf("object %d has a /Length but no stream", i)
+		}
+	}
+	return objects
+}
+
+var pdfLength = regexp.MustCompile(`/Length (\d+) >>\nstream\n`)
+
+// pdfStream returns the stream of obj, failing if it isn't /Length bytes.
+func pdfStream(t *testing.T, obj string) []byte {
+	t.Helper()
+	m := pdfLength.FindStringSubmatchIndex(obj)
+	if m == nil {
+		t.Fatalf("no stream in %.60q", obj)
+	}
+	length, _ := strconv.Atoi(obj[m[2]:m[3]])
+	stream := obj[m[1]:]
+	if len(stream) < length || strings.TrimPrefix(stream[length:], "\n") != "endstream" {
+		t.Fatalf("stream is not %d bytes long", length)
+	}
+	return []byte(stream[:length])
+}
+
+func inflate(t *testing.T, data []byte) []byte {
+	t.Helper()
+	r, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("stream is not zlib data: %v", err)
+	}
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("stream is not zlib data: %v", err)
+	}
+	return out
+}
@@ -1,10 +1,28 @@
 package services
 
-import "slink-backend/internal/models"
+import (
+	"context"
+
+	"slink-backend/internal/models"
+)
 
 type URLServiceInterface interface {
-	CreateShortURL(originalURL string, customAlias *string, userID *string) (*models.URL, error)
-	GetURLByCode(code string) (*models.URL, error)
-	IncrementHitCount(shortCode string) error
-	GetLinksByUser(userID string) ([]models.URL, error)
+	CreateShortURL(ctx context.Context, req models.ShortenRequest, userID *string) (*models.URL, error)
+	GetURLByCode(ctx context.Context, code string) (*models.URL, error)
+	IncrementHitCount(ctx context.Context, shortCode string) error
+	RecordQRScan(ctx context.Context, shortCode, variant string) error
+	GetLinkStats(ctx context.Context, url *models.URL) (*models.LinkStats, error)
+	GetLinksByUser(ctx context.Context, userID string) ([]models.URL, error)
+	FindLinkByDestination(ctx context.Context, userID, originalURL string) (*models.URL, error)
+	GetURLByID(ctx context.Context, id string) (*models.URL, error)
+	SearchLinks(ctx context.Context, query, userID string, limit, offset int) ([]models.URL, error)
+	TakeDownLink(ctx context.Context, id, reason string) (*models.URL, error)
+	RestoreLink(ctx context.Context, id string) (*models.URL, error)
+	GetOwnedURL(ctx context.Context, id, userID string) (*models.URL, error)
+	UpdateLink(ctx context.Context, id, userID string, req models.UpdateLinkRequest) (*models.URL, error)
+	SetLinkMetadata(ctx context.Context, id string, meta *models.LinkMetadata) error
+	DeleteLink(ctx context.Context, id, userID string) (*models.URL, error)
+	RemoveLink(ctx context.Context, id string) (*models.URL, error)
+	SuggestAliases(ctx context.Context, customAlias, userID string, limit int) (*models.AliasSuggestion, error)
+	FilterLinksByUser(ctx context.Context, userID string, ids []string, tag string, limit int) ([]models.URL, error)
 }
@@ -2,86 +2,166 @@ package services
 
 import (
 	"context"
-	cryptoRand "crypto/rand"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"strings"
 	"time"
+	"unicode/utf8"
 
+	"slink-backend/internal/alias"
 	"slink-backend/internal/database"
+	"slink-backend/internal/metrics"
 	"slink-backend/internal/models"
+	"slink-backend/internal/shortcode"
 	"slink-backend/internal/utils"
 
 	"github.com/google/uuid"
+	"github.com/lengzuo/supa/utils/enum"
 )
 
 type URLRecord struct {
-	ID          string    `json:"id"`
-	OriginalURL string    `json:"original_url"`
-	ShortCode   string    `json:"short_code"`
-	CustomAlias *string   `json:"custom_alias,omitempty"`
-	UserID      *string   `json:"user_id,omitempty"`
-	HitCount    int       `json:"hit_count"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID              string     `json:"id"`
+	OriginalURL     string     `json:"original_url"`
+	NormalizedURL   string     `json:"normalized_url,omitempty"`
+	ShortCode       string     `json:"short_code"`
+	CustomAlias     *string    `json:"custom_alias,omitempty"`
+	UserID          *string    `json:"user_id,omitempty"`
+	HitCount        int        `json:"hit_count"`
+	QRScanCount     int        `json:"qr_scan_count"`
+	Tags            []string   `json:"tags"`
+	TakenDownAt     *time.Time `json:"taken_down_at,omitempty"`
+	TakedownReason  *string    `json:"takedown_reason,omitempty"`
+	MetaTitle       *string    `json:"meta_title,omitempty"`
+	MetaDescription *string    `json:"meta_description,omitempty"`
+	MetaImage       *string    `json:"meta_image,omitempty"`
+	MetaFetchedAt   *time.Time `json:"meta_fetched_at,omitempty"`
+	OGTitle         *string    `json:"og_title,omitempty"`
+	OGDescription   *string    `json:"og_description,omitempty"`
+	OGImage         *string    `json:"og_image,omitempty"`
+	ExpiresAt       *time.Time `json:"expires_at,omitempty"`
+	HideReferrer    bool       `json:"hide_referrer"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
 }
 
+func (r URLRecord) toModel() *models.URL {
+	url := &models.URL{
+		ID:             r.ID,
+		OriginalURL:    r.OriginalURL,
+		ShortCode:      r.ShortCode,
+		CustomAlias:    r.CustomAlias,
+		UserID:         r.UserID,
+		HitCount:       r.HitCount,
+		QRScanCount:    r.QRScanCount,
+		Tags:           r.Tags,
+		TakenDownAt:    r.TakenDownAt,
+		TakedownReason: r.TakedownReason,
+		OGTitle:        r.OGTitle,
+		OGDescription:  r.OGDescription,
+		OGImage:        r.OGImage,
+		ExpiresAt:      r.ExpiresAt,
+		HideReferrer:   r.HideReferrer,
+		CreatedAt:      r.CreatedAt,
+		UpdatedAt:      r.UpdatedAt,
+	}
+	if r.MetaFetchedAt != nil {
+		url.Metadata = &models.LinkMetadata{
+			Title:       deref(r.MetaTitle),
+			Description: deref(r.MetaDescription),
+			Image:       deref(r.MetaImage),
+			FetchedAt:   r.MetaFetchedAt,
+		}
+	}
+	return url
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func toModels(records []URLRecord) []models.URL {
+	urls := make([]models.URL, len(records))
+	for i, record := range records {
+		urls[i] = *record.toModel()
+	}
+	return urls
+}
+
+const (
+	maxOGTitleLength       = 200
+	maxOGDescriptionLength = 500
+)
+
+var (
+	errLinkNotFound = NotFound("link_not_found", "URL not found")
+	errInvalidURL   = InvalidField("original_url", "invalid_url", "invalid URL")
+	errAliasTaken   = Conflict("alias_taken", "custom alias already exists")
+)
+
+// maxInsertAttempts bounds how often CreateShortURL picks a new generated
+// code after losing a race for one.
+const maxInsertAttempts = 3
+
 type URLServiceSupa struct {
 	supabase *database.SupabaseClient
+	codes    shortcode.Generator
+	aliases  *alias.Policy
 }
 
-func NewURLServiceSupa(supabaseClient *database.SupabaseClient) *URLServiceSupa {
-	return &URLServiceSupa{supabase: supabaseClient}
+func NewURLServiceSupa(supabaseClient *database.SupabaseClient, codes shortcode.Generator, aliases *alias.Policy) *URLServiceSupa {
+	return &URLServiceSupa{supabase: supabaseClient, codes: codes, aliases: aliases}
 }
 
-func (s *URLServiceSupa) CreateShortURL(originalURL string, customAlias *string, userID *string) (*models.URL, error) {
+func (s *URLServiceSupa) CreateShortURL(ctx context.Context, req models.ShortenRequest, userID *string) (*models.URL, error) {
+	originalURL, customAlias := req.OriginalURL, req.CustomAlias
 	if !utils.IsValidURL(originalURL) {
-		return nil, fmt.Errorf("invalid URL")
+		return nil, errInvalidURL
 	}
 
-	if customAlias != nil {
-		client := s.supabase.GetClient()
-		var results []URLRecord
+	tags, err := utils.NormalizeTags(req.Tags)
+	if err != nil {
+		return nil, InvalidField("tags", "invalid_tags", err.Error())
+	}
 
-		err := client.DB.From("urls").Select("id").Eq("custom_alias", *customAlias).Execute(context.Background(), &results)
-		if err != nil {
-			return nil, err
-		}
-		if len(results) > 0 {
-			return nil, fmt.Errorf("custom alias already exists")
-		}
+	normalizedURL, err := utils.NormalizeURL(originalURL)
+	if err != nil {
+		return nil, errInvalidURL
+	}
 
-		results = []URLRecord{}
-		err = client.DB.From("urls").Select("id").Eq("short_code", *customAlias).Execute(context.Background(), &results)
-		if err != nil {
-			return nil, err
-		}
-		if len(results) > 0 {
-			return nil, fmt.Errorf("custom alias already exists")
-		}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, InvalidField("expires_at", "invalid_expiry", "expires_at must be in the future")
 	}
 
 	var shortCode string
 	if customAlias != nil {
-		if !utils.IsValidCustomAlias(*customAlias) {
-			return nil, fmt.Errorf("invalid custom alias")
+		if err := s.checkAlias(ctx, *customAlias, userID); err != nil {
+			return nil, err
 		}
 		shortCode = *customAlias
 	} else {
 		var err error
-		shortCode, err = s.generateUniqueShortCode()
+		shortCode, err = s.generateUniqueShortCode(ctx, originalURL)
 		if err != nil {
 			return nil, err
 		}
 	}
 
 	urlRecord := URLRecord{
-		ID:          uuid.New().String(),
-		OriginalURL: originalURL,
-		ShortCode:   shortCode,
-		UserID:      userID,
-		HitCount:    0,
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
+		ID:            uuid.New().String(),
+		OriginalURL:   originalURL,
+		NormalizedURL: normalizedURL,
+		ShortCode:     shortCode,
+		UserID:        userID,
+		HitCount:      0,
+		Tags:          tags,
+		ExpiresAt:     req.ExpiresAt,
+		HideReferrer:  req.HideReferrer,
+		CreatedAt:     time.Now(),
+		UpdatedAt:     time.Now(),
 	}
 
 	if customAlias != nil {
@@ -90,7 +170,22 @@ func (s *URLServiceSupa) CreateShortURL(originalURL string, customAlias *string,
 
 	client := s.supabase.GetClient()
 	var result URLRecord
-	err := client.DB.From("urls").Insert(urlRecord).Execute(context.Background(), &result)
+	for attempt := 1; ; attempt++ {
+		err = database.Execute(ctx, "insert", "urls", client.DB.From("urls").Insert(urlRecord), &result)
+		if !database.IsUniqueViolation(err) {
+			break
+		}
+		// Another request claimed the code between the check and the insert.
+		if customAlias != nil {
+			return nil, errAliasTaken
+		}
+		if attempt == maxInsertAttempts {
+			return nil, Conflict("short_code_taken", "failed to claim a free short code, try again")
+		}
+		if urlRecord.ShortCode, err = s.generateUniqueShortCode(ctx, originalURL); err != nil {
+			return nil, err
+		}
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -99,65 +194,48 @@ func (s *URLServiceSupa) CreateShortURL(originalURL string, customAlias *string,
 		return nil, fmt.Errorf("failed to create URL")
 	}
 
-	return &models.URL{
-		ID:          result.ID,
-		OriginalURL: result.OriginalURL,
-		ShortCode:   result.ShortCode,
-		CustomAlias: result.CustomAlias,
-		HitCount:    result.HitCount,
-		CreatedAt:   result.CreatedAt,
-		UpdatedAt:   result.UpdatedAt,
-	}, nil
+	return result.toModel(), nil
 }
 
-func (s *URLServiceSupa) GetURLByCode(code string) (*models.URL, error) {
+func (s *URLServiceSupa) GetURLByCode(ctx context.Context, code string) (*models.URL, error) {
 	client := s.supabase.GetClient()
 	var results []URLRecord
 
 	// Query by short_code first
-	err := client.DB.From("urls").Select("*").Eq("short_code", code).Execute(context.Background(), &results)
+	err := database.Execute(ctx, "select", "urls", client.DB.From("urls").Select("*").Eq("short_code", code), &results)
 	if err != nil {
 		return nil, err
 	}
 
 	// If not found by short_code, try custom_alias
 	if len(results) == 0 {
-		err = client.DB.From("urls").Select("*").Eq("custom_alias", code).Execute(context.Background(), &results)
+		err = database.Execute(ctx, "select", "urls", client.DB.From("urls").Select("*").Eq("custom_alias", code), &results)
 		if err != nil {
 			return nil, err
 		}
 	}
 
 	if len(results) == 0 {
-		return nil, fmt.Errorf("URL not found")
-	}
-
-	// Convert to models.URL
-	return &models.URL{
-		ID:          results[0].ID,
-		OriginalURL: results[0].OriginalURL,
-		ShortCode:   results[0].ShortCode,
-		CustomAlias: results[0].CustomAlias,
-		HitCount:    results[0].HitCount,
-		CreatedAt:   results[0].CreatedAt,
-		UpdatedAt:   results[0].UpdatedAt,
-	}, nil
+		return nil, errLinkNotFound
+	}
+
+	return results[0].toModel(), nil
 }
 
-func (s *URLServiceSupa) IncrementHitCount(shortCode string) error {
+func (s *URLServiceSupa) IncrementHitCount(ctx context.Context, shortCode string) error {
 	client := s.supabase.GetClient()
 
 	// Get current hit count
 	var results []struct {
 		HitCount int `json:"hit_count"`
 	}
-	err := client.DB.From("urls").Select("hit_count").Eq("short_code", shortCode).Execute(context.Background(), &results)
+	err := database.Execute(ctx, "select", "urls", client.DB.From("urls").Select("hit_count").Eq("short_code", shortCode), &results)
 	if err != nil {
 		return err
 	}
 
 	if len(results) == 0 {
-		return fmt.Errorf("URL not found")
+		return errLinkNotFound
 	}
 
 	// Update hit count
@@ -170,53 +248,116 @@ func (s *URLServiceSupa) IncrementHitCount(shortCode string) error {
 		UpdatedAt: time.Now(),
 	}
 
-	err = client.DB.From("urls").Update(updateData).Eq("short_code", shortCode).Execute(context.Background(), nil)
+	err = database.Execute(ctx, "update", "urls", client.DB.From("urls").Update(updateData).Eq("short_code", shortCode), nil)
 
 	return err
 }
 
-func (s *URLServiceSupa) GetLinksByUser(userID string) ([]models.URL, error) {
+// RecordQRScan counts a visit that came from a QR code, attributing it to
+// variant when one is given. The visit also counts towards hit_count.
+func (s *URLServiceSupa) RecordQRScan(ctx context.Context, shortCode, variant string) error {
 	client := s.supabase.GetClient()
-	var results []URLRecord
 
-	err := client.DB.From("urls").Select("*").Eq("user_id", userID).Execute(context.Background(), &results)
+	params := map[string]interface{}{
+		"p_short_code": shortCode,
+		"p_variant":    variant,
+	}
+	if err := database.Execute(ctx, "rpc", "record_qr_scan", client.DB.RPC("record_qr_scan", params), nil); err != nil {
+		return fmt.Errorf("failed to record QR scan: %v", err)
+	}
+	return nil
+}
+
+// GetLinkStats returns visit statistics for url.
+func (s *URLServiceSupa) GetLinkStats(ctx context.Context, url *models.URL) (*models.LinkStats, error) {
+	client := s.supabase.GetClient()
+	variants := []models.QRVariantStats{}
+
+	err := database.Execute(ctx, "select", "qr_scan_variants", client.DB.From("qr_scan_variants").Select("variant,scans,last_scanned_at").Order("scans", enum.OrderDesc).Eq("url_id", url.ID), &variants)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get QR variants: %v", err)
+	}
+
+	return &models.LinkStats{
+		LinkID:     url.ID,
+		ShortCode:  url.ShortCode,
+		TotalHits:  url.HitCount,
+		LinkClicks: url.HitCount - url.QRScanCount,
+		QRScans:    url.QRScanCount,
+		QRVariants: variants,
+	}, nil
+}
+
+// FindLinkByDestination returns userID's oldest working link whose
+// destination normalizes to the same URL as originalURL.
+func (s *URLServiceSupa) FindLinkByDestination(ctx context.Context, userID, originalURL string) (*models.URL, error) {
+	normalizedURL, err := utils.NormalizeURL(originalURL)
 	if err != nil {
 		return nil, err
 	}
 
-	// Convert to models.URL slice
-	urls := make([]models.URL, len(results))
-	for i, result := range results {
-		urls[i] = models.URL{
-			ID:          result.ID,
-			OriginalURL: result.OriginalURL,
-			ShortCode:   result.ShortCode,
-			CustomAlias: result.CustomAlias,
-			HitCount:    result.HitCount,
-			CreatedAt:   result.CreatedAt,
-			UpdatedAt:   result.UpdatedAt,
+	client := s.supabase.GetClient()
+	var results []URLRecord
+
+	req := client.DB.From("urls").Select("*").Order("created_at", enum.OrderAsc)
+	req.Eq("user_id", userID)
+	req.Eq("normalized_url", normalizedURL)
+	req.Is("taken_down_at", "null")
+
+	if err := database.Execute(ctx, "select", "urls", req, &results); err != nil {
+		return nil, err
+	}
+
+	for _, record := range results {
+		if url := record.toModel(); !url.Expired() {
+			return url, nil
 		}
 	}
+	return nil, errLinkNotFound
+}
 
-	return urls, nil
+func (s *URLServiceSupa) GetLinksByUser(ctx context.Context, userID string) ([]models.URL, error) {
+	client := s.supabase.GetClient()
+	var results []URLRecord
+
+	err := database.Execute(ctx, "select", "urls", client.DB.From("urls").Select("*").Eq("user_id", userID), &results)
+	if err != nil {
+		return nil, err
+	}
+
+	return toModels(results), nil
 }
 
-func (s *URLServiceSupa) generateUniqueShortCode() (string, error) {
+// generateUniqueShortCode asks the configured generator for codes until one
+// is free, reporting each outcome so adaptive generators can grow. Codes the
+// alias policy reserves are skipped like taken ones, so a generated code
+// never shadows a route; sequence-based generators move on to the next value.
+func (s *URLServiceSupa) generateUniqueShortCode(ctx context.Context, originalURL string) (string, error) {
 	const maxAttempts = 10
+	observer, _ := s.codes.(shortcode.Observer)
 
 	for i := 0; i < maxAttempts; i++ {
-		code := generateRandomCodeSupa()
+		code, err := s.codes.Generate(ctx, originalURL, i)
+		if err != nil {
+			return "", err
+		}
+		if err := s.aliases.CheckGenerated(code); err != nil {
+			continue
+		}
 
-		// Check if code exists
-		client := s.supabase.GetClient()
-		var results []URLRecord
-		err := client.DB.From("urls").Select("id").Eq("short_code", code).Execute(context.Background(), &results)
+		taken, err := s.codeTaken(ctx, code)
 		if err != nil {
-			log.Printf("Error checking short code existence: %v", err)
+			slog.Error("Failed to check short code existence", "error", err)
 			continue
 		}
+		if observer != nil {
+			observer.Observe(taken)
+		}
+		if taken {
+			metrics.ShortCodeCollisions.Inc()
+		}
 
-		if len(results) == 0 {
+		if !taken {
 			return code, nil
 		}
 	}
@@ -224,15 +365,415 @@ func (s *URLServiceSupa) generateUniqueShortCode() (string, error) {
 	return "", fmt.Errorf("failed to generate unique short code after %d attempts", maxAttempts)
 }
 
-func generateRandomCodeSupa() string {
-	const charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
-	const length = 6
+func (s *URLServiceSupa) GetURLByID(ctx context.Context, id string) (*models.URL, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, errLinkNotFound
+	}
+
+	client := s.supabase.GetClient()
+	var results []URLRecord
+
+	err := database.Execute(ctx, "select", "urls", client.DB.From("urls").Select("*").Eq("id", id), &results)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(results) == 0 {
+		return nil, errLinkNotFound
+	}
+
+	return results[0].toModel(), nil
+}
+
+// SearchLinks lists links across all users, newest first. query matches the
+// destination URL case-insensitively; userID restricts to one owner.
+func (s *URLServiceSupa) SearchLinks(ctx context.Context, query, userID string, limit, offset int) ([]models.URL, error) {
+	client := s.supabase.GetClient()
+	var results []URLRecord
 
-	b := make([]byte, length)
-	cryptoRand.Read(b)
-	for i := range b {
-		b[i] = charset[b[i]%byte(len(charset))]
+	req := client.DB.From("urls").Select("*").Order("created_at", enum.OrderDesc).Limit(limit).Offset(offset)
+	if query != "" {
+		req.Ilike("original_url", "*"+query+"*")
+	}
+	if userID != "" {
+		req.Eq("user_id", userID)
+	}
+
+	if err := database.Execute(ctx, "select", "urls", req, &results); err != nil {
+		return nil, err
+	}
+
+	return toModels(results), nil
+}
+
+// TakeDownLink disables redirects for a link; visitors see reason instead.
+func (s *URLServiceSupa) TakeDownLink(ctx context.Context, id, reason string) (*models.URL, error) {
+	now := time.Now()
+	updateData := map[string]interface{}{
+		"taken_down_at":   now,
+		"takedown_reason": reason,
+		"updated_at":      now,
+	}
+	return s.updateLink(ctx, id, updateData)
+}
+
+func (s *URLServiceSupa) RestoreLink(ctx context.Context, id string) (*models.URL, error) {
+	updateData := map[string]interface{}{
+		"taken_down_at":   nil,
+		"takedown_reason": nil,
+		"updated_at":      time.Now(),
+	}
+	return s.updateLink(ctx, id, updateData)
+}
+
+func (s *URLServiceSupa) updateLink(ctx context.Context, id string, updateData map[string]interface{}) (*models.URL, error) {
+	client := s.supabase.GetClient()
+	var results []URLRecord
+
+	err := database.Execute(ctx, "update", "urls", client.DB.From("urls").Update(updateData).Eq("id", id), &results)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(results) == 0 {
+		return nil, errLinkNotFound
+	}
+
+	return results[0].toModel(), nil
+}
+
+// GetOwnedURL returns the link with id if it belongs to userID.
+func (s *URLServiceSupa) GetOwnedURL(ctx context.Context, id, userID string) (*models.URL, error) {
+	url, err := s.GetURLByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if url.UserID == nil || *url.UserID != userID {
+		return nil, errLinkNotFound
+	}
+	return url, nil
+}
+
+// UpdateLink changes the destination and/or custom alias of a link owned by
+// userID. Setting an alias also makes it the link's short code.
+func (s *URLServiceSupa) UpdateLink(ctx context.Context, id, userID string, req models.UpdateLinkRequest) (*models.URL, error) {
+	url, err := s.GetOwnedURL(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	updateData := map[string]interface{}{}
+
+	if req.OriginalURL != nil && *req.OriginalURL != url.OriginalURL {
+		if !utils.IsValidURL(*req.OriginalURL) {
+			return nil, errInvalidURL
+		}
+		normalizedURL, err := utils.NormalizeURL(*req.OriginalURL)
+		if err != nil {
+			return nil, errInvalidURL
+		}
+		updateData["original_url"] = *req.OriginalURL
+		updateData["normalized_url"] = normalizedURL
+	}
+
+	if req.CustomAlias != nil {
+		alias := *req.CustomAlias
+		switch {
+		case alias == "":
+			updateData["custom_alias"] = nil
+		case url.CustomAlias != nil && strings.EqualFold(*url.CustomAlias, alias):
+			// Only the case changes, so the alias can't collide with
+			// another link's.
+			if *url.CustomAlias != alias {
+				if err := s.aliases.Check(alias, s.userPlan(ctx, &userID)); err != nil {
+					return nil, aliasError(err)
+				}
+				updateData["custom_alias"] = alias
+				updateData["short_code"] = alias
+			}
+		default:
+			if err := s.checkAlias(ctx, alias, &userID); err != nil {
+				return nil, err
+			}
+			updateData["custom_alias"] = alias
+			updateData["short_code"] = alias
+		}
+	}
+
+	if req.Tags != nil {
+		tags, err := utils.NormalizeTags(*req.Tags)
+		if err != nil {
+			return nil, InvalidField("tags", "invalid_tags", err.Error())
+		}
+		updateData["tags"] = tags
+	}
+
+	if req.ExpiresAt != nil {
+		if *req.ExpiresAt == "" {
+			updateData["expires_at"] = nil
+		} else {
+			expiresAt, err := time.Parse(time.RFC3339, *req.ExpiresAt)
+			if err != nil {
+				return nil, InvalidField("expires_at", "invalid_expiry", "expires_at must be an RFC 3339 timestamp")
+			}
+			if !expiresAt.After(time.Now()) {
+				return nil, InvalidField("expires_at", "invalid_expiry", "expires_at must be in the future")
+			}
+			updateData["expires_at"] = expiresAt
+		}
+		// A new expiry is a new chance to fire link.expired.
+		updateData["expired_event_at"] = nil
+	}
+
+	if req.HideReferrer != nil {
+		updateData["hide_referrer"] = *req.HideReferrer
+	}
+
+	ogFields := []struct {
+		column string
+		value  *string
+		limit  int
+	}{
+		{"og_title", req.OGTitle, maxOGTitleLength},
+		{"og_description", req.OGDescription, maxOGDescriptionLength},
+		{"og_image", req.OGImage, 0},
+	}
+	for _, field := range ogFields {
+		if field.value == nil {
+			continue
+		}
+		value := strings.TrimSpace(*field.value)
+		switch {
+		case value == "":
+			updateData[field.column] = nil
+		case field.limit == 0 && !utils.IsValidURL(value):
+			return nil, InvalidField(field.column, "invalid_url", "invalid og_image URL")
+		case field.limit > 0 && utf8.RuneCountInString(value) > field.limit:
+			return nil, InvalidField(field.column, "too_long", fmt.Sprintf("%s must be at most %d characters", field.column, field.limit))
+		default:
+			updateData[field.column] = value
+		}
+	}
+
+	if len(updateData) == 0 {
+		return url, nil
+	}
+
+	updateData["updated_at"] = time.Now()
+	updated, err := s.updateLink(ctx, id, updateData)
+	if database.IsUniqueViolation(err) {
+		return nil, errAliasTaken
+	}
+	return updated, err
+}
+
+// SetLinkMetadata stores what was fetched from the link's destination.
+func (s *URLServiceSupa) SetLinkMetadata(ctx context.Context, id string, meta *models.LinkMetadata) error {
+	updateData := map[string]interface{}{
+		"meta_title":       nullIfEmpty(meta.Title),
+		"meta_description": nullIfEmpty(meta.Description),
+		"meta_image":       nullIfEmpty(meta.Image),
+		"meta_fetched_at":  time.Now(),
+	}
+
+	client := s.supabase.GetClient()
+	if err := database.Execute(ctx, "update", "urls", client.DB.From("urls").Update(updateData).Eq("id", id), nil); err != nil {
+		return fmt.Errorf("failed to store link metadata: %v", err)
+	}
+	return nil
+}
+
+func nullIfEmpty(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+func (s *URLServiceSupa) DeleteLink(ctx context.Context, id, userID string) (*models.URL, error) {
+	url, err := s.GetOwnedURL(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.removeLink(ctx, id); err != nil {
+		return nil, err
+	}
+	return url, nil
+}
+
+// RemoveLink deletes a link whoever owns it, anonymous links included.
+func (s *URLServiceSupa) RemoveLink(ctx context.Context, id string) (*models.URL, error) {
+	url, err := s.GetURLByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.removeLink(ctx, id); err != nil {
+		return nil, err
+	}
+	return url, nil
+}
+
+func (s *URLServiceSupa) removeLink(ctx context.Context, id string) error {
+	client := s.supabase.GetClient()
+	return database.Execute(ctx, "delete", "urls", client.DB.From("urls").Delete().Eq("id", id), nil)
+}
+
+// codeTaken reports whether code is in use as a short code or custom alias.
+func (s *URLServiceSupa) codeTaken(ctx context.Context, code string) (bool, error) {
+	client := s.supabase.GetClient()
+
+	for _, column := range []string{"custom_alias", "short_code"} {
+		var results []URLRecord
+		err := database.Execute(ctx, "select", "urls", client.DB.From("urls").Select("id").Eq(column, code), &results)
+		if err != nil {
+			return false, err
+		}
+		if len(results) > 0 {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// aliasTaken reports whether alias is in use as a short code or custom
+// alias, ignoring case.
+func (s *URLServiceSupa) aliasTaken(ctx context.Context, alias string) (bool, error) {
+	client := s.supabase.GetClient()
+
+	// Aliases only contain letters, digits and hyphens, so they have no
+	// wildcards to escape.
+	for _, column := range []string{"custom_alias", "short_code"} {
+		var results []URLRecord
+		err := database.Execute(ctx, "select", "urls", client.DB.From("urls").Select("id").Ilike(column, alias), &results)
+		if err != nil {
+			return false, err
+		}
+		if len(results) > 0 {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// userPlan returns the plan whose alias limits apply to userID.
+func (s *URLServiceSupa) userPlan(ctx context.Context, userID *string) string {
+	if userID == nil {
+		return alias.DefaultPlan
+	}
+	user, err := s.supabase.GetUserByID(ctx, *userID)
+	if err != nil || user.Plan == "" {
+		return alias.DefaultPlan
+	}
+	return user.Plan
+}
+
+// checkAlias applies the alias policy for userID's plan and makes sure the
+// alias is free.
+func (s *URLServiceSupa) checkAlias(ctx context.Context, customAlias string, userID *string) error {
+	if err := s.aliases.Check(customAlias, s.userPlan(ctx, userID)); err != nil {
+		return aliasError(err)
+	}
+	taken, err := s.aliasTaken(ctx, customAlias)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return errAliasTaken
+	}
+	return nil
+}
+
+// aliasError converts an alias policy violation into an API error.
+func aliasError(err error) error {
+	code := "invalid_alias"
+	var lengthErr *alias.LengthError
+	switch {
+	case errors.As(err, &lengthErr):
+		code = "alias_length"
+	case errors.Is(err, alias.ErrReserved):
+		code = "alias_reserved"
+	case errors.Is(err, alias.ErrBlocked):
+		code = "alias_blocked"
+	}
+	return InvalidField("custom_alias", code, err.Error())
+}
+
+// SuggestAliases reports whether userID may claim customAlias and, if not,
+// up to limit available alternatives.
+func (s *URLServiceSupa) SuggestAliases(ctx context.Context, customAlias, userID string, limit int) (*models.AliasSuggestion, error) {
+	plan := s.userPlan(ctx, &userID)
+	limits := s.aliases.Limits(plan)
+	suggestion := &models.AliasSuggestion{
+		Alias:       customAlias,
+		MinLength:   limits.Min,
+		MaxLength:   limits.Max,
+		Suggestions: []string{},
+	}
+
+	if err := s.aliases.Check(customAlias, plan); err != nil {
+		suggestion.Reason = err.Error()
+		// Blocked or malformed aliases get no help finding a variant.
+		if !errors.Is(err, alias.ErrReserved) {
+			return suggestion, nil
+		}
+	} else {
+		taken, err := s.aliasTaken(ctx, customAlias)
+		if err != nil {
+			return nil, err
+		}
+		if !taken {
+			suggestion.Available = true
+			return suggestion, nil
+		}
+		suggestion.Reason = errAliasTaken.Message
+	}
+
+	for _, candidate := range s.aliases.Candidates(customAlias, plan) {
+		taken, err := s.aliasTaken(ctx, candidate)
+		if err != nil {
+			return nil, err
+		}
+		if taken {
+			continue
+		}
+		suggestion.Suggestions = append(suggestion.Suggestions, candidate)
+		if len(suggestion.Suggestions) == limit {
+			break
+		}
+	}
+
+	return suggestion, nil
+}
+
+// FilterLinksByUser returns up to limit of userID's links that are among ids
+// (when given) and carry tag (when given).
+func (s *URLServiceSupa) FilterLinksByUser(ctx context.Context, userID string, ids []string, tag string, limit int) ([]models.URL, error) {
+	for _, id := range ids {
+		if _, err := uuid.Parse(id); err != nil {
+			return nil, InvalidField("link_ids", "invalid_link_id", fmt.Sprintf("invalid link ID %q", id))
+		}
+	}
+	if tag != "" && !utils.IsValidTag(tag) {
+		return nil, InvalidField("tag", "invalid_tag", fmt.Sprintf("invalid tag %q", tag))
+	}
+
+	client := s.supabase.GetClient()
+	var results []URLRecord
+
+	req := client.DB.From("urls").Select("*").Order("created_at", enum.OrderAsc).Limit(limit)
+	req.Eq("user_id", userID)
+	if len(ids) > 0 {
+		req.In("id", ids)
+	}
+	if tag != "" {
+		req.Cs("tags", []string{tag})
+	}
+
+	if err := database.Execute(ctx, "select", "urls", req, &results); err != nil {
+		return nil, err
 	}
 
-	return string(b)
+	return toModels(results), nil
 }
@@ -0,0 +1,151 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"slink-backend/internal/database"
+	"slink-backend/internal/models"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	DeletionPolicyDelete    = "delete"
+	DeletionPolicyAnonymize = "anonymize"
+)
+
+// UpdateProfile changes the user's name, email and/or link preferences. A
+// new email address must be verified again before it counts as verified.
+func (s *UserService) UpdateProfile(ctx context.Context, userID string, req models.UpdateProfileRequest) (*models.User, error) {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := map[string]interface{}{}
+
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			updates["name"] = nil
+			user.Name = nil
+		} else {
+			updates["name"] = name
+			user.Name = &name
+		}
+	}
+
+	if req.ReuseExistingLinks != nil && *req.ReuseExistingLinks != user.ReuseExistingLinks {
+		updates["reuse_existing_links"] = *req.ReuseExistingLinks
+		user.ReuseExistingLinks = *req.ReuseExistingLinks
+	}
+
+	emailChanged := false
+	if req.Email != nil && !strings.EqualFold(*req.Email, user.Email) {
+		_, err := s.GetUserByEmail(ctx, *req.Email)
+		if err == nil {
+			return nil, errEmailTaken
+		}
+		if !errors.Is(err, database.ErrNotFound) {
+			return nil, err
+		}
+
+		updates["email"] = *req.Email
+		updates["email_verified"] = false
+		updates["email_verified_at"] = nil
+		user.Email = *req.Email
+		user.EmailVerified = false
+		user.EmailVerifiedAt = nil
+		emailChanged = true
+	}
+
+	if len(updates) == 0 {
+		return user, nil
+	}
+
+	if err := s.db.UpdateUser(ctx, user.ID, updates); err != nil {
+		return nil, err
+	}
+
+	if emailChanged {
+		if err := s.SendVerificationEmail(ctx, user); err != nil {
+			slog.Error("Failed to send verification email", "user_id", user.ID, "error", err)
+		}
+	}
+
+	return user, nil
+}
+
+// ChangePassword replaces the password and revokes all existing tokens. The
+// returned user carries the new token version.
+func (s *UserService) ChangePassword(ctx context.Context, userID, currentPassword, newPassword string) (*models.User, error) {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(currentPassword)); err != nil {
+		return nil, wrongPassword("current_password")
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %v", err)
+	}
+
+	err = s.db.UpdateUser(ctx, user.ID, map[string]interface{}{
+		"password_hash": string(hashedPassword),
+		"token_version": user.TokenVersion + 1,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	user.PasswordHash = string(hashedPassword)
+	user.TokenVersion++
+	return user, nil
+}
+
+// DeleteAccount removes the user according to ACCOUNT_DELETION_POLICY:
+// "delete" removes the user and their links, "anonymize" scrubs personal
+// data from the user row and detaches the links so they keep working.
+func (s *UserService) DeleteAccount(ctx context.Context, userID, password string) error {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		return wrongPassword("password")
+	}
+
+	switch s.config.AccountDeletionPolicy {
+	case DeletionPolicyAnonymize:
+		if err := s.db.DisownURLsByUser(ctx, user.ID); err != nil {
+			return err
+		}
+		return s.db.UpdateUser(ctx, user.ID, map[string]interface{}{
+			"email":          fmt.Sprintf("deleted-%s@deleted.invalid", user.ID),
+			"name":           nil,
+			"password_hash":  "",
+			"email_verified": false,
+			"totp_secret":    nil,
+			"totp_enabled":   false,
+			"recovery_codes": []string{},
+			"token_version":  user.TokenVersion + 1,
+			"deleted_at":     time.Now(),
+		})
+	case DeletionPolicyDelete:
+		if err := s.db.DeleteURLsByUser(ctx, user.ID); err != nil {
+			return err
+		}
+		return s.db.DeleteUser(ctx, user.ID)
+	default:
+		return fmt.Errorf("unknown account deletion policy: %s", s.config.AccountDeletionPolicy)
+	}
+}
@@ -0,0 +1,75 @@
+package services
+
+import (
+	"context"
+	"slink-backend/internal/models"
+)
+
+func (s *UserService) ListUsers(ctx context.Context, query string, limit, offset int) ([]models.User, error) {
+	return s.db.ListUsers(ctx, query, limit, offset)
+}
+
+// SetDisabled blocks or unblocks a user. Disabling also revokes the user's
+// existing tokens.
+func (s *UserService) SetDisabled(ctx context.Context, userID string, disabled bool) (*models.User, error) {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if disabled && user.Role == models.RoleAdmin {
+		return nil, Forbidden("admin_not_disableable", "admins cannot be disabled")
+	}
+
+	updates := map[string]interface{}{"disabled": disabled}
+	if disabled {
+		updates["token_version"] = user.TokenVersion + 1
+		user.TokenVersion++
+	}
+
+	if err := s.db.UpdateUser(ctx, user.ID, updates); err != nil {
+		return nil, err
+	}
+
+	user.Disabled = disabled
+	return user, nil
+}
+
+// SetPlan moves a user to another plan, which decides their alias limits.
+func (s *UserService) SetPlan(ctx context.Context, userID, plan string) (*models.User, error) {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.db.UpdateUser(ctx, user.ID, map[string]interface{}{"plan": plan}); err != nil {
+		return nil, err
+	}
+
+	user.Plan = plan
+	return user, nil
+}
+
+// SetRole makes a user an admin or an ordinary user. Demoting revokes the
+// user's existing tokens so admin sessions don't outlive the role.
+func (s *UserService) SetRole(ctx context.Context, userID, role string) (*models.User, error) {
+	if role != models.RoleUser && role != models.RoleAdmin {
+		return nil, InvalidField("role", "invalid_role", "role must be user or admin")
+	}
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := map[string]interface{}{"role": role}
+	if user.Role == models.RoleAdmin && role != models.RoleAdmin {
+		updates["token_version"] = user.TokenVersion + 1
+		user.TokenVersion++
+	}
+
+	if err := s.db.UpdateUser(ctx, user.ID, updates); err != nil {
+		return nil, err
+	}
+
+	user.Role = role
+	return user, nil
+}
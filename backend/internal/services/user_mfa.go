@@ -0,0 +1,236 @@
+package services
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"time"
+
+	"slink-backend/internal/models"
+	"slink-backend/internal/utils"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	recoveryCodeCount = 10
+	mfaChallengeTTL   = 5 * time.Minute
+)
+
+var (
+	errMFAEnabled    = Conflict("mfa_already_enabled", "two-factor authentication already enabled")
+	errMFANotEnabled = Conflict("mfa_not_enabled", "two-factor authentication not enabled")
+	errInvalidCode   = InvalidField("code", "invalid_code", "invalid code")
+	errInvalidMFA    = Unauthorized("invalid_mfa_token", "invalid or expired MFA token")
+)
+
+// BeginTOTPEnrollment stores a new pending secret for the user. The secret
+// only takes effect once ConfirmTOTPEnrollment receives a valid code for it.
+func (s *UserService) BeginTOTPEnrollment(ctx context.Context, userID string) (string, string, error) {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return "", "", err
+	}
+	if user.TOTPEnabled {
+		return "", "", errMFAEnabled
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate TOTP secret: %v", err)
+	}
+
+	err = s.db.UpdateUser(ctx, user.ID, map[string]interface{}{
+		"totp_secret":    secret,
+		"totp_last_step": 0,
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	return secret, utils.TOTPURI(s.config.TOTPIssuer, user.Email, secret), nil
+}
+
+func (s *UserService) ConfirmTOTPEnrollment(ctx context.Context, userID, code string) ([]string, error) {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabled {
+		return nil, errMFAEnabled
+	}
+	if user.TOTPSecret == nil {
+		return nil, Conflict("mfa_enrollment_not_started", "two-factor enrollment not started")
+	}
+
+	step, ok := utils.ValidateTOTP(*user.TOTPSecret, code, time.Now())
+	if !ok {
+		return nil, errInvalidCode
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.db.UpdateUser(ctx, user.ID, map[string]interface{}{
+		"totp_enabled":   true,
+		"totp_last_step": step,
+		"recovery_codes": hashes,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+func (s *UserService) DisableTOTP(ctx context.Context, userID, password, code, recoveryCode string) error {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !user.TOTPEnabled {
+		return errMFANotEnabled
+	}
+	if user.MFARequired {
+		return Forbidden("mfa_required", "two-factor authentication is required for this account")
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		return wrongPassword("password")
+	}
+	if err := s.verifySecondFactor(ctx, user, code, recoveryCode); err != nil {
+		return err
+	}
+
+	return s.db.UpdateUser(ctx, user.ID, map[string]interface{}{
+		"totp_enabled":   false,
+		"totp_secret":    nil,
+		"totp_last_step": 0,
+		"recovery_codes": []string{},
+	})
+}
+
+func (s *UserService) RegenerateRecoveryCodes(ctx context.Context, userID, code string) ([]string, error) {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.TOTPEnabled {
+		return nil, errMFANotEnabled
+	}
+	if err := s.verifySecondFactor(ctx, user, code, ""); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.db.UpdateUser(ctx, user.ID, map[string]interface{}{"recovery_codes": hashes}); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// CreateMFAChallenge issues the short-lived token exchanged for a session
+// token in the second login step.
+func (s *UserService) CreateMFAChallenge(ctx context.Context, user *models.User) (string, error) {
+	return utils.GenerateActionToken(user.ID, utils.PurposeMFAChallenge, mfaFingerprint(user), mfaChallengeTTL)
+}
+
+func (s *UserService) VerifyMFAChallenge(ctx context.Context, token, code, recoveryCode string) (*models.User, error) {
+	claims, err := utils.ValidateActionToken(token, utils.PurposeMFAChallenge)
+	if err != nil {
+		return nil, errInvalidMFA
+	}
+
+	user, err := s.db.GetUserByID(ctx, claims.UserID)
+	if err != nil || !user.TOTPEnabled || claims.Fingerprint != mfaFingerprint(user) {
+		return nil, errInvalidMFA
+	}
+
+	if err := s.verifySecondFactor(ctx, user, code, recoveryCode); err != nil {
+		return nil, loginFailure(err)
+	}
+	return user, nil
+}
+
+// loginFailure reports a rejected second factor during login as
+// unauthorized rather than as a bad request, like a wrong password.
+func loginFailure(err error) error {
+	var e *Error
+	if !errors.As(err, &e) || e.Kind != ErrInvalidInput {
+		return err
+	}
+	copied := *e
+	copied.Kind = ErrUnauthorized
+	return &copied
+}
+
+// SetMFARequired forces a user to enroll in 2FA, or lifts the requirement.
+// Requiring it of a user without 2FA revokes their existing tokens, so they
+// have to sign in again and enroll.
+func (s *UserService) SetMFARequired(ctx context.Context, userID string, required bool) (*models.User, error) {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := map[string]interface{}{"mfa_required": required}
+	if required && !user.MFARequired && !user.TOTPEnabled {
+		updates["token_version"] = user.TokenVersion + 1
+		user.TokenVersion++
+	}
+
+	if err := s.db.UpdateUser(ctx, user.ID, updates); err != nil {
+		return nil, err
+	}
+
+	user.MFARequired = required
+	return user, nil
+}
+
+// verifySecondFactor accepts either a current TOTP code or an unused recovery
+// code, consuming whichever one succeeded.
+func (s *UserService) verifySecondFactor(ctx context.Context, user *models.User, code, recoveryCode string) error {
+	if code != "" && user.TOTPSecret != nil {
+		step, ok := utils.ValidateTOTP(*user.TOTPSecret, code, time.Now())
+		if !ok || step <= user.TOTPLastStep {
+			return errInvalidCode
+		}
+		return s.db.UpdateUser(ctx, user.ID, map[string]interface{}{"totp_last_step": step})
+	}
+
+	if recoveryCode != "" {
+		hash := utils.HashRecoveryCode(recoveryCode)
+		for i, stored := range user.RecoveryCodes {
+			if subtle.ConstantTimeCompare([]byte(stored), []byte(hash)) == 1 {
+				remaining := append(append([]string{}, user.RecoveryCodes[:i]...), user.RecoveryCodes[i+1:]...)
+				return s.db.UpdateUser(ctx, user.ID, map[string]interface{}{"recovery_codes": remaining})
+			}
+		}
+		return InvalidField("recovery_code", "invalid_recovery_code", "invalid recovery code")
+	}
+
+	return InvalidField("code", "code_required", "code or recovery code required")
+}
+
+func newRecoveryCodes() ([]string, []string, error) {
+	codes, err := utils.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate recovery codes: %v", err)
+	}
+
+	hashes := make([]string, len(codes))
+	for i, code := range codes {
+		hashes[i] = utils.HashRecoveryCode(code)
+	}
+	return codes, hashes, nil
+}
+
+func mfaFingerprint(user *models.User) string {
+	return utils.Fingerprint(user.PasswordHash, fmt.Sprint(user.TOTPEnabled))
+}
@@ -1,32 +1,105 @@
 package services
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"net/url"
+	"time"
+
+	"slink-backend/internal/config"
 	"slink-backend/internal/database"
+	"slink-backend/internal/mailer"
 	"slink-backend/internal/models"
+	"slink-backend/internal/utils"
 
+	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 )
 
 type UserServiceInterface interface {
-	Register(req models.RegisterRequest) (*models.User, error)
-	Login(req models.LoginRequest) (*models.User, error)
-	GetUserByID(userID string) (*models.User, error)
+	Register(ctx context.Context, req models.RegisterRequest) (*models.User, error)
+	RegisterVerified(ctx context.Context, req models.RegisterRequest) (*models.User, error)
+	Login(ctx context.Context, req models.LoginRequest) (*models.User, error)
+	GetUserByID(ctx context.Context, userID string) (*models.User, error)
+	GetUserByEmail(ctx context.Context, email string) (*models.User, error)
+	SendVerificationEmail(ctx context.Context, user *models.User) error
+	VerifyEmail(ctx context.Context, token string) (*models.User, error)
+	RequestPasswordReset(ctx context.Context, email string) error
+	ResetPassword(ctx context.Context, token, newPassword string) (string, error)
+	BeginTOTPEnrollment(ctx context.Context, userID string) (secret string, uri string, err error)
+	ConfirmTOTPEnrollment(ctx context.Context, userID, code string) ([]string, error)
+	DisableTOTP(ctx context.Context, userID, password, code, recoveryCode string) error
+	RegenerateRecoveryCodes(ctx context.Context, userID, code string) ([]string, error)
+	CreateMFAChallenge(ctx context.Context, user *models.User) (string, error)
+	VerifyMFAChallenge(ctx context.Context, token, code, recoveryCode string) (*models.User, error)
+	SetMFARequired(ctx context.Context, userID string, required bool) (*models.User, error)
+	UpdateProfile(ctx context.Context, userID string, req models.UpdateProfileRequest) (*models.User, error)
+	ChangePassword(ctx context.Context, userID, currentPassword, newPassword string) (*models.User, error)
+	DeleteAccount(ctx context.Context, userID, password string) error
+	ListUsers(ctx context.Context, query string, limit, offset int) ([]models.User, error)
+	SetDisabled(ctx context.Context, userID string, disabled bool) (*models.User, error)
+	SetPlan(ctx context.Context, userID, plan string) (*models.User, error)
+	SetRole(ctx context.Context, userID, role string) (*models.User, error)
+}
+
+var (
+	errUserNotFound       = NotFound("user_not_found", "user not found")
+	errEmailTaken         = invalidConflict("email", "email_taken", "user with this email already exists")
+	errInvalidCredentials = Unauthorized("invalid_credentials", "invalid credentials")
+	errInvalidToken       = InvalidField("token", "invalid_token", "invalid or expired token")
+)
+
+// invalidConflict reports a field whose value clashes with existing data.
+func invalidConflict(field, code, message string) *Error {
+	err := Conflict(code, message)
+	err.Fields = []FieldError{{Field: field, Code: code, Message: message}}
+	return err
+}
+
+// wrongPassword is returned when re-entering the password fails.
+func wrongPassword(field string) *Error {
+	return InvalidField(field, "invalid_password", "password is incorrect")
 }
 
 type UserService struct {
-	db *database.SupabaseClient
+	db     *database.SupabaseClient
+	mailer mailer.Mailer
+	config *config.Config
+}
+
+func NewUserService(db *database.SupabaseClient, m mailer.Mailer, cfg *config.Config) UserServiceInterface {
+	return &UserService{db: db, mailer: m, config: cfg}
+}
+
+func (s *UserService) Register(ctx context.Context, req models.RegisterRequest) (*models.User, error) {
+	user, err := s.createUser(ctx, req, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.SendVerificationEmail(ctx, user); err != nil {
+		slog.Error("Failed to send verification email", "user_id", user.ID, "error", err)
+	}
+
+	return user, nil
 }
 
-func NewUserService(db *database.SupabaseClient) UserServiceInterface {
-	return &UserService{db: db}
+// RegisterVerified creates a user whose email address counts as verified,
+// for accounts created by operators. No verification email is sent.
+func (s *UserService) RegisterVerified(ctx context.Context, req models.RegisterRequest) (*models.User, error) {
+	return s.createUser(ctx, req, true)
 }
 
-func (s *UserService) Register(req models.RegisterRequest) (*models.User, error) {
+func (s *UserService) createUser(ctx context.Context, req models.RegisterRequest, verified bool) (*models.User, error) {
 	// Check if user already exists
-	existingUser, err := s.GetUserByEmail(req.Email)
-	if err == nil && existingUser != nil {
-		return nil, fmt.Errorf("user with this email already exists")
+	_, err := s.GetUserByEmail(ctx, req.Email)
+	if err == nil {
+		return nil, errEmailTaken
+	}
+	if !errors.Is(err, database.ErrNotFound) {
+		return nil, err
 	}
 
 	// Hash password
@@ -37,40 +110,192 @@ func (s *UserService) Register(req models.RegisterRequest) (*models.User, error)
 
 	// Create user
 	user := &models.User{
-		Email:        req.Email,
-		PasswordHash: string(hashedPassword),
-		Name:         req.Name,
+		Email:         req.Email,
+		PasswordHash:  string(hashedPassword),
+		Name:          req.Name,
+		EmailVerified: verified,
+	}
+	if verified {
+		now := time.Now()
+		user.EmailVerifiedAt = &now
 	}
 
 	// Insert into database
-	err = s.db.CreateUser(user)
+	err = s.db.CreateUser(ctx, user)
+	if database.IsUniqueViolation(err) {
+		// Registered concurrently since the check above.
+		return nil, errEmailTaken
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to create user: %v", err)
+		return nil, err
 	}
-
 	return user, nil
 }
 
-func (s *UserService) Login(req models.LoginRequest) (*models.User, error) {
+func (s *UserService) Login(ctx context.Context, req models.LoginRequest) (*models.User, error) {
 	// Get user by email
-	user, err := s.GetUserByEmail(req.Email)
-	if err != nil {
-		return nil, fmt.Errorf("invalid credentials")
+	user, err := s.GetUserByEmail(ctx, req.Email)
+	if err != nil || user.DeletedAt != nil {
+		return nil, errInvalidCredentials
 	}
 
 	// Compare password
 	err = bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password))
 	if err != nil {
-		return nil, fmt.Errorf("invalid credentials")
+		return nil, errInvalidCredentials
+	}
+
+	if user.Disabled {
+		return nil, Forbidden("account_disabled", "account disabled")
 	}
 
 	return user, nil
 }
 
-func (s *UserService) GetUserByID(userID string) (*models.User, error) {
-	return s.db.GetUserByID(userID)
+func (s *UserService) GetUserByID(ctx context.Context, userID string) (*models.User, error) {
+	return s.getUser(ctx, userID)
+}
+
+// getUser loads a user, reporting unknown and malformed IDs as not found.
+func (s *UserService) getUser(ctx context.Context, userID string) (*models.User, error) {
+	if _, err := uuid.Parse(userID); err != nil {
+		return nil, errUserNotFound
+	}
+	user, err := s.db.GetUserByID(ctx, userID)
+	if errors.Is(err, database.ErrNotFound) {
+		return nil, errUserNotFound
+	}
+	return user, err
+}
+
+func (s *UserService) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	return s.db.GetUserByEmail(ctx, email)
+}
+
+func (s *UserService) SendVerificationEmail(ctx context.Context, user *models.User) error {
+	if user.EmailVerified {
+		return Conflict("email_already_verified", "email already verified")
+	}
+
+	ttl := s.config.VerificationTokenTTL
+	token, err := utils.GenerateActionToken(user.ID, utils.PurposeEmailVerification, verificationFingerprint(user), ttl)
+	if err != nil {
+		return fmt.Errorf("failed to generate verification token: %v", err)
+	}
+
+	link := fmt.Sprintf("%s/api/verify-email?token=%s", s.config.BaseURL, url.QueryEscape(token))
+	return s.mailer.Send(mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your Slink email address",
+		Body: fmt.Sprintf("Welcome to Slink!\n\nConfirm your email address by opening the link below:\n\n%s\n\nThe link expires in %d hours.\n",
+			link, int(s.config.VerificationTokenTTL.Hours())),
+	})
+}
+
+func (s *UserService) VerifyEmail(ctx context.Context, token string) (*models.User, error) {
+	claims, err := utils.ValidateActionToken(token, utils.PurposeEmailVerification)
+	if err != nil {
+		return nil, errInvalidToken
+	}
+
+	user, err := s.db.GetUserByID(ctx, claims.UserID)
+	if err != nil {
+		return nil, errInvalidToken
+	}
+	if user.EmailVerified {
+		return user, nil
+	}
+	if claims.Fingerprint != verificationFingerprint(user) {
+		return nil, errInvalidToken
+	}
+
+	if err := s.markVerified(ctx, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (s *UserService) markVerified(ctx context.Context, user *models.User) error {
+	now := time.Now()
+	err := s.db.UpdateUser(ctx, user.ID, map[string]interface{}{
+		"email_verified":    true,
+		"email_verified_at": now,
+	})
+	if err != nil {
+		return err
+	}
+
+	user.EmailVerified = true
+	user.EmailVerifiedAt = &now
+	return nil
+}
+
+// RequestPasswordReset mails a reset link if the address belongs to a user.
+// Unknown addresses are ignored so callers can't probe for accounts.
+func (s *UserService) RequestPasswordReset(ctx context.Context, email string) error {
+	user, err := s.GetUserByEmail(ctx, email)
+	if errors.Is(err, database.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	ttl := s.config.PasswordResetTTL
+	token, err := utils.GenerateActionToken(user.ID, utils.PurposePasswordReset, resetFingerprint(user), ttl)
+	if err != nil {
+		return fmt.Errorf("failed to generate reset token: %v", err)
+	}
+
+	link := fmt.Sprintf("%s/reset-password?token=%s", s.config.FrontendURL, url.QueryEscape(token))
+	return s.mailer.Send(mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your Slink password",
+		Body: fmt.Sprintf("Someone requested a password reset for your Slink account.\n\nChoose a new password here:\n\n%s\n\nThe link expires in %d minutes. If you didn't request this, you can ignore this email.\n",
+			link, int(s.config.PasswordResetTTL.Minutes())),
+	})
+}
+
+// ResetPassword sets a new password using a reset token and returns the ID
+// of the affected user.
+func (s *UserService) ResetPassword(ctx context.Context, token, newPassword string) (string, error) {
+	claims, err := utils.ValidateActionToken(token, utils.PurposePasswordReset)
+	if err != nil {
+		return "", errInvalidToken
+	}
+
+	user, err := s.db.GetUserByID(ctx, claims.UserID)
+	if err != nil || claims.Fingerprint != resetFingerprint(user) {
+		return "", errInvalidToken
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %v", err)
+	}
+
+	// Completing a reset proves ownership of the mailbox as well.
+	updates := map[string]interface{}{
+		"password_hash": string(hashedPassword),
+		"token_version": user.TokenVersion + 1,
+	}
+	if !user.EmailVerified {
+		updates["email_verified"] = true
+		updates["email_verified_at"] = time.Now()
+	}
+
+	if err := s.db.UpdateUser(ctx, user.ID, updates); err != nil {
+		return "", err
+	}
+	return user.ID, nil
+}
+
+func verificationFingerprint(user *models.User) string {
+	return utils.Fingerprint(user.Email, fmt.Sprint(user.EmailVerified))
 }
 
-func (s *UserService) GetUserByEmail(email string) (*models.User, error) {
-	return s.db.GetUserByEmail(email)
+// resetFingerprint changes with the password hash, which makes reset tokens
+// single-use.
+func resetFingerprint(user *models.User) string {
+	return utils.Fingerprint(user.Email, user.PasswordHash)
 }
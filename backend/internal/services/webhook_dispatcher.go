@@ -0,0 +1,261 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"slink-backend/internal/config"
+	"slink-backend/internal/models"
+)
+
+const (
+	webhookUserAgent     = "Slink-Webhooks/1.0"
+	webhookBaseBackoff   = 30 * time.Second
+	webhookMaxBackoff    = 6 * time.Hour
+	webhookPollInterval  = 5 * time.Second
+	maxWebhookErrorBytes = 500
+)
+
+// WebhookDispatcher sends queued webhook deliveries. Failed attempts are
+// retried with exponential backoff; after maxAttempts a delivery is marked
+// dead and stays in the log for manual redelivery. Deliveries for disabled
+// endpoints are held until the endpoint is enabled again. It also fires
+// link.expired for links whose expiry has passed.
+type WebhookDispatcher struct {
+	webhooks    *WebhookService
+	client      *http.Client
+	maxAttempts int
+	batchSize   int
+	concurrency int
+	lease       time.Duration
+
+	lastRun atomic.Int64
+}
+
+func NewWebhookDispatcher(webhooks *WebhookService, cfg *config.Config) *WebhookDispatcher {
+	timeout := cfg.WebhookTimeout
+	client := NewPublicHTTPClient(timeout)
+	// Endpoints must answer the URL they registered; following redirects
+	// would also re-sign the payload for wherever they point.
+	client.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	return &WebhookDispatcher{
+		webhooks:    webhooks,
+		client:      client,
+		maxAttempts: max(cfg.WebhookMaxAttempts, 1),
+		batchSize:   max(cfg.WebhookBatchSize, 1),
+		concurrency: max(cfg.WebhookConcurrency, 1),
+		lease:       2*timeout + time.Minute,
+	}
+}
+
+// Run dispatches deliveries until ctx is cancelled.
+func (d *WebhookDispatcher) Run(ctx context.Context) {
+	for {
+		expiredErr := d.emitExpiredLinks(ctx)
+		if expiredErr != nil {
+			slog.Error("Failed to emit link.expired events", "error", expiredErr)
+		}
+
+		n, err := d.DispatchOnce(ctx)
+		if err != nil {
+			slog.Error("Webhook dispatch failed", "error", err)
+		}
+		if err == nil && expiredErr == nil {
+			d.lastRun.Store(time.Now().UnixNano())
+		}
+
+		wait := webhookPollInterval
+		if n == d.batchSize {
+			wait = 0
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// LastRun returns when Run last finished a batch without error, or the zero
+// time if it hasn't yet.
+func (d *WebhookDispatcher) LastRun() time.Time {
+	if ns := d.lastRun.Load(); ns != 0 {
+		return time.Unix(0, ns)
+	}
+	return time.Time{}
+}
+
+// DispatchOnce sends one batch of due deliveries and returns its size.
+// Deliveries whose endpoint couldn't be looked up are left alone; they are
+// claimed again once their lease runs out.
+func (d *WebhookDispatcher) DispatchOnce(ctx context.Context) (int, error) {
+	deliveries, err := d.webhooks.claimDueDeliveries(ctx, d.batchSize, d.lease)
+	if err != nil {
+		return 0, err
+	}
+
+	endpoints := map[string]*webhookEndpointRecord{}
+	var lookupErr error
+	for _, delivery := range deliveries {
+		if _, ok := endpoints[delivery.EndpointID]; ok {
+			continue
+		}
+		endpoint, err := d.webhooks.getEndpointRecord(ctx, delivery.EndpointID)
+		if err != nil && !errors.Is(err, errWebhookNotFound) {
+			lookupErr = fmt.Errorf("failed to get webhook endpoint: %v", err)
+			continue
+		}
+		endpoints[delivery.EndpointID] = endpoint
+	}
+
+	sem := make(chan struct{}, d.concurrency)
+	var wg sync.WaitGroup
+	for i := range deliveries {
+		delivery := &deliveries[i]
+		endpoint, ok := endpoints[delivery.EndpointID]
+		if !ok {
+			continue
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			d.deliver(ctx, delivery, endpoint)
+		}()
+	}
+	wg.Wait()
+
+	return len(deliveries), lookupErr
+}
+
+func (d *WebhookDispatcher) deliver(ctx context.Context, delivery *models.WebhookDelivery, endpoint *webhookEndpointRecord) {
+	switch {
+	case endpoint == nil:
+		d.finish(ctx, delivery, nil, fmt.Errorf("endpoint was deleted"), true)
+		return
+	case !endpoint.Active:
+		d.park(ctx, delivery)
+		return
+	}
+
+	statusCode, err := d.send(ctx, endpoint, delivery)
+	// Record the attempt even if shutdown interrupted it, so it isn't
+	// retried before its backoff.
+	d.finish(context.WithoutCancel(ctx), delivery, statusCode, err, false)
+}
+
+// park holds a delivery for a disabled endpoint without using up an attempt.
+// It stays pending with no next attempt until the endpoint is enabled again.
+func (d *WebhookDispatcher) park(ctx context.Context, delivery *models.WebhookDelivery) {
+	updateData := map[string]interface{}{
+		"next_attempt_at": nil,
+		"last_error":      "endpoint is disabled",
+	}
+	if err := d.webhooks.updateDelivery(ctx, delivery.ID, updateData); err != nil {
+		slog.Error("Failed to hold webhook delivery", "delivery_id", delivery.ID, "error", err)
+	}
+}
+
+// send POSTs the stored payload, signed with the endpoint's current secret.
+func (d *WebhookDispatcher) send(ctx context.Context, endpoint *webhookEndpointRecord, delivery *models.WebhookDelivery) (*int, error) {
+	timestamp := time.Now().Unix()
+	signature := SignWebhookPayload(endpoint.Secret, timestamp, delivery.Payload)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", webhookUserAgent)
+	req.Header.Set("Slink-Event", delivery.EventType)
+	req.Header.Set("Slink-Event-ID", delivery.EventID)
+	req.Header.Set("Slink-Delivery", delivery.ID)
+	req.Header.Set("Slink-Signature", fmt.Sprintf("t=%d,v1=%s", timestamp, signature))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxWebhookErrorBytes))
+		return &resp.StatusCode, fmt.Errorf("endpoint returned status %d: %s", resp.StatusCode, bytes.TrimSpace(body))
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	return &resp.StatusCode, nil
+}
+
+// finish records the outcome of an attempt and schedules the next one.
+func (d *WebhookDispatcher) finish(ctx context.Context, delivery *models.WebhookDelivery, statusCode *int, sendErr error, dead bool) {
+	attempts := delivery.Attempts + 1
+	updateData := map[string]interface{}{
+		"attempts":         attempts,
+		"last_status_code": statusCode,
+	}
+
+	switch {
+	case sendErr == nil:
+		updateData["status"] = models.DeliverySucceeded
+		updateData["delivered_at"] = time.Now()
+		updateData["next_attempt_at"] = nil
+		updateData["last_error"] = nil
+	case dead || attempts >= d.maxAttempts:
+		updateData["status"] = models.DeliveryDead
+		updateData["next_attempt_at"] = nil
+		updateData["last_error"] = sendErr.Error()
+	default:
+		updateData["next_attempt_at"] = time.Now().Add(webhookBackoff(attempts))
+		updateData["last_error"] = sendErr.Error()
+	}
+
+	if err := d.webhooks.updateDelivery(ctx, delivery.ID, updateData); err != nil {
+		slog.Error("Failed to record webhook delivery", "delivery_id", delivery.ID, "error", err)
+	}
+}
+
+// webhookBackoff is the wait after the given number of failed attempts.
+func webhookBackoff(attempts int) time.Duration {
+	backoff := webhookBaseBackoff
+	for i := 1; i < attempts && backoff < webhookMaxBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, webhookMaxBackoff)
+}
+
+// emitExpiredLinks queues link.expired for links whose expiry has passed.
+// Claiming marks a link as announced, so if queueing its event fails the mark
+// is cleared again and the link is picked up on the next run.
+func (d *WebhookDispatcher) emitExpiredLinks(ctx context.Context) error {
+	links, err := d.webhooks.claimExpiredLinks(ctx, d.batchSize)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for i := range links {
+		link := &links[i]
+		if link.UserID == nil {
+			continue
+		}
+		if err := d.webhooks.Emit(ctx, *link.UserID, models.WebhookLinkExpired, link); err != nil {
+			errs = append(errs, fmt.Errorf("link %s: %v", link.ID, err))
+			if err := d.webhooks.releaseExpiredLink(context.WithoutCancel(ctx), link.ID); err != nil {
+				slog.Error("Failed to release expired link", "link_id", link.ID, "error", err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,467 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+
+	"slink-backend/internal/database"
+	"slink-backend/internal/models"
+	"slink-backend/internal/utils"
+
+	"github.com/google/uuid"
+	"github.com/lengzuo/supa/utils/enum"
+)
+
+const (
+	maxWebhookEndpoints   = 10
+	webhookSecretPrefix   = "whsec_"
+	webhookSecretLength   = 32
+	webhookSecretCharset  = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+	maxWebhookDescription = 200
+)
+
+var (
+	errWebhookNotFound  = NotFound("webhook_not_found", "webhook endpoint not found")
+	errDeliveryNotFound = NotFound("delivery_not_found", "webhook delivery not found")
+	errWebhookURL       = InvalidField("url", "invalid_url", "invalid URL")
+	errWebhookDesc      = InvalidField("description", "too_long", fmt.Sprintf("description must be at most %d characters", maxWebhookDescription))
+)
+
+type webhookEndpointRecord struct {
+	ID          string    `json:"id"`
+	UserID      string    `json:"user_id"`
+	URL         string    `json:"url"`
+	Description string    `json:"description"`
+	Events      []string  `json:"events"`
+	Active      bool      `json:"active"`
+	Secret      string    `json:"secret"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// toModel leaves out the secret, which is only shown when it's generated.
+func (r webhookEndpointRecord) toModel() *models.WebhookEndpoint {
+	return &models.WebhookEndpoint{
+		ID:          r.ID,
+		UserID:      r.UserID,
+		URL:         r.URL,
+		Description: r.Description,
+		Events:      r.Events,
+		Active:      r.Active,
+		CreatedAt:   r.CreatedAt,
+		UpdatedAt:   r.UpdatedAt,
+	}
+}
+
+// WebhookService manages users' webhook endpoints and queues events for
+// delivery to them. Deliveries are sent by WebhookDispatcher.
+type WebhookService struct {
+	supabase *database.SupabaseClient
+}
+
+func NewWebhookService(supabase *database.SupabaseClient) *WebhookService {
+	return &WebhookService{supabase: supabase}
+}
+
+func (s *WebhookService) CreateEndpoint(ctx context.Context, userID string, req models.CreateWebhookRequest) (*models.WebhookEndpoint, error) {
+	events, err := normalizeWebhookEvents(req.Events)
+	if err != nil {
+		return nil, err
+	}
+	if !utils.IsValidURL(req.URL) {
+		return nil, errWebhookURL
+	}
+	if len(req.Description) > maxWebhookDescription {
+		return nil, errWebhookDesc
+	}
+
+	existing, err := s.ListEndpoints(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) >= maxWebhookEndpoints {
+		return nil, Conflict("webhook_limit", fmt.Sprintf("at most %d webhook endpoints are allowed", maxWebhookEndpoints))
+	}
+
+	secret, err := generateWebhookSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	record := webhookEndpointRecord{
+		ID:          uuid.New().String(),
+		UserID:      userID,
+		URL:         req.URL,
+		Description: req.Description,
+		Events:      events,
+		Active:      true,
+		Secret:      secret,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+
+	client := s.supabase.GetClient()
+	if err := database.Execute(ctx, "insert", "webhook_endpoints", client.DB.From("webhook_endpoints").Insert(record), nil); err != nil {
+		return nil, fmt.Errorf("failed to create webhook endpoint: %v", err)
+	}
+
+	endpoint := record.toModel()
+	endpoint.Secret = secret
+	return endpoint, nil
+}
+
+func (s *WebhookService) ListEndpoints(ctx context.Context, userID string) ([]models.WebhookEndpoint, error) {
+	client := s.supabase.GetClient()
+	var results []webhookEndpointRecord
+
+	err := database.Execute(ctx, "select", "webhook_endpoints", client.DB.From("webhook_endpoints").Select("*").Order("created_at", enum.OrderAsc).Eq("user_id", userID), &results)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook endpoints: %v", err)
+	}
+
+	endpoints := make([]models.WebhookEndpoint, len(results))
+	for i, record := range results {
+		endpoints[i] = *record.toModel()
+	}
+	return endpoints, nil
+}
+
+// GetEndpoint returns the endpoint with id if it belongs to userID.
+func (s *WebhookService) GetEndpoint(ctx context.Context, id, userID string) (*models.WebhookEndpoint, error) {
+	record, err := s.getEndpointRecord(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if record.UserID != userID {
+		return nil, errWebhookNotFound
+	}
+	return record.toModel(), nil
+}
+
+func (s *WebhookService) getEndpointRecord(ctx context.Context, id string) (*webhookEndpointRecord, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, errWebhookNotFound
+	}
+
+	client := s.supabase.GetClient()
+	var results []webhookEndpointRecord
+
+	err := database.Execute(ctx, "select", "webhook_endpoints", client.DB.From("webhook_endpoints").Select("*").Eq("id", id), &results)
+	if err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return nil, errWebhookNotFound
+	}
+	return &results[0], nil
+}
+
+func (s *WebhookService) UpdateEndpoint(ctx context.Context, id, userID string, req models.UpdateWebhookRequest) (*models.WebhookEndpoint, error) {
+	endpoint, err := s.GetEndpoint(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	updateData := map[string]interface{}{}
+
+	if req.URL != nil {
+		if !utils.IsValidURL(*req.URL) {
+			return nil, errWebhookURL
+		}
+		updateData["url"] = *req.URL
+	}
+	if req.Description != nil {
+		if len(*req.Description) > maxWebhookDescription {
+			return nil, errWebhookDesc
+		}
+		updateData["description"] = *req.Description
+	}
+	if req.Events != nil {
+		events, err := normalizeWebhookEvents(*req.Events)
+		if err != nil {
+			return nil, err
+		}
+		updateData["events"] = events
+	}
+	if req.Active != nil {
+		updateData["active"] = *req.Active
+	}
+
+	if len(updateData) == 0 {
+		return endpoint, nil
+	}
+
+	updateData["updated_at"] = time.Now()
+	updated, err := s.updateEndpoint(ctx, id, updateData)
+	if err != nil {
+		return nil, err
+	}
+	if updated.Active && !endpoint.Active {
+		if err := s.resumeDeliveries(ctx, id); err != nil {
+			return nil, err
+		}
+	}
+	return updated, nil
+}
+
+// resumeDeliveries makes deliveries held while the endpoint was disabled due
+// now.
+func (s *WebhookService) resumeDeliveries(ctx context.Context, endpointID string) error {
+	client := s.supabase.GetClient()
+	updateData := map[string]interface{}{
+		"next_attempt_at": time.Now(),
+		"updated_at":      time.Now(),
+	}
+	query := client.DB.From("webhook_deliveries").Update(updateData).Eq("endpoint_id", endpointID).Eq("status", models.DeliveryPending).Is("next_attempt_at", "null")
+	if err := database.Execute(ctx, "update", "webhook_deliveries", query, nil); err != nil {
+		return fmt.Errorf("failed to resume webhook deliveries: %v", err)
+	}
+	return nil
+}
+
+// RotateSecret replaces the endpoint's signing secret and returns the new one.
+func (s *WebhookService) RotateSecret(ctx context.Context, id, userID string) (*models.WebhookEndpoint, error) {
+	if _, err := s.GetEndpoint(ctx, id, userID); err != nil {
+		return nil, err
+	}
+
+	secret, err := generateWebhookSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint, err := s.updateEndpoint(ctx, id, map[string]interface{}{
+		"secret":     secret,
+		"updated_at": time.Now(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	endpoint.Secret = secret
+	return endpoint, nil
+}
+
+func (s *WebhookService) updateEndpoint(ctx context.Context, id string, updateData map[string]interface{}) (*models.WebhookEndpoint, error) {
+	client := s.supabase.GetClient()
+	var results []webhookEndpointRecord
+
+	if err := database.Execute(ctx, "update", "webhook_endpoints", client.DB.From("webhook_endpoints").Update(updateData).Eq("id", id), &results); err != nil {
+		return nil, fmt.Errorf("failed to update webhook endpoint: %v", err)
+	}
+	if len(results) == 0 {
+		return nil, errWebhookNotFound
+	}
+	return results[0].toModel(), nil
+}
+
+// DeleteEndpoint removes the endpoint together with its delivery log.
+func (s *WebhookService) DeleteEndpoint(ctx context.Context, id, userID string) (*models.WebhookEndpoint, error) {
+	endpoint, err := s.GetEndpoint(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	client := s.supabase.GetClient()
+	if err := database.Execute(ctx, "delete", "webhook_endpoints", client.DB.From("webhook_endpoints").Delete().Eq("id", id), nil); err != nil {
+		return nil, fmt.Errorf("failed to delete webhook endpoint: %v", err)
+	}
+	return endpoint, nil
+}
+
+// Emit queues an event for every active endpoint of userID subscribed to
+// eventType. All deliveries of one event share its ID and payload, so
+// receivers can de-duplicate.
+func (s *WebhookService) Emit(ctx context.Context, userID, eventType string, data interface{}) error {
+	client := s.supabase.GetClient()
+	var endpoints []webhookEndpointRecord
+
+	err := database.Execute(ctx, "select", "webhook_endpoints", client.DB.From("webhook_endpoints").Select("id").Eq("user_id", userID).Eq("active", "true").Cs("events", []string{eventType}), &endpoints)
+	if err != nil {
+		return fmt.Errorf("failed to find webhook endpoints: %v", err)
+	}
+	if len(endpoints) == 0 {
+		return nil
+	}
+
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	eventID := uuid.New().String()
+	payload, err := json.Marshal(models.WebhookPayload{
+		ID:        eventID,
+		Type:      eventType,
+		CreatedAt: now,
+		Data:      encoded,
+	})
+	if err != nil {
+		return err
+	}
+
+	deliveries := make([]models.WebhookDelivery, len(endpoints))
+	for i, endpoint := range endpoints {
+		deliveries[i] = newDelivery(endpoint.ID, eventID, eventType, payload, now)
+	}
+
+	if err := database.Execute(ctx, "insert", "webhook_deliveries", client.DB.From("webhook_deliveries").Insert(deliveries), nil); err != nil {
+		return fmt.Errorf("failed to queue webhook deliveries: %v", err)
+	}
+	return nil
+}
+
+func newDelivery(endpointID, eventID, eventType string, payload json.RawMessage, now time.Time) models.WebhookDelivery {
+	return models.WebhookDelivery{
+		ID:            uuid.New().String(),
+		EndpointID:    endpointID,
+		EventID:       eventID,
+		EventType:     eventType,
+		Payload:       payload,
+		Status:        models.DeliveryPending,
+		NextAttemptAt: &now,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+}
+
+// ListDeliveries returns the delivery log of an endpoint owned by userID,
+// newest first, optionally restricted to one status.
+func (s *WebhookService) ListDeliveries(ctx context.Context, endpointID, userID, status string, limit, offset int) ([]models.WebhookDelivery, error) {
+	if _, err := s.GetEndpoint(ctx, endpointID, userID); err != nil {
+		return nil, err
+	}
+
+	client := s.supabase.GetClient()
+	deliveries := []models.WebhookDelivery{}
+
+	req := client.DB.From("webhook_deliveries").Select("*").Order("created_at", enum.OrderDesc).Limit(limit).Offset(offset)
+	req.Eq("endpoint_id", endpointID)
+	if status != "" {
+		req.Eq("status", status)
+	}
+
+	if err := database.Execute(ctx, "select", "webhook_deliveries", req, &deliveries); err != nil {
+		return nil, fmt.Errorf("failed to list webhook deliveries: %v", err)
+	}
+	return deliveries, nil
+}
+
+// Redeliver queues a fresh delivery of the same payload. The original stays
+// in the log unchanged.
+func (s *WebhookService) Redeliver(ctx context.Context, endpointID, deliveryID, userID string) (*models.WebhookDelivery, error) {
+	if _, err := s.GetEndpoint(ctx, endpointID, userID); err != nil {
+		return nil, err
+	}
+	if _, err := uuid.Parse(deliveryID); err != nil {
+		return nil, errDeliveryNotFound
+	}
+
+	client := s.supabase.GetClient()
+	var results []models.WebhookDelivery
+
+	err := database.Execute(ctx, "select", "webhook_deliveries", client.DB.From("webhook_deliveries").Select("*").Eq("id", deliveryID).Eq("endpoint_id", endpointID), &results)
+	if err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return nil, errDeliveryNotFound
+	}
+
+	original := results[0]
+	delivery := newDelivery(endpointID, original.EventID, original.EventType, original.Payload, time.Now())
+	delivery.RedeliveryOf = &original.ID
+
+	if err := database.Execute(ctx, "insert", "webhook_deliveries", client.DB.From("webhook_deliveries").Insert(delivery), nil); err != nil {
+		return nil, fmt.Errorf("failed to queue webhook delivery: %v", err)
+	}
+	return &delivery, nil
+}
+
+// claimDueDeliveries leases up to limit pending deliveries whose next attempt
+// is due. A claimed delivery isn't handed out again until the lease runs out,
+// so a crashed dispatcher's work is picked up later.
+func (s *WebhookService) claimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.WebhookDelivery, error) {
+	client := s.supabase.GetClient()
+	var results []models.WebhookDelivery
+
+	params := map[string]interface{}{
+		"p_limit":         limit,
+		"p_lease_seconds": int(lease.Seconds()),
+	}
+	if err := database.Execute(ctx, "rpc", "claim_webhook_deliveries", client.DB.RPC("claim_webhook_deliveries", params), &results); err != nil {
+		return nil, fmt.Errorf("failed to claim webhook deliveries: %v", err)
+	}
+	return results, nil
+}
+
+// claimExpiredLinks marks up to limit links whose expiry has passed as
+// announced and returns them, so link.expired fires once per expiry.
+func (s *WebhookService) claimExpiredLinks(ctx context.Context, limit int) ([]models.URL, error) {
+	client := s.supabase.GetClient()
+	var results []URLRecord
+
+	if err := database.Execute(ctx, "rpc", "claim_expired_links", client.DB.RPC("claim_expired_links", map[string]interface{}{"p_limit": limit}), &results); err != nil {
+		return nil, fmt.Errorf("failed to claim expired links: %v", err)
+	}
+	return toModels(results), nil
+}
+
+// releaseExpiredLink clears the mark set by claimExpiredLinks, so the link
+// is claimed again.
+func (s *WebhookService) releaseExpiredLink(ctx context.Context, id string) error {
+	client := s.supabase.GetClient()
+	if err := database.Execute(ctx, "update", "urls", client.DB.From("urls").Update(map[string]interface{}{"expired_event_at": nil}).Eq("id", id), nil); err != nil {
+		return fmt.Errorf("failed to release expired link: %v", err)
+	}
+	return nil
+}
+
+func (s *WebhookService) updateDelivery(ctx context.Context, id string, updateData map[string]interface{}) error {
+	updateData["updated_at"] = time.Now()
+
+	client := s.supabase.GetClient()
+	if err := database.Execute(ctx, "update", "webhook_deliveries", client.DB.From("webhook_deliveries").Update(updateData).Eq("id", id), nil); err != nil {
+		return fmt.Errorf("failed to update webhook delivery: %v", err)
+	}
+	return nil
+}
+
+func normalizeWebhookEvents(events []string) ([]string, error) {
+	normalized := make([]string, 0, len(events))
+	for _, event := range events {
+		if !slices.Contains(models.WebhookEventTypes, event) {
+			return nil, InvalidField("events", "unknown_event", fmt.Sprintf("unknown event type %q", event))
+		}
+		if !slices.Contains(normalized, event) {
+			normalized = append(normalized, event)
+		}
+	}
+	if len(normalized) == 0 {
+		return nil, InvalidField("events", "events_required", "at least one event type is required")
+	}
+	return normalized, nil
+}
+
+func generateWebhookSecret() (string, error) {
+	secret, err := utils.RandomString(webhookSecretCharset, webhookSecretLength)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate secret: %v", err)
+	}
+	return webhookSecretPrefix + secret, nil
+}
+
+// SignWebhookPayload computes the v1 signature sent in the Slink-Signature
+// header: hex HMAC-SHA256 of "<timestamp>.<body>" keyed with the endpoint
+// secret. Receivers should recompute it and reject stale timestamps.
+func SignWebhookPayload(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
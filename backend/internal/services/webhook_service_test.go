@@ -0,0 +1,91 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"slink-backend/internal/models"
+)
+
+func TestSignWebhookPayload(t *testing.T) {
+	body := []byte(`{"event":"link.clicked"}`)
+
+	// Computed independently with:
+	//   printf '1700000000.{"event":"link.clicked"}' | openssl dgst -sha256 -hmac whsec_test
+	const want = "8dfbfd107a9e9da63ab453d0fc44de1909ae9dfa652c8a43f76dcb4284dccecc"
+	if got := SignWebhookPayload("whsec_test", 1700000000, body); got != want {
+		t.Errorf("signature = %s, want %s", got, want)
+	}
+
+	if SignWebhookPayload("whsec_other", 1700000000, body) == want {
+		t.Error("signature does not depend on the secret")
+	}
+	if SignWebhookPayload("whsec_test", 1700000001, body) == want {
+		t.Error("signature does not depend on the timestamp")
+	}
+	if SignWebhookPayload("whsec_test", 1700000000, []byte(`{"event":"link.created"}`)) == want {
+		t.Error("signature does not depend on the body")
+	}
+}
+
+func TestWebhookSendSignsRequest(t *testing.T) {
+	const secret = "whsec_test"
+	delivery := &models.WebhookDelivery{
+		ID:        "delivery-1",
+		EventID:   "event-1",
+		EventType: models.WebhookLinkClicked,
+		Payload:   []byte(`{"id":"event-1"}`),
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var timestamp int64
+		var signature string
+		if _, err := fmt.Sscanf(r.Header.Get("Slink-Signature"), "t=%d,v1=%s", &timestamp, &signature); err != nil {
+			t.Errorf("Slink-Signature %q: %v", r.Header.Get("Slink-Signature"), err)
+		}
+		if want := SignWebhookPayload(secret, timestamp, body); signature != want {
+			t.Errorf("v1 = %s, want %s", signature, want)
+		}
+		if r.Header.Get("Slink-Event") != delivery.EventType || r.Header.Get("Slink-Delivery") != delivery.ID {
+			t.Errorf("event headers = %q, %q", r.Header.Get("Slink-Event"), r.Header.Get("Slink-Delivery"))
+		}
+		if r.URL.Path == "/fail" {
+			http.Error(w, "nope", http.StatusBadGateway)
+		}
+	}))
+	defer srv.Close()
+
+	d := &WebhookDispatcher{client: srv.Client()}
+
+	status, err := d.send(context.Background(), &webhookEndpointRecord{URL: srv.URL + "/ok", Secret: secret}, delivery)
+	if err != nil || status == nil || *status != http.StatusOK {
+		t.Errorf("send = %v, %v; want 200, nil", status, err)
+	}
+
+	status, err = d.send(context.Background(), &webhookEndpointRecord{URL: srv.URL + "/fail", Secret: secret}, delivery)
+	if err == nil || !strings.Contains(err.Error(), "nope") || status == nil || *status != http.StatusBadGateway {
+		t.Errorf("send = %v, %v; want 502 and the response body in the error", status, err)
+	}
+}
+
+func TestWebhookSendStopsWithContext(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("request sent despite a cancelled context")
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	d := &WebhookDispatcher{client: srv.Client()}
+	delivery := &models.WebhookDelivery{ID: "delivery-1", Payload: []byte(`{}`)}
+	if _, err := d.send(ctx, &webhookEndpointRecord{URL: srv.URL, Secret: "whsec_test"}, delivery); !errors.Is(err, context.Canceled) {
+		t.Errorf("send = %v, want context.Canceled", err)
+	}
+}
@@ -0,0 +1,54 @@
+package shortcode
+
+import (
+	"log/slog"
+	"sync"
+)
+
+// collisionWindow is how many recent candidates the collision rate is
+// measured over.
+const collisionWindow = 100
+
+// Resizable is implemented by generators with a configurable code length.
+type Resizable interface {
+	Generator
+	Length() int
+	SetLength(n int)
+}
+
+// Adaptive grows the code length of a Resizable generator by one whenever
+// more than threshold percent of the last collisionWindow candidates were
+// already taken, up to maxLength. The grown length only lives in memory; a
+// restart goes back to the configured length and grows again if needed.
+type Adaptive struct {
+	Resizable
+	maxLength int
+	threshold int
+
+	mu         sync.Mutex
+	samples    int
+	collisions int
+}
+
+func NewAdaptive(gen Resizable, maxLength, thresholdPercent int) *Adaptive {
+	return &Adaptive{Resizable: gen, maxLength: maxLength, threshold: thresholdPercent}
+}
+
+func (a *Adaptive) Observe(collided bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.samples++
+	if collided {
+		a.collisions++
+	}
+	if a.samples < collisionWindow {
+		return
+	}
+
+	if a.collisions*100 > a.threshold*a.samples && a.Length() < a.maxLength {
+		a.SetLength(a.Length() + 1)
+		slog.Info("Short code collision rate too high, growing length", "collision_percent", a.collisions*100/a.samples, "samples", a.samples, "length", a.Length())
+	}
+	a.samples, a.collisions = 0, 0
+}
@@ -0,0 +1,60 @@
+package shortcode
+
+import (
+	"context"
+	"fmt"
+)
+
+// Counter encodes the next value of a sequence in the alphabet, left-padded
+// to a minimum length. Codes never repeat, but are guessable.
+type Counter struct {
+	seq       Sequence
+	alphabet  string
+	minLength int
+}
+
+func NewCounter(seq Sequence, alphabet string, minLength int) *Counter {
+	return &Counter{seq: seq, alphabet: alphabet, minLength: minLength}
+}
+
+func (c *Counter) Generate(ctx context.Context, _ string, _ int) (string, error) {
+	id, err := c.seq.NextShortCodeID(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get next short code ID: %v", err)
+	}
+	if id < 0 {
+		return "", fmt.Errorf("short code sequence returned %d", id)
+	}
+
+	code := toID(uint64(id), []byte(c.alphabet))
+	for len(code) < c.minLength {
+		code = append([]byte{c.alphabet[0]}, code...)
+	}
+	return string(code), nil
+}
+
+// Sqids encodes the next value of a sequence with Sqids, which keeps codes
+// unique like Counter while making consecutive ones look unrelated.
+type Sqids struct {
+	seq     Sequence
+	encoder *sqidsEncoder
+}
+
+func NewSqids(seq Sequence, alphabet string, minLength int) (*Sqids, error) {
+	encoder, err := newSqidsEncoder(alphabet, minLength, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &Sqids{seq: seq, encoder: encoder}, nil
+}
+
+func (s *Sqids) Generate(ctx context.Context, _ string, _ int) (string, error) {
+	id, err := s.seq.NextShortCodeID(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get next short code ID: %v", err)
+	}
+	if id < 0 {
+		return "", fmt.Errorf("short code sequence returned %d", id)
+	}
+	return s.encoder.encode([]uint64{uint64(id)})
+}
@@ -0,0 +1,76 @@
+package shortcode
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"math/big"
+	"sync/atomic"
+
+	"slink-backend/internal/utils"
+)
+
+// Random draws each character uniformly from the alphabet.
+type Random struct {
+	alphabet string
+	length   atomic.Int32
+}
+
+func NewRandom(alphabet string, length int) *Random {
+	r := &Random{alphabet: alphabet}
+	r.length.Store(int32(length))
+	return r
+}
+
+func (r *Random) Generate(context.Context, string, int) (string, error) {
+	return utils.RandomString(r.alphabet, r.Length())
+}
+
+func (r *Random) Length() int {
+	return int(r.length.Load())
+}
+
+func (r *Random) SetLength(n int) {
+	r.length.Store(int32(n))
+}
+
+// Hash derives the code from a SHA-256 of the URL, so the same URL always
+// gets the same code unless that code is taken, in which case the attempt
+// number is mixed in.
+type Hash struct {
+	alphabet string
+	length   atomic.Int32
+}
+
+func NewHash(alphabet string, length int) *Hash {
+	h := &Hash{alphabet: alphabet}
+	h.length.Store(int32(length))
+	return h
+}
+
+func (h *Hash) Generate(_ context.Context, originalURL string, attempt int) (string, error) {
+	input := originalURL
+	if attempt > 0 {
+		input = fmt.Sprintf("%s#%d", originalURL, attempt)
+	}
+	sum := sha256.Sum256([]byte(input))
+
+	n := h.Length()
+	num := new(big.Int).SetBytes(sum[:])
+	base := big.NewInt(int64(len(h.alphabet)))
+	mod := new(big.Int)
+	code := make([]byte, n)
+	for i := range code {
+		num.DivMod(num, base, mod)
+		code[i] = h.alphabet[mod.Int64()]
+	}
+	return string(code), nil
+}
+
+func (h *Hash) Length() int {
+	return int(h.length.Load())
+}
+
+func (h *Hash) SetLength(n int) {
+	h.length.Store(int32(n))
+}
@@ -0,0 +1,72 @@
+// Package shortcode generates the codes used in short URLs.
+package shortcode
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"slink-backend/internal/config"
+)
+
+const DefaultAlphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// Generator produces candidate short codes. attempt counts the candidates
+// for the same URL that were already taken, so deterministic strategies can
+// derive a different code on retry.
+type Generator interface {
+	Generate(ctx context.Context, originalURL string, attempt int) (string, error)
+}
+
+// Observer is implemented by generators that adapt to how often their codes
+// collide with existing ones.
+type Observer interface {
+	Observe(collided bool)
+}
+
+// Sequence hands out increasing integers, e.g. from a database sequence.
+type Sequence interface {
+	NextShortCodeID(ctx context.Context) (int64, error)
+}
+
+// Codes only use characters that are safe in a URL path and can't be
+// mistaken for the preview suffix "+".
+var alphabetRegex = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// New returns the generator selected by SHORT_CODE_STRATEGY.
+func New(cfg *config.Config, seq Sequence) (Generator, error) {
+	alphabet := cfg.ShortCodeAlphabet
+	if err := validateAlphabet(alphabet); err != nil {
+		return nil, err
+	}
+	if cfg.ShortCodeLength < 1 || cfg.ShortCodeMaxLength < cfg.ShortCodeLength {
+		return nil, fmt.Errorf("short code length must be at least 1 and at most SHORT_CODE_MAX_LENGTH")
+	}
+
+	switch cfg.ShortCodeStrategy {
+	case "random", "":
+		return NewAdaptive(NewRandom(alphabet, cfg.ShortCodeLength), cfg.ShortCodeMaxLength, cfg.ShortCodeGrowThreshold), nil
+	case "hash":
+		return NewAdaptive(NewHash(alphabet, cfg.ShortCodeLength), cfg.ShortCodeMaxLength, cfg.ShortCodeGrowThreshold), nil
+	case "counter":
+		return NewCounter(seq, alphabet, cfg.ShortCodeLength), nil
+	case "sqids":
+		return NewSqids(seq, alphabet, cfg.ShortCodeLength)
+	default:
+		return nil, fmt.Errorf("unknown short code strategy: %s", cfg.ShortCodeStrategy)
+	}
+}
+
+func validateAlphabet(alphabet string) error {
+	if len(alphabet) < 3 || !alphabetRegex.MatchString(alphabet) {
+		return fmt.Errorf("short code alphabet must be at least 3 of A-Z, a-z, 0-9, - and _")
+	}
+	seen := map[rune]bool{}
+	for _, r := range alphabet {
+		if seen[r] {
+			return fmt.Errorf("short code alphabet contains %q twice", r)
+		}
+		seen[r] = true
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+package shortcode
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// fakeSequence counts up from next.
+type fakeSequence struct {
+	next int64
+}
+
+func (s *fakeSequence) NextShortCodeID(context.Context) (int64, error) {
+	id := s.next
+	s.next++
+	return id, nil
+}
+
+func TestCounter(t *testing.T) {
+	c := NewCounter(&fakeSequence{}, "abc", 4)
+
+	seen := map[string]bool{}
+	for i := 0; i < 200; i++ {
+		code, err := c.Generate(context.Background(), "", 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if seen[code] {
+			t.Fatalf("code %q repeated", code)
+		}
+		seen[code] = true
+
+		switch i {
+		case 0:
+			if code != "aaaa" {
+				t.Errorf("first code = %q, want aaaa", code)
+			}
+		case 5:
+			// 5 is "12" in base 3.
+			if code != "aabc" {
+				t.Errorf("code for 5 = %q, want aabc", code)
+			}
+		}
+		if len(code) < 4 {
+			t.Errorf("code %q is shorter than the minimum", code)
+		}
+	}
+
+	// 3^4 = 81 is the first value that needs a fifth character.
+	long, err := NewCounter(&fakeSequence{next: 81}, "abc", 4).Generate(context.Background(), "", 0)
+	if err != nil || long != "baaaa" {
+		t.Errorf("code for 81 = %q, %v; want baaaa", long, err)
+	}
+
+	if _, err := NewCounter(&fakeSequence{next: -1}, "abc", 4).Generate(context.Background(), "", 0); err == nil {
+		t.Error("accepted a negative sequence value")
+	}
+}
+
+func TestSqidsGenerator(t *testing.T) {
+	s, err := NewSqids(&fakeSequence{}, DefaultAlphabet, 6)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	seen := map[string]bool{}
+	for i := 0; i < 1000; i++ {
+		code, err := s.Generate(context.Background(), "", 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(code) < 6 {
+			t.Errorf("code %q is shorter than the minimum", code)
+		}
+		if seen[code] {
+			t.Fatalf("code %q repeated", code)
+		}
+		seen[code] = true
+	}
+}
+
+func TestHash(t *testing.T) {
+	h := NewHash(DefaultAlphabet, 7)
+	generate := func(url string, attempt int) string {
+		code, err := h.Generate(context.Background(), url, attempt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return code
+	}
+
+	first := generate("https://example.com/a", 0)
+	if len(first) != 7 {
+		t.Errorf("code %q has length %d, want 7", first, len(first))
+	}
+	if again := generate("https://example.com/a", 0); again != first {
+		t.Errorf("same URL gave %q and %q", first, again)
+	}
+	if other := generate("https://example.com/b", 0); other == first {
+		t.Errorf("different URLs both gave %q", first)
+	}
+
+	retry := generate("https://example.com/a", 1)
+	if retry == first {
+		t.Errorf("attempt 1 gave the same code as attempt 0: %q", retry)
+	}
+	if again := generate("https://example.com/a", 1); again != retry {
+		t.Errorf("attempt 1 gave %q and %q", retry, again)
+	}
+	if generate("https://example.com/a", 2) == retry {
+		t.Error("attempts 1 and 2 gave the same code")
+	}
+
+	h.SetLength(9)
+	if code := generate("https://example.com/a", 0); len(code) != 9 || !strings.HasPrefix(code, first) {
+		t.Errorf("after growing to 9: %q, want %q extended", code, first)
+	}
+}
+
+func TestAdaptive(t *testing.T) {
+	observe := func(a *Adaptive, collisions, samples int) {
+		for i := 0; i < samples; i++ {
+			a.Observe(i < collisions)
+		}
+	}
+
+	a := NewAdaptive(NewRandom(DefaultAlphabet, 6), 8, 10)
+
+	observe(a, 10, collisionWindow)
+	if a.Length() != 6 {
+		t.Errorf("grew to %d at exactly the threshold", a.Length())
+	}
+
+	observe(a, 11, collisionWindow-1)
+	if a.Length() != 6 {
+		t.Errorf("grew to %d before the window was full", a.Length())
+	}
+	a.Observe(false)
+	if a.Length() != 7 {
+		t.Errorf("length = %d after a window above the threshold, want 7", a.Length())
+	}
+
+	for i := 0; i < 5; i++ {
+		observe(a, collisionWindow, collisionWindow)
+	}
+	if a.Length() != 8 {
+		t.Errorf("length = %d, want it capped at 8", a.Length())
+	}
+
+	code, err := a.Generate(context.Background(), "", 0)
+	if err != nil || len(code) != 8 {
+		t.Errorf("Generate = %q, %v; want an 8 character code", code, err)
+	}
+}
+
+func TestValidateAlphabet(t *testing.T) {
+	valid := []string{DefaultAlphabet, "abc", "0123456789", "a-b_c"}
+	for _, alphabet := range valid {
+		if err := validateAlphabet(alphabet); err != nil {
+			t.Errorf("validateAlphabet(%q) = %v", alphabet, err)
+		}
+	}
+
+	invalid := []string{"", "ab", "abca", "abc+", "abc/", "abc d", "abcé", "aAbBa"}
+	for _, alphabet := range invalid {
+		if err := validateAlphabet(alphabet); err == nil {
+			t.Errorf("validateAlphabet(%q) accepted it", alphabet)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package shortcode
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// sqidsEncoder implements the encoding half of Sqids (https://sqids.org),
+// which turns integers into short, non-sequential looking IDs. Only single
+// numbers are ever encoded here, but the algorithm is kept general so codes
+// match other Sqids implementations configured with the same alphabet.
+type sqidsEncoder struct {
+	alphabet  []byte
+	minLength int
+	blocklist []string
+}
+
+func newSqidsEncoder(alphabet string, minLength int, blocklist []string) (*sqidsEncoder, error) {
+	if len(alphabet) < 3 {
+		return nil, fmt.Errorf("sqids alphabet must contain at least 3 characters")
+	}
+	if minLength < 0 || minLength > 255 {
+		return nil, fmt.Errorf("sqids minimum length must be between 0 and 255")
+	}
+
+	lower := strings.ToLower(alphabet)
+	var words []string
+	for _, word := range blocklist {
+		word = strings.ToLower(word)
+		if len(word) < 3 {
+			continue
+		}
+		if strings.Trim(word, lower) == "" {
+			words = append(words, word)
+		}
+	}
+
+	return &sqidsEncoder{
+		alphabet:  shuffle([]byte(alphabet)),
+		minLength: minLength,
+		blocklist: words,
+	}, nil
+}
+
+func (e *sqidsEncoder) encode(numbers []uint64) (string, error) {
+	if len(numbers) == 0 {
+		return "", nil
+	}
+	return e.encodeNumbers(numbers, 0)
+}
+
+func (e *sqidsEncoder) encodeNumbers(numbers []uint64, increment int) (string, error) {
+	n := len(e.alphabet)
+	if increment > n {
+		return "", fmt.Errorf("reached max attempts to re-generate the ID")
+	}
+
+	offset := len(numbers)
+	for i, v := range numbers {
+		offset += int(e.alphabet[v%uint64(n)]) + i
+	}
+	offset = (offset%n + increment) % n
+
+	alphabet := append(slices.Clone(e.alphabet[offset:]), e.alphabet[:offset]...)
+	prefix := alphabet[0]
+	slices.Reverse(alphabet)
+
+	id := []byte{prefix}
+	for i, v := range numbers {
+		id = append(id, toID(v, alphabet[1:])...)
+		if i < len(numbers)-1 {
+			id = append(id, alphabet[0])
+			alphabet = shuffle(alphabet)
+		}
+	}
+
+	if len(id) < e.minLength {
+		id = append(id, alphabet[0])
+		for len(id) < e.minLength {
+			alphabet = shuffle(alphabet)
+			id = append(id, alphabet[:min(e.minLength-len(id), n)]...)
+		}
+	}
+
+	if e.isBlocked(string(id)) {
+		return e.encodeNumbers(numbers, increment+1)
+	}
+	return string(id), nil
+}
+
+func (e *sqidsEncoder) isBlocked(id string) bool {
+	id = strings.ToLower(id)
+	for _, word := range e.blocklist {
+		if len(word) > len(id) {
+			continue
+		}
+		switch {
+		case len(id) <= 3 || len(word) <= 3:
+			if id == word {
+				return true
+			}
+		case strings.ContainsAny(word, "0123456789"):
+			if strings.HasPrefix(id, word) || strings.HasSuffix(id, word) {
+				return true
+			}
+		case strings.Contains(id, word):
+			return true
+		}
+	}
+	return false
+}
+
+// shuffle is Sqids' deterministic alphabet permutation.
+func shuffle(alphabet []byte) []byte {
+	chars := slices.Clone(alphabet)
+	for i, j := 0, len(chars)-1; j > 0; i, j = i+1, j-1 {
+		r := (i*j + int(chars[i]) + int(chars[j])) % len(chars)
+		chars[i], chars[r] = chars[r], chars[i]
+	}
+	return chars
+}
+
+func toID(num uint64, alphabet []byte) []byte {
+	var id []byte
+	n := uint64(len(alphabet))
+	for {
+		id = append(id, alphabet[num%n])
+		num /= n
+		if num == 0 {
+			break
+		}
+	}
+	slices.Reverse(id)
+	return id
+}
@@ -0,0 +1,72 @@
+package shortcode
+
+import (
+	"strings"
+	"testing"
+)
+
+// Vectors from the Sqids spec's test suite
+// (https://github.com/sqids/sqids-spec/tree/main/tests).
+func TestSqidsEncode(t *testing.T) {
+	tests := []struct {
+		name      string
+		alphabet  string
+		minLength int
+		blocklist []string
+		numbers   []uint64
+		want      string
+	}{
+		{"simple", DefaultAlphabet, 0, nil, []uint64{1, 2, 3}, "86Rf07"},
+		{"zero", DefaultAlphabet, 0, nil, []uint64{0}, "bM"},
+		{"one", DefaultAlphabet, 0, nil, []uint64{1}, "Uk"},
+		{"two", DefaultAlphabet, 0, nil, []uint64{2}, "gb"},
+		{"nine", DefaultAlphabet, 0, nil, []uint64{9}, "nJ"},
+		{"pair", DefaultAlphabet, 0, nil, []uint64{0, 0}, "SvIz"},
+		{"pair incremented", DefaultAlphabet, 0, nil, []uint64{0, 9}, "moxr"},
+		{"custom alphabet", "0123456789abcdef", 0, nil, []uint64{1, 2, 3}, "489158"},
+		{"min length", DefaultAlphabet, 10, nil, []uint64{1, 2, 3}, "86Rf07xd4z"},
+		{"min length of the alphabet", DefaultAlphabet, len(DefaultAlphabet), nil, []uint64{1, 2, 3}, "86Rf07xd4zBmiJXQG6otHEbew02c3PWsUOLZxADhCpKj7aVFv9I8RquYrNlSTM"},
+		{"blocked word", DefaultAlphabet, 0, []string{"ArUO"}, []uint64{100000}, "QyG4"},
+		{
+			"blocked words", DefaultAlphabet, 0,
+			[]string{"JSwXFaosAN", "OCjV9JK64o", "rBHf", "79SM", "7tE6"},
+			[]uint64{1_000_000, 2_000_000}, "1aYeB7bRUt",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := newSqidsEncoder(tt.alphabet, tt.minLength, tt.blocklist)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := e.encode(tt.numbers)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("encode(%v) = %q, want %q", tt.numbers, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSqidsEncoderOptions(t *testing.T) {
+	if _, err := newSqidsEncoder("ab", 0, nil); err == nil {
+		t.Error("accepted a 2 character alphabet")
+	}
+	for _, minLength := range []int{-1, 256} {
+		if _, err := newSqidsEncoder(DefaultAlphabet, minLength, nil); err == nil {
+			t.Errorf("accepted minimum length %d", minLength)
+		}
+	}
+
+	// Words with characters outside the alphabet can never match and are
+	// dropped; short words are too.
+	e, err := newSqidsEncoder("abcdef", 0, []string{"ab", "abcx", "ABC"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(e.blocklist, ",") != "abc" {
+		t.Errorf("blocklist = %q, want [abc]", e.blocklist)
+	}
+}
@@ -0,0 +1,63 @@
+package tracing
+
+import (
+	"context"
+
+	"slink-backend/internal/logging"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// redactingExporter applies the log redaction to spans before export. Error
+// messages reach spans verbatim, through span statuses, recorded errors and
+// instrumentation such as otelgin's gin.errors attribute, so scrubbing at
+// the exporter catches them all.
+type redactingExporter struct {
+	sdktrace.SpanExporter
+}
+
+func (e redactingExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	redacted := make([]sdktrace.ReadOnlySpan, len(spans))
+	for i, span := range spans {
+		redacted[i] = redactedSpan{span}
+	}
+	return e.SpanExporter.ExportSpans(ctx, redacted)
+}
+
+// redactedSpan scrubs the status description and every string attribute of
+// the span and its events.
+type redactedSpan struct {
+	sdktrace.ReadOnlySpan
+}
+
+func (s redactedSpan) Status() sdktrace.Status {
+	status := s.ReadOnlySpan.Status()
+	status.Description = logging.Scrub(status.Description)
+	return status
+}
+
+func (s redactedSpan) Attributes() []attribute.KeyValue {
+	return redactAttributes(s.ReadOnlySpan.Attributes())
+}
+
+func (s redactedSpan) Events() []sdktrace.Event {
+	events := s.ReadOnlySpan.Events()
+	redacted := make([]sdktrace.Event, len(events))
+	for i, event := range events {
+		event.Attributes = redactAttributes(event.Attributes)
+		redacted[i] = event
+	}
+	return redacted
+}
+
+func redactAttributes(attrs []attribute.KeyValue) []attribute.KeyValue {
+	redacted := make([]attribute.KeyValue, len(attrs))
+	for i, kv := range attrs {
+		if kv.Value.Type() == attribute.STRING {
+			kv.Value = attribute.StringValue(logging.Scrub(kv.Value.AsString()))
+		}
+		redacted[i] = kv
+	}
+	return redacted
+}
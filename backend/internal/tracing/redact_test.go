@@ -0,0 +1,52 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestRedactingExporterScrubsSpans(t *testing.T) {
+	exported := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(redactingExporter{exported}))
+	defer provider.Shutdown(context.Background())
+
+	_, span := provider.Tracer("test").Start(context.Background(), "update users")
+	err := errors.New("duplicate key: email jane@example.com")
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+	span.SetAttributes(attribute.String("gin.errors", "Error #01: "+err.Error()), attribute.String("client.address", "203.0.113.7"), attribute.Int("status", 500))
+	span.End()
+
+	spans := exported.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("exported %d spans, want 1", len(spans))
+	}
+	got := spans[0]
+
+	texts := []string{got.Status.Description}
+	for _, kv := range got.Attributes {
+		texts = append(texts, kv.Value.Emit())
+	}
+	for _, event := range got.Events {
+		for _, kv := range event.Attributes {
+			texts = append(texts, kv.Value.Emit())
+		}
+	}
+	all := strings.Join(texts, "\n")
+	if strings.Contains(all, "jane@") || strings.Contains(all, "203.0.113.7") {
+		t.Errorf("exported span leaks PII:\n%s", all)
+	}
+	if got.Status.Description != "duplicate key: email ***@example.com" {
+		t.Errorf("status = %q", got.Status.Description)
+	}
+	if len(got.Events) != 1 {
+		t.Errorf("exported %d events, want the recorded error", len(got.Events))
+	}
+}
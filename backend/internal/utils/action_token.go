@@ -0,0 +1,73 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+const (
+	PurposeEmailVerification = "email_verification"
+	PurposePasswordReset     = "password_reset"
+	PurposeMFAChallenge      = "mfa_challenge"
+)
+
+// actionAudience marks action tokens so they can never pass as session
+// tokens, which are signed with the same key.
+const actionAudience = "slink-action"
+
+// ActionClaims are carried by the single-purpose tokens mailed to users.
+// Fingerprint is derived from the user state the token acts on, so a token
+// stops validating once it has been used (e.g. the password hash changed).
+type ActionClaims struct {
+	UserID      string `json:"user_id"`
+	Purpose     string `json:"purpose"`
+	Fingerprint string `json:"fp"`
+	jwt.RegisteredClaims
+}
+
+func GenerateActionToken(userID, purpose, fingerprint string, ttl time.Duration) (string, error) {
+	now := time.Now()
+	claims := &ActionClaims{
+		UserID:      userID,
+		Purpose:     purpose,
+		Fingerprint: fingerprint,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.New().String(),
+			Audience:  jwt.ClaimStrings{actionAudience},
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(jwtSecret())
+}
+
+func ValidateActionToken(tokenString, purpose string) (*ActionClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &ActionClaims{}, keyFunc, jwt.WithAudience(actionAudience))
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(*ActionClaims)
+	if !ok || !token.Valid {
+		return nil, fmt.Errorf("invalid token")
+	}
+	if claims.Purpose != purpose {
+		return nil, fmt.Errorf("invalid token purpose")
+	}
+
+	return claims, nil
+}
+
+// Fingerprint returns a short, non-reversible digest of the given values.
+func Fingerprint(values ...string) string {
+	sum := sha256.Sum256([]byte(strings.Join(values, "\x00")))
+	return base64.RawURLEncoding.EncodeToString(sum[:12])
+}
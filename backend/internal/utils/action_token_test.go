@@ -0,0 +1,100 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestActionTokenRoundTrip(t *testing.T) {
+	SetJWTSecret("test-secret")
+
+	token, err := GenerateActionToken("user-1", PurposeEmailVerification, "fp", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := ValidateActionToken(token, PurposeEmailVerification)
+	if err != nil {
+		t.Fatalf("ValidateActionToken: %v", err)
+	}
+	if claims.UserID != "user-1" || claims.Fingerprint != "fp" || claims.Purpose != PurposeEmailVerification {
+		t.Errorf("claims = %+v", claims)
+	}
+}
+
+func TestActionTokenWrongPurpose(t *testing.T) {
+	SetJWTSecret("test-secret")
+
+	token, err := GenerateActionToken("user-1", PurposeEmailVerification, "fp", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ValidateActionToken(token, PurposePasswordReset); err == nil {
+		t.Error("verification token accepted as a password reset token")
+	}
+}
+
+func TestActionTokenExpired(t *testing.T) {
+	SetJWTSecret("test-secret")
+
+	token, err := GenerateActionToken("user-1", PurposePasswordReset, "fp", -time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ValidateActionToken(token, PurposePasswordReset); err == nil {
+		t.Error("expired token accepted")
+	}
+}
+
+func TestActionTokenWrongKey(t *testing.T) {
+	SetJWTSecret("test-secret")
+	token, err := GenerateActionToken("user-1", PurposePasswordReset, "fp", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	SetJWTSecret("other-secret")
+	if _, err := ValidateActionToken(token, PurposePasswordReset); err == nil {
+		t.Error("token signed with another key accepted")
+	}
+}
+
+func TestActionTokensAreNotSessions(t *testing.T) {
+	SetJWTSecret("test-secret")
+
+	for _, purpose := range []string{PurposeEmailVerification, PurposePasswordReset, PurposeMFAChallenge} {
+		token, err := GenerateActionToken("user-1", purpose, "fp", time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ValidateToken(token); err == nil {
+			t.Errorf("%s token accepted as a session token", purpose)
+		}
+	}
+}
+
+func TestSessionTokensAreNotActionTokens(t *testing.T) {
+	SetJWTSecret("test-secret")
+
+	token, err := GenerateToken("user-1", "user@example.com", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ValidateToken(token); err != nil {
+		t.Fatalf("session token rejected: %v", err)
+	}
+	if _, err := ValidateActionToken(token, PurposePasswordReset); err == nil {
+		t.Error("session token accepted as an action token")
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	if Fingerprint("a", "b") != Fingerprint("a", "b") {
+		t.Error("fingerprint is not deterministic")
+	}
+	if Fingerprint("a", "bc") == Fingerprint("ab", "c") {
+		t.Error("fingerprint ignores value boundaries")
+	}
+	if Fingerprint("hash-1") == Fingerprint("hash-2") {
+		t.Error("fingerprint doesn't change with its input")
+	}
+}
@@ -2,7 +2,6 @@ package utils
 
 import (
 	"fmt"
-	"os"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -11,13 +10,22 @@ import (
 type Claims struct {
 	UserID string `json:"user_id"`
 	Email  string `json:"email"`
+	// TokenVersion must match the user's current version; bumping it on the
+	// user revokes every token issued before.
+	TokenVersion int `json:"ver"`
+	// MFAEnrollmentPending marks tokens issued to users who must enroll in
+	// two-factor authentication before they can use the rest of the API.
+	MFAEnrollmentPending bool `json:"mfa_enrollment_pending,omitempty"`
+	// Purpose is only decoded to reject action tokens; sessions never set it.
+	Purpose string `json:"purpose,omitempty"`
 	jwt.RegisteredClaims
 }
 
-func GenerateToken(userID, email string) (string, error) {
+func GenerateToken(userID, email string, tokenVersion int) (string, error) {
 	claims := &Claims{
-		UserID: userID,
-		Email:  email,
+		UserID:       userID,
+		Email:        email,
+		TokenVersion: tokenVersion,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)), // 24 hours
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
@@ -26,36 +34,72 @@ func GenerateToken(userID, email string) (string, error) {
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 
-	// Get JWT secret from environment
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		jwtSecret = "your-secret-key" // Default for development
+	return token.SignedString(jwtSecret())
+}
+
+func GenerateEnrollmentToken(userID, email string, tokenVersion int) (string, error) {
+	claims := &Claims{
+		UserID:               userID,
+		Email:                email,
+		TokenVersion:         tokenVersion,
+		MFAEnrollmentPending: true,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(15 * time.Minute)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
 	}
 
-	return token.SignedString([]byte(jwtSecret))
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+
+	return token.SignedString(jwtSecret())
 }
 
 func ValidateToken(tokenString string) (*Claims, error) {
-	// Get JWT secret from environment
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		jwtSecret = "your-secret-key" // Default for development
-	}
-
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(jwtSecret), nil
-	})
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, keyFunc)
 
 	if err != nil {
 		return nil, err
 	}
 
-	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
-		return claims, nil
+	claims, ok := token.Claims.(*Claims)
+	if !ok || !token.Valid {
+		return nil, fmt.Errorf("invalid token")
+	}
+	// Action tokens are signed with the same key; they carry a purpose
+	// and an audience, session tokens neither.
+	if claims.Purpose != "" || len(claims.Audience) > 0 {
+		return nil, fmt.Errorf("not a session token")
+	}
+
+	return claims, nil
+}
+
+var (
+	jwtKey          []byte
+	jwtPreviousKeys []jwt.VerificationKey
+)
+
+// SetJWTSecret sets the key tokens are signed with. Tokens signed with one of
+// the previous keys are still accepted, so rotating the key doesn't log
+// everyone out. It must be called before any token is issued or validated.
+func SetJWTSecret(key string, previous ...string) {
+	jwtKey = []byte(key)
+	jwtPreviousKeys = nil
+	for _, p := range previous {
+		jwtPreviousKeys = append(jwtPreviousKeys, []byte(p))
 	}
+}
+
+func jwtSecret() []byte {
+	return jwtKey
+}
 
-	return nil, fmt.Errorf("invalid token")
+func keyFunc(token *jwt.Token) (interface{}, error) {
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	if len(jwtPreviousKeys) == 0 {
+		return jwtSecret(), nil
+	}
+	return jwt.VerificationKeySet{Keys: append([]jwt.VerificationKey{jwtSecret()}, jwtPreviousKeys...)}, nil
 }
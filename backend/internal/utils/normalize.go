@@ -0,0 +1,67 @@
+package utils
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+var defaultPorts = map[string]string{
+	"http":  "80",
+	"https": "443",
+}
+
+// NormalizeURL returns a canonical form of rawURL used to recognise the same
+// destination written differently: scheme and host are lowercased, default
+// ports and trailing slashes dropped, and query parameters sorted by name.
+// The result is only for comparison; links keep redirecting to the URL as
+// entered.
+func NormalizeURL(rawURL string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return "", fmt.Errorf("invalid URL: %v", err)
+	}
+	if u.Host == "" {
+		return "", fmt.Errorf("invalid URL: missing host")
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	host, port := strings.ToLower(u.Hostname()), u.Port()
+	if port == defaultPorts[u.Scheme] {
+		port = ""
+	}
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	if port != "" {
+		host = net.JoinHostPort(strings.Trim(host, "[]"), port)
+	}
+	u.Host = host
+
+	u.Path = strings.TrimRight(u.Path, "/")
+	u.RawPath = strings.TrimRight(u.RawPath, "/")
+
+	if u.RawQuery != "" {
+		params := strings.Split(u.RawQuery, "&")
+		kept := params[:0]
+		for _, p := range params {
+			if p != "" {
+				kept = append(kept, p)
+			}
+		}
+		sort.SliceStable(kept, func(i, j int) bool {
+			return queryKey(kept[i]) < queryKey(kept[j])
+		})
+		u.RawQuery = strings.Join(kept, "&")
+	}
+	u.ForceQuery = false
+
+	return u.String(), nil
+}
+
+func queryKey(param string) string {
+	key, _, _ := strings.Cut(param, "=")
+	return key
+}
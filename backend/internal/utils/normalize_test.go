@@ -0,0 +1,69 @@
+package utils
+
+import "testing"
+
+func TestNormalizeURLMatchesEquivalentURLs(t *testing.T) {
+	groups := [][]string{
+		{
+			"https://example.com/path",
+			"HTTPS://EXAMPLE.COM/path",
+			"https://example.com:443/path",
+			"https://example.com/path/",
+			"  https://example.com/path  ",
+			"https://example.com/path?",
+		},
+		{
+			"https://example.com/search?a=1&b=2",
+			"https://example.com/search?b=2&a=1",
+			"https://example.com/search/?a=1&&b=2",
+		},
+		{
+			"http://example.com",
+			"http://example.com:80/",
+		},
+		{
+			"http://[::1]:8080/x",
+			"http://[::1]:8080/x/",
+		},
+	}
+	for _, group := range groups {
+		want, err := NormalizeURL(group[0])
+		if err != nil {
+			t.Fatalf("NormalizeURL(%q): %v", group[0], err)
+		}
+		for _, rawURL := range group[1:] {
+			if got, err := NormalizeURL(rawURL); err != nil || got != want {
+				t.Errorf("NormalizeURL(%q) = %q, %v; want %q", rawURL, got, err, want)
+			}
+		}
+	}
+}
+
+func TestNormalizeURLKeepsDistinctURLsApart(t *testing.T) {
+	pairs := [][2]string{
+		{"http://example.com/path", "https://example.com/path"},
+		{"https://example.com/Path", "https://example.com/path"},
+		{"https://example.com:8443/path", "https://example.com/path"},
+		{"https://example.com/?a=1", "https://example.com/?a=2"},
+		{"https://example.com/?a=1&a=2", "https://example.com/?a=2&a=1"},
+		{"https://example.com/#top", "https://example.com/"},
+	}
+	for _, pair := range pairs {
+		a, errA := NormalizeURL(pair[0])
+		b, errB := NormalizeURL(pair[1])
+		if errA != nil || errB != nil {
+			t.Fatalf("NormalizeURL: %v, %v", errA, errB)
+		}
+		if a == b {
+			t.Errorf("%q and %q both normalize to %q", pair[0], pair[1], a)
+		}
+	}
+}
+
+func TestNormalizeURLRejectsURLsWithoutHost(t *testing.T) {
+	for _, rawURL := range []string{"", "example.com/path", "/relative", "https://", "http://%zz"} {
+		if got, err := NormalizeURL(rawURL); err == nil {
+			t.Errorf("NormalizeURL(%q) = %q, want an error", rawURL, got)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package utils
+
+import (
+	"crypto/rand"
+	"fmt"
+)
+
+// RandomString returns n characters drawn uniformly from charset. Bytes that
+// would bias the result towards the start of charset are discarded.
+func RandomString(charset string, n int) (string, error) {
+	if len(charset) == 0 || len(charset) > 256 {
+		return "", fmt.Errorf("charset must contain between 1 and 256 characters")
+	}
+
+	limit := 256 - 256%len(charset)
+	result := make([]byte, 0, n)
+	buf := make([]byte, n)
+	for len(result) < n {
+		if _, err := rand.Read(buf); err != nil {
+			return "", err
+		}
+		for _, b := range buf {
+			if int(b) < limit {
+				result = append(result, charset[int(b)%len(charset)])
+				if len(result) == n {
+					break
+				}
+			}
+		}
+	}
+	return string(result), nil
+}
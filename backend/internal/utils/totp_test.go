@@ -0,0 +1,114 @@
+package utils
+
+import (
+	"regexp"
+	"testing"
+	"time"
+)
+
+// rfc6238Secret is the RFC 6238 SHA-1 test key "12345678901234567890".
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCodeRFC6238(t *testing.T) {
+	// The RFC lists 8-digit codes; ours are their last 6 digits.
+	for _, tc := range []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	} {
+		got, err := TOTPCode(rfc6238Secret, time.Unix(tc.unix, 0))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tc.want {
+			t.Errorf("TOTPCode at %d = %s, want %s", tc.unix, got, tc.want)
+		}
+	}
+}
+
+func TestValidateTOTPSkew(t *testing.T) {
+	now := time.Unix(1111111109, 0)
+	for _, tc := range []struct {
+		offset time.Duration
+		ok     bool
+	}{
+		{0, true},
+		{-30 * time.Second, true},
+		{30 * time.Second, true},
+		{-60 * time.Second, false},
+		{60 * time.Second, false},
+	} {
+		code, err := TOTPCode(rfc6238Secret, now.Add(tc.offset))
+		if err != nil {
+			t.Fatal(err)
+		}
+		step, ok := ValidateTOTP(rfc6238Secret, code, now)
+		if ok != tc.ok {
+			t.Errorf("code from %v: ok = %v, want %v", tc.offset, ok, tc.ok)
+		}
+		if ok && step != now.Add(tc.offset).Unix()/totpPeriod {
+			t.Errorf("code from %v matched step %d", tc.offset, step)
+		}
+	}
+}
+
+func TestValidateTOTPRejectsMalformed(t *testing.T) {
+	now := time.Now()
+	if _, ok := ValidateTOTP(rfc6238Secret, "12345", now); ok {
+		t.Error("short code accepted")
+	}
+	if _, ok := ValidateTOTP("not base32!", "123456", now); ok {
+		t.Error("invalid secret accepted")
+	}
+}
+
+func TestGenerateTOTPSecret(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, err := TOTPCode(secret, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := ValidateTOTP(secret, code, time.Now()); !ok {
+		t.Error("code for a generated secret rejected")
+	}
+}
+
+func TestGenerateRecoveryCodes(t *testing.T) {
+	codes, err := GenerateRecoveryCodes(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(codes) != 10 {
+		t.Fatalf("got %d codes, want 10", len(codes))
+	}
+	format := regexp.MustCompile(`^[a-z2-9]{5}-[a-z2-9]{5}$`)
+	seen := map[string]bool{}
+	for _, code := range codes {
+		if !format.MatchString(code) {
+			t.Errorf("code %q doesn't look like xxxxx-xxxxx", code)
+		}
+		if seen[code] {
+			t.Errorf("code %q generated twice", code)
+		}
+		seen[code] = true
+	}
+}
+
+func TestHashRecoveryCodeNormalizes(t *testing.T) {
+	want := HashRecoveryCode("abcde-fghjk")
+	for _, input := range []string{"ABCDE-FGHJK", " abcde-fghjk ", "abcde - fghjk"} {
+		if got := HashRecoveryCode(input); got != want {
+			t.Errorf("HashRecoveryCode(%q) differs from the canonical form", input)
+		}
+	}
+	if HashRecoveryCode("abcde-fghjm") == want {
+		t.Error("different codes hash the same")
+	}
+}
@@ -0,0 +1,35 @@
+package utils
+
+import "strings"
+
+// socialCrawlers are User-Agent substrings of link unfurlers that read Open
+// Graph tags instead of following redirects.
+var socialCrawlers = []string{
+	"facebookexternalhit",
+	"facebot",
+	"twitterbot",
+	"linkedinbot",
+	"slackbot",
+	"discordbot",
+	"telegrambot",
+	"whatsapp",
+	"pinterest",
+	"redditbot",
+	"applebot",
+	"skypeuripreview",
+	"vkshare",
+	"embedly",
+	"iframely",
+	"mastodon",
+}
+
+// IsSocialCrawler reports whether userAgent belongs to a link preview bot.
+func IsSocialCrawler(userAgent string) bool {
+	ua := strings.ToLower(userAgent)
+	for _, bot := range socialCrawlers {
+		if strings.Contains(ua, bot) {
+			return true
+		}
+	}
+	return false
+}
@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"fmt"
 	"net/url"
 	"regexp"
 	"strings"
@@ -41,25 +42,35 @@ func IsValidURL(input string) bool {
 	return true
 }
 
-func IsValidCustomAlias(alias string) bool {
-	if len(alias) == 0 || len(alias) > 10 {
-		return false
-	}
-
-	// Only allow alphanumeric characters and hyphens
-	validAliasRegex := `^[a-zA-Z0-9\-]+$`
-	return regexp.MustCompile(validAliasRegex).MatchString(alias)
-}
+const (
+	MaxTags      = 20
+	maxTagLength = 32
+)
 
-func GenerateShortCode() string {
-	const charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
-	const length = 6
+var tagRegex = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
 
-	result := make([]byte, length)
-	for i := range result {
-		// Simple pseudo-random generation
-		result[i] = charset[i%len(charset)]
+// NormalizeTags lowercases and de-duplicates tags, rejecting anything that
+// isn't a short slug.
+func NormalizeTags(tags []string) ([]string, error) {
+	normalized := make([]string, 0, len(tags))
+	seen := map[string]bool{}
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		if len(tag) > maxTagLength || !tagRegex.MatchString(tag) {
+			return nil, fmt.Errorf("invalid tag %q", tag)
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	if len(normalized) > MaxTags {
+		return nil, fmt.Errorf("at most %d tags are allowed", MaxTags)
 	}
+	return normalized, nil
+}
 
-	return string(result)
+func IsValidTag(tag string) bool {
+	return len(tag) <= maxTagLength && tagRegex.MatchString(tag)
 }
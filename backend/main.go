@@ -1,79 +1,268 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
 	"log"
+	"log/slog"
 	"net/http"
+	"net/url"
 	"os"
 
+	"slink-backend/internal/alias"
 	"slink-backend/internal/api"
 	"slink-backend/internal/config"
+	"slink-backend/internal/cors"
 	"slink-backend/internal/database"
+	"slink-backend/internal/health"
+	"slink-backend/internal/logging"
+	"slink-backend/internal/mailer"
+	"slink-backend/internal/models"
+	"slink-backend/internal/notify"
+	"slink-backend/internal/secure"
+	"slink-backend/internal/services"
+	"slink-backend/internal/shortcode"
+	"slink-backend/internal/tracing"
+	"slink-backend/internal/utils"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
 func main() {
-	if err := godotenv.Load(); err != nil {
-		log.Println("No .env file found")
+	envErr := godotenv.Load()
+
+	cfg, err := config.Load(os.Args[1:])
+	switch {
+	case errors.Is(err, flag.ErrHelp):
+		return
+	case errors.Is(err, config.ErrPrintConfig):
+		if err := cfg.Print(os.Stdout); err != nil {
+			log.Fatal("Failed to print configuration: ", err)
+		}
+		return
+	case err != nil:
+		log.Fatal("Invalid configuration:\n", err)
 	}
 
 	gin.SetMode(gin.DebugMode)
+	if cfg.IsProduction() {
+		gin.SetMode(gin.ReleaseMode)
+	}
+	utils.SetJWTSecret(cfg.JWTSecret, cfg.JWTPreviousSecrets...)
+
+	logger, err := logging.New(cfg)
+	if err != nil {
+		log.Fatal("Failed to configure logging: ", err)
+	}
+	// Also routes the standard library's log package through the logger.
+	slog.SetDefault(logger)
+	if envErr != nil {
+		slog.Info("No .env file found")
+	}
+
+	shutdownTracing, err := tracing.Setup(cfg)
+	if err != nil {
+		fatal("Failed to configure tracing", err)
+	}
+
+	supabaseClient, err := database.ConnectSupabase(cfg.SupabaseURL, cfg.SupabaseKey, cfg.SupabaseProjectRef, cfg.SupabaseDebug)
+	if err != nil {
+		fatal("Failed to connect to Supabase", err)
+	}
 
-	cfg := config.Load()
+	mail, err := mailer.New(cfg)
+	if err != nil {
+		fatal("Failed to configure mailer", err)
+	}
 
-	supabaseClient, err := database.ConnectSupabase(cfg.SupabaseURL, cfg.SupabaseKey, cfg.SupabaseProjectRef)
+	codes, err := shortcode.New(cfg, supabaseClient)
 	if err != nil {
-		log.Fatal("Failed to connect to Supabase:", err)
+		fatal("Failed to configure short codes", err)
 	}
 
-	router := gin.Default()
+	aliases, err := alias.New(cfg)
+	if err != nil {
+		fatal("Failed to configure alias policy", err)
+	}
 
-	router.Use(func(c *gin.Context) {
-		c.Header("Access-Control-Allow-Origin", "*")
-		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization")
+	checker := health.NewChecker(
+		cfg.HealthCheckTimeout, cfg.HealthCacheTTL)
+	checker.Add("database", supabaseClient.Ping)
 
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(204)
-			return
+	if cfg.LinkCheckEnabled {
+		notifier, err := notify.New(cfg, mail)
+		if err != nil {
+			fatal("Failed to configure notifier", err)
 		}
+		linkChecker := services.NewLinkChecker(supabaseClient, notifier, cfg)
+		checker.Add("link_checker", health.Recent(linkChecker.LastRun, cfg.HealthWorkerMaxAge))
+		go linkChecker.Run(context.Background())
+	}
 
-		c.Next()
+	if cfg.WebhooksEnabled {
+		dispatcher := services.NewWebhookDispatcher(services.NewWebhookService(supabaseClient), cfg)
+		checker.Add("webhook_dispatcher", health.Recent(dispatcher.LastRun, cfg.HealthWorkerMaxAge))
+		go dispatcher.Run(context.Background())
+	}
+
+	baseURL, err := url.Parse(cfg.BaseURL)
+	if err != nil {
+		fatal("Invalid BASE_URL", err)
+	}
+	secureHeaders, err := secure.New(secure.Policy{
+		HSTSMaxAge:            cfg.HSTSMaxAge,
+		HSTSIncludeSubdomains: cfg.HSTSIncludeSubdomains,
+		FrameAncestors:        cfg.CSPFrameAncestors,
+		ReferrerPolicy:        cfg.ReferrerPolicy,
+		ForceHTTPS:            cfg.ForceHTTPS,
+		Host:                  baseURL.Host,
+		ExemptPaths:           []string{"/health"},
+		TrustedProxies:        cfg.TrustedProxies,
 	})
+	if err != nil {
+		fatal("Failed to configure security headers", err)
+	}
 
-	apiHandler := api.NewHandler(supabaseClient, cfg)
+	router := gin.New()
+	if err := router.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		fatal("Failed to configure trusted proxies", err)
+	}
+	router.Use(
+		api.RequestID(),
+		otelgin.Middleware(cfg.TracingServiceName),
+		api.RequestMetrics(),
+		api.RequestLogger(),
+		api.ErrorHandler(),
+		api.Recovery(),
+		secureHeaders,
+	)
+	router.NoRoute(api.NoRoute)
+
+	apiOrigins := cfg.CORSAPIOrigins
+	if len(apiOrigins) == 0 {
+		apiOrigins = []string{cfg.FrontendURL}
+	}
+	apiCORS, err := cors.New(cors.Policy{
+		Origins:        apiOrigins,
+		Methods:        cfg.CORSAPIMethods,
+		Headers:        cfg.CORSAPIHeaders,
+		ExposedHeaders: cfg.CORSExposedHeaders,
+		Credentials:    cfg.CORSAPICredentials,
+		MaxAge:         cfg.CORSMaxAge,
+	})
+	if err != nil {
+		fatal("Failed to configure CORS for the API", err)
+	}
+	publicCORS, err := cors.New(cors.Policy{
+		Origins:        cfg.CORSPublicOrigins,
+		Methods:        []string{http.MethodGet, http.MethodHead},
+		Headers:        []string{"X-Request-ID"},
+		ExposedHeaders: cfg.CORSExposedHeaders,
+		MaxAge:         cfg.CORSMaxAge,
+	})
+	if err != nil {
+		fatal("Failed to configure CORS for redirects", err)
+	}
 
-	apiGroup := router.Group("/api")
+	apiHandler := api.NewHandler(supabaseClient, mail, codes, aliases, cfg)
+
+	apiGroup := router.Group("/api", apiCORS)
 	{
-		apiGroup.POST("/register", apiHandler.Register)
-		apiGroup.POST("/login", apiHandler.Login)
+		apiGroup.OPTIONS("/*path", cors.Preflight)
+
+		// The audit log export streams, so it can't share the read deadline.
+		apiGroup.GET("/audit", api.AuditDeadline(cfg.ReadTimeout, cfg.ExportTimeout), apiHandler.AuthMiddleware(), apiHandler.EnforceMFAEnrollment(), apiHandler.ListAuditEvents)
+
+		timed := apiGroup.Group("/", api.Deadline(cfg.ReadTimeout, cfg.WriteTimeout))
+		timed.POST("/register", apiHandler.Register)
+		timed.POST("/login", apiHandler.Login)
+		timed.POST("/login/mfa", apiHandler.LoginMFA)
+		timed.GET("/verify-email", apiHandler.VerifyEmail)
+		timed.POST("/verify-email", apiHandler.VerifyEmail)
+		timed.POST("/password/forgot", apiHandler.ForgotPassword)
+		timed.POST("/password/reset", apiHandler.ResetPassword)
 
-		protected := apiGroup.Group("/")
-		protected.Use(apiHandler.AuthMiddleware())
+		mfa := timed.Group("/2fa")
+		mfa.Use(apiHandler.AuthMiddleware())
+		{
+			mfa.POST("/enroll", apiHandler.EnrollTOTP)
+			mfa.POST("/confirm", apiHandler.ConfirmTOTP)
+			mfa.POST("/disable", apiHandler.EnforceMFAEnrollment(), apiHandler.DisableTOTP)
+			mfa.POST("/recovery-codes", apiHandler.EnforceMFAEnrollment(), apiHandler.RegenerateRecoveryCodes)
+		}
+
+		protected := timed.Group("/")
+		protected.Use(apiHandler.AuthMiddleware(), apiHandler.EnforceMFAEnrollment())
 		{
 			protected.GET("/profile", apiHandler.GetProfile)
-			protected.POST("/shorten", apiHandler.ShortenURL)
+			protected.PATCH("/profile", apiHandler.UpdateProfile)
+			protected.DELETE("/profile", apiHandler.DeleteProfile)
+			protected.POST("/profile/password", apiHandler.ChangePassword)
+			protected.GET("/profile/qr-logo", apiHandler.GetQRLogo)
+			protected.PUT("/profile/qr-logo", apiHandler.UploadQRLogo)
+			protected.DELETE("/profile/qr-logo", apiHandler.DeleteQRLogo)
+			protected.POST("/qr/batch", apiHandler.BatchQR)
+			protected.POST("/verify-email/resend", apiHandler.ResendVerification)
+			protected.POST("/shorten", apiHandler.RequireVerifiedEmail(), apiHandler.Idempotent(), apiHandler.ShortenURL)
 			protected.GET("/links", apiHandler.GetLinksByUser)
+			protected.PATCH("/links/:id", apiHandler.UpdateLink)
+			protected.DELETE("/links/:id", apiHandler.DeleteLink)
+			protected.GET("/links/:id/stats", apiHandler.GetLinkStats)
+			protected.GET("/aliases/suggest", apiHandler.SuggestAlias)
+			protected.GET("/webhooks", apiHandler.ListWebhooks)
+			protected.POST("/webhooks", apiHandler.CreateWebhook)
+			protected.PATCH("/webhooks/:id", apiHandler.UpdateWebhook)
+			protected.DELETE("/webhooks/:id", apiHandler.DeleteWebhook)
+			protected.POST("/webhooks/:id/rotate-secret", apiHandler.RotateWebhookSecret)
+			protected.GET("/webhooks/:id/deliveries", apiHandler.ListWebhookDeliveries)
+			protected.POST("/webhooks/:id/deliveries/:deliveryID/redeliver", apiHandler.RedeliverWebhook)
+		}
+
+		admin := timed.Group("/admin")
+		admin.Use(apiHandler.AuthMiddleware(), apiHandler.EnforceMFAEnrollment(), apiHandler.RequireRole(models.RoleAdmin))
+		{
+			admin.GET("/users", apiHandler.AdminListUsers)
+			admin.POST("/users/:id/disable", apiHandler.AdminDisableUser)
+			admin.POST("/users/:id/enable", apiHandler.AdminEnableUser)
+			admin.POST("/users/:id/require-mfa", apiHandler.AdminSetMFARequired)
+			admin.PUT("/users/:id/plan", apiHandler.AdminSetPlan)
+			admin.GET("/links", apiHandler.AdminListLinks)
+			admin.POST("/links/:id/takedown", apiHandler.AdminTakeDownLink)
+			admin.DELETE("/links/:id/takedown", apiHandler.AdminRestoreLink)
+			admin.GET("/stats", apiHandler.AdminStats)
 		}
 
-		apiGroup.GET("/qr/:shortCode", apiHandler.GenerateQR)
+		timed.GET("/qr/:shortCode", apiHandler.GenerateQR)
 	}
 
-	router.GET("/:shortCode", apiHandler.RedirectURL)
+	router.GET("/:shortCode", publicCORS, api.Deadline(cfg.RedirectTimeout, cfg.RedirectTimeout), apiHandler.RedirectURL)
+	router.OPTIONS("/:shortCode", publicCORS, cors.Preflight)
 
-	router.GET("/health", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{
-			"status":  "ok",
-			"service": "slink-backend",
-		})
-	})
+	if cfg.MetricsEnabled {
+		router.GET("/metrics", api.Metrics(cfg.MetricsToken))
+	}
+
+	checker.Add("qr_cache", apiHandler.CheckQRCache)
+	router.GET("/health", api.Live)
+	router.GET("/health/live", api.Live)
+	router.GET("/health/ready", api.Ready(checker))
 
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
+	// Every route is registered by now; aliases must not shadow any of them.
+	aliases.ReserveRoutes(router.Routes())
+
+	slog.Info("Server starting", "port", cfg.Port, "environment", cfg.Environment)
+	err = http.ListenAndServe(fmt.Sprintf(":%d", cfg.Port), router)
+	if err := shutdownTracing(context.Background()); err != nil {
+		slog.Error("Failed to flush traces", "error", err)
 	}
+	fatal("Server stopped", err)
+}
 
-	log.Printf("Server starting on port %s", port)
-	log.Fatal(http.ListenAndServe(":"+port, router))
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
 }
@@ -0,0 +1,9 @@
+-- Token revocation and account deletion support for users
+-- Run this after two_factor_auth.sql
+
+ALTER TABLE users
+ADD COLUMN token_version INTEGER NOT NULL DEFAULT 0,
+ADD COLUMN deleted_at TIMESTAMP WITH TIME ZONE;
+
+-- Anonymized links keep redirecting without an owner
+ALTER TABLE urls ALTER COLUMN user_id DROP NOT NULL;
@@ -0,0 +1,30 @@
+-- Roles, account disabling and link takedowns for the admin API
+-- Run this after account_management.sql
+
+ALTER TABLE users
+ADD COLUMN role VARCHAR(20) NOT NULL DEFAULT 'user' CHECK (role IN ('user', 'admin')),
+ADD COLUMN disabled BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX idx_users_role ON users(role);
+
+ALTER TABLE urls
+ADD COLUMN taken_down_at TIMESTAMP WITH TIME ZONE,
+ADD COLUMN takedown_reason TEXT;
+
+-- System-wide numbers for GET /api/admin/stats
+CREATE OR REPLACE FUNCTION admin_stats()
+RETURNS JSON AS $$
+    SELECT json_build_object(
+        'total_users', (SELECT COUNT(*) FROM users WHERE deleted_at IS NULL),
+        'disabled_users', (SELECT COUNT(*) FROM users WHERE disabled),
+        'admin_users', (SELECT COUNT(*) FROM users WHERE role = 'admin'),
+        'total_links', (SELECT COUNT(*) FROM urls),
+        'taken_down_links', (SELECT COUNT(*) FROM urls WHERE taken_down_at IS NOT NULL),
+        'total_clicks', (SELECT COALESCE(SUM(hit_count), 0) FROM urls),
+        'links_last_7_days', (SELECT COUNT(*) FROM urls WHERE created_at > NOW() - INTERVAL '7 days'),
+        'signups_last_7_days', (SELECT COUNT(*) FROM users WHERE created_at > NOW() - INTERVAL '7 days')
+    );
+$$ LANGUAGE sql STABLE SECURITY DEFINER;
+
+-- Promote the first administrator manually, e.g.:
+-- UPDATE users SET role = 'admin' WHERE email = 'admin@example.com';
@@ -0,0 +1,13 @@
+-- Alias policy: user plans, longer aliases and case-insensitive uniqueness
+-- Run this after link_dedup.sql
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS plan VARCHAR(32) NOT NULL DEFAULT 'free';
+
+-- Pro plans may use aliases up to 64 characters, and custom aliases double
+-- as short codes.
+ALTER TABLE urls ALTER COLUMN short_code TYPE VARCHAR(64);
+ALTER TABLE urls ALTER COLUMN custom_alias TYPE VARCHAR(64);
+
+-- Aliases that only differ in case can't coexist. Rename any existing
+-- duplicates before running this.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_urls_custom_alias_lower ON urls(LOWER(custom_alias));
@@ -0,0 +1,50 @@
+-- Append-only audit log of security- and link-relevant events
+-- Run this after admin_roles.sql
+
+CREATE TABLE audit_logs (
+    id UUID DEFAULT gen_random_uuid() PRIMARY KEY,
+    actor_id UUID,
+    action VARCHAR(64) NOT NULL,
+    target_type VARCHAR(32),
+    target_id TEXT,
+    ip VARCHAR(64),
+    user_agent TEXT,
+    before JSONB,
+    after JSONB,
+    changes JSONB,
+    metadata JSONB,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- actor_id deliberately has no foreign key: entries must outlive deleted users
+
+CREATE INDEX idx_audit_logs_created_at ON audit_logs(created_at);
+CREATE INDEX idx_audit_logs_actor_id ON audit_logs(actor_id);
+CREATE INDEX idx_audit_logs_action ON audit_logs(action);
+CREATE INDEX idx_audit_logs_target ON audit_logs(target_type, target_id);
+
+-- Reject any attempt to change or remove existing entries
+CREATE OR REPLACE FUNCTION audit_logs_append_only()
+RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_logs is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_logs_no_update
+    BEFORE UPDATE OR DELETE ON audit_logs
+    FOR EACH ROW
+    EXECUTE FUNCTION audit_logs_append_only();
+
+CREATE TRIGGER audit_logs_no_truncate
+    BEFORE TRUNCATE ON audit_logs
+    FOR EACH STATEMENT
+    EXECUTE FUNCTION audit_logs_append_only();
+
+ALTER TABLE audit_logs ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY "Allow audit inserts" ON audit_logs
+    FOR INSERT WITH CHECK (true);
+
+CREATE POLICY "Allow audit reads" ON audit_logs
+    FOR SELECT USING (true);
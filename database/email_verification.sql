@@ -0,0 +1,9 @@
+-- Email verification state for users
+-- Run this after creating the users table
+
+ALTER TABLE users
+ADD COLUMN email_verified BOOLEAN NOT NULL DEFAULT FALSE,
+ADD COLUMN email_verified_at TIMESTAMP WITH TIME ZONE;
+
+-- Existing accounts were created before verification existed; treat them as verified
+UPDATE users SET email_verified = TRUE, email_verified_at = NOW();
@@ -0,0 +1,51 @@
+-- Reusing links for identical destinations and Idempotency-Key support
+-- Run this after short_code_sequence.sql
+
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS normalized_url TEXT;
+CREATE INDEX IF NOT EXISTS idx_urls_user_normalized_url ON urls(user_id, normalized_url);
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS reuse_existing_links BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    key VARCHAR(255) NOT NULL,
+    request_hash VARCHAR(64) NOT NULL,
+    status_code INTEGER,
+    response_body JSONB,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (user_id, key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_created_at ON idempotency_keys(created_at);
+
+-- Claims p_key for p_user_id, replacing a use older than p_ttl_seconds.
+-- Returns the key's row; claimed is true when the caller now owns it.
+CREATE OR REPLACE FUNCTION claim_idempotency_key(p_user_id UUID, p_key TEXT, p_request_hash TEXT, p_ttl_seconds INTEGER)
+RETURNS TABLE (
+    user_id UUID,
+    key VARCHAR,
+    request_hash VARCHAR,
+    status_code INTEGER,
+    response_body JSONB,
+    created_at TIMESTAMP WITH TIME ZONE,
+    claimed BOOLEAN
+) AS $$
+BEGIN
+    DELETE FROM idempotency_keys k
+    WHERE k.user_id = p_user_id AND k.key = p_key
+      AND k.created_at < NOW() - make_interval(secs => p_ttl_seconds);
+
+    RETURN QUERY
+    INSERT INTO idempotency_keys AS k (user_id, key, request_hash)
+    VALUES (p_user_id, p_key, p_request_hash)
+    ON CONFLICT ON CONSTRAINT idempotency_keys_pkey DO NOTHING
+    RETURNING k.user_id, k.key, k.request_hash, k.status_code, k.response_body, k.created_at, TRUE;
+
+    IF NOT FOUND THEN
+        RETURN QUERY
+        SELECT k.user_id, k.key, k.request_hash, k.status_code, k.response_body, k.created_at, FALSE
+        FROM idempotency_keys k
+        WHERE k.user_id = p_user_id AND k.key = p_key;
+    END IF;
+END;
+$$ LANGUAGE plpgsql VOLATILE SECURITY DEFINER;
@@ -0,0 +1,37 @@
+-- Optional expiry for links
+-- Run this after link_health.sql
+
+ALTER TABLE urls
+    ADD COLUMN IF NOT EXISTS expires_at TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN IF NOT EXISTS expired_event_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_urls_expires_at ON urls(expires_at) WHERE expired_event_at IS NULL;
+
+-- Expired links no longer redirect, so there is no point checking them
+CREATE OR REPLACE FUNCTION links_due_for_check(p_limit INTEGER)
+RETURNS SETOF urls AS $$
+    SELECT u.*
+    FROM urls u
+    LEFT JOIN link_health h ON h.url_id = u.id
+    WHERE u.taken_down_at IS NULL
+      AND (u.expires_at IS NULL OR u.expires_at > NOW())
+      AND (h.url_id IS NULL OR h.next_check_at <= NOW())
+    ORDER BY h.next_check_at ASC NULLS FIRST
+    LIMIT p_limit;
+$$ LANGUAGE sql STABLE SECURITY DEFINER;
+
+-- Marks links whose expiry has passed as announced and returns them, so the
+-- link.expired webhook fires once per expiry
+CREATE OR REPLACE FUNCTION claim_expired_links(p_limit INTEGER)
+RETURNS SETOF urls AS $$
+    UPDATE urls
+    SET expired_event_at = NOW()
+    WHERE id IN (
+        SELECT id FROM urls
+        WHERE expires_at <= NOW() AND expired_event_at IS NULL
+        ORDER BY expires_at
+        LIMIT p_limit
+        FOR UPDATE SKIP LOCKED
+    )
+    RETURNING *;
+$$ LANGUAGE sql VOLATILE SECURITY DEFINER;
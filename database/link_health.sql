@@ -0,0 +1,30 @@
+-- Destination health checks for links
+-- Run this after admin_roles.sql
+
+CREATE TABLE IF NOT EXISTS link_health (
+    url_id UUID PRIMARY KEY REFERENCES urls(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL CHECK (status IN ('healthy', 'failing')),
+    status_code INTEGER,
+    latency_ms BIGINT NOT NULL DEFAULT 0,
+    redirect_chain TEXT[] NOT NULL DEFAULT '{}',
+    error TEXT,
+    consecutive_failures INTEGER NOT NULL DEFAULT 0,
+    checked_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    next_check_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    notified_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_link_health_next_check_at ON link_health(next_check_at);
+
+-- Links that were never checked come first, then those whose next check is
+-- most overdue. Taken-down links are not checked.
+CREATE OR REPLACE FUNCTION links_due_for_check(p_limit INTEGER)
+RETURNS SETOF urls AS $$
+    SELECT u.*
+    FROM urls u
+    LEFT JOIN link_health h ON h.url_id = u.id
+    WHERE u.taken_down_at IS NULL
+      AND (h.url_id IS NULL OR h.next_check_at <= NOW())
+    ORDER BY h.next_check_at ASC NULLS FIRST
+    LIMIT p_limit;
+$$ LANGUAGE sql STABLE SECURITY DEFINER;
@@ -0,0 +1,11 @@
+-- Destination metadata and custom Open Graph tags for link previews
+-- Run this after creating the urls table
+
+ALTER TABLE urls
+    ADD COLUMN IF NOT EXISTS meta_title TEXT,
+    ADD COLUMN IF NOT EXISTS meta_description TEXT,
+    ADD COLUMN IF NOT EXISTS meta_image TEXT,
+    ADD COLUMN IF NOT EXISTS meta_fetched_at TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN IF NOT EXISTS og_title VARCHAR(200),
+    ADD COLUMN IF NOT EXISTS og_description VARCHAR(500),
+    ADD COLUMN IF NOT EXISTS og_image TEXT;
@@ -0,0 +1,4 @@
+-- Per-link option to keep the short link out of the destination's referrer
+-- Run this after alias_policy.sql
+
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS hide_referrer BOOLEAN NOT NULL DEFAULT FALSE;
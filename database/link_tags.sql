@@ -0,0 +1,6 @@
+-- Free-form tags on links, used for filtering and bulk QR export
+-- Run this after creating the urls table
+
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS tags TEXT[] NOT NULL DEFAULT '{}';
+
+CREATE INDEX IF NOT EXISTS idx_urls_tags ON urls USING GIN (tags);
@@ -0,0 +1,13 @@
+-- Per-user logos drawn into the center of QR codes
+-- Run this after creating the users table
+
+CREATE TABLE qr_logos (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    data TEXT NOT NULL, -- base64-encoded PNG
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+ALTER TABLE qr_logos ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY "Allow logo access for QR rendering" ON qr_logos
+    FOR ALL USING (true) WITH CHECK (true);
@@ -0,0 +1,41 @@
+-- Separate QR code scans from other clicks, per printed code variant
+-- Run this after creating the urls table
+
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS qr_scan_count INTEGER NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS qr_scan_variants (
+    url_id UUID NOT NULL REFERENCES urls(id) ON DELETE CASCADE,
+    variant VARCHAR(32) NOT NULL,
+    scans INTEGER NOT NULL DEFAULT 0,
+    last_scanned_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (url_id, variant)
+);
+
+-- Counts a QR scan atomically: the scan adds to both hit_count and
+-- qr_scan_count, and to the variant's own counter when one is given.
+CREATE OR REPLACE FUNCTION record_qr_scan(p_short_code TEXT, p_variant TEXT)
+RETURNS VOID
+LANGUAGE plpgsql
+SECURITY DEFINER
+AS $$
+DECLARE
+    v_url_id UUID;
+BEGIN
+    UPDATE urls
+    SET hit_count = hit_count + 1,
+        qr_scan_count = qr_scan_count + 1,
+        updated_at = NOW()
+    WHERE short_code = p_short_code
+    RETURNING id INTO v_url_id;
+
+    IF v_url_id IS NULL OR COALESCE(p_variant, '') = '' THEN
+        RETURN;
+    END IF;
+
+    INSERT INTO qr_scan_variants (url_id, variant, scans, last_scanned_at)
+    VALUES (v_url_id, p_variant, 1, NOW())
+    ON CONFLICT (url_id, variant) DO UPDATE
+    SET scans = qr_scan_variants.scans + 1,
+        last_scanned_at = NOW();
+END;
+$$;
@@ -0,0 +1,42 @@
+-- Row level security for the tables added since audit_logs.sql, and no
+-- default EXECUTE on the SECURITY DEFINER functions
+-- Run this after link_referrer.sql
+
+ALTER TABLE qr_scan_variants ENABLE ROW LEVEL SECURITY;
+ALTER TABLE link_health ENABLE ROW LEVEL SECURITY;
+ALTER TABLE webhook_endpoints ENABLE ROW LEVEL SECURITY;
+ALTER TABLE webhook_deliveries ENABLE ROW LEVEL SECURITY;
+ALTER TABLE idempotency_keys ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY "Allow scan counts for the backend" ON qr_scan_variants
+    FOR ALL USING (true) WITH CHECK (true);
+
+CREATE POLICY "Allow link health for the backend" ON link_health
+    FOR ALL USING (true) WITH CHECK (true);
+
+CREATE POLICY "Allow webhook endpoints for the backend" ON webhook_endpoints
+    FOR ALL USING (true) WITH CHECK (true);
+
+CREATE POLICY "Allow webhook deliveries for the backend" ON webhook_deliveries
+    FOR ALL USING (true) WITH CHECK (true);
+
+CREATE POLICY "Allow idempotency keys for the backend" ON idempotency_keys
+    FOR ALL USING (true) WITH CHECK (true);
+
+-- Functions are executable by PUBLIC unless revoked. The backend calls these
+-- with SUPABASE_KEY, so only its roles keep EXECUTE.
+REVOKE EXECUTE ON FUNCTION admin_stats() FROM PUBLIC;
+REVOKE EXECUTE ON FUNCTION record_qr_scan(TEXT, TEXT) FROM PUBLIC;
+REVOKE EXECUTE ON FUNCTION links_due_for_check(INTEGER) FROM PUBLIC;
+REVOKE EXECUTE ON FUNCTION claim_expired_links(INTEGER) FROM PUBLIC;
+REVOKE EXECUTE ON FUNCTION claim_webhook_deliveries(INTEGER, INTEGER) FROM PUBLIC;
+REVOKE EXECUTE ON FUNCTION next_short_code_id() FROM PUBLIC;
+REVOKE EXECUTE ON FUNCTION claim_idempotency_key(UUID, TEXT, TEXT, INTEGER) FROM PUBLIC;
+
+GRANT EXECUTE ON FUNCTION admin_stats() TO anon, service_role;
+GRANT EXECUTE ON FUNCTION record_qr_scan(TEXT, TEXT) TO anon, service_role;
+GRANT EXECUTE ON FUNCTION links_due_for_check(INTEGER) TO anon, service_role;
+GRANT EXECUTE ON FUNCTION claim_expired_links(INTEGER) TO anon, service_role;
+GRANT EXECUTE ON FUNCTION claim_webhook_deliveries(INTEGER, INTEGER) TO anon, service_role;
+GRANT EXECUTE ON FUNCTION next_short_code_id() TO anon, service_role;
+GRANT EXECUTE ON FUNCTION claim_idempotency_key(UUID, TEXT, TEXT, INTEGER) TO anon, service_role;
@@ -0,0 +1,9 @@
+-- Sequence behind the counter and sqids short code strategies
+-- Run this after creating the urls table
+
+CREATE SEQUENCE IF NOT EXISTS short_code_seq START WITH 1;
+
+CREATE OR REPLACE FUNCTION next_short_code_id()
+RETURNS BIGINT AS $$
+    SELECT nextval('short_code_seq');
+$$ LANGUAGE sql VOLATILE SECURITY DEFINER;
@@ -0,0 +1,9 @@
+-- TOTP two-factor authentication for users
+-- Run this after email_verification.sql
+
+ALTER TABLE users
+ADD COLUMN totp_secret VARCHAR(64),
+ADD COLUMN totp_enabled BOOLEAN NOT NULL DEFAULT FALSE,
+ADD COLUMN totp_last_step BIGINT NOT NULL DEFAULT 0,
+ADD COLUMN recovery_codes TEXT[] NOT NULL DEFAULT '{}',
+ADD COLUMN mfa_required BOOLEAN NOT NULL DEFAULT FALSE;